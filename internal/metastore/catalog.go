@@ -159,7 +159,30 @@ type QueryCoordCatalog interface {
 	ReleasePartition(collection int64, partitions ...int64) error
 	ReleaseReplicas(collectionID int64) error
 	ReleaseReplica(collection, replica int64) error
-	SaveResourceGroup(rgs ...*querypb.ResourceGroup) error
-	RemoveResourceGroup(rgName string) error
-	GetResourceGroups() ([]*querypb.ResourceGroup, error)
+	SaveResourceGroup(ctx context.Context, rgs ...*querypb.ResourceGroup) error
+	RemoveResourceGroup(ctx context.Context, rgName string) error
+	GetResourceGroup(ctx context.Context, rgName string) (*querypb.ResourceGroup, error)
+	GetResourceGroups(ctx context.Context) ([]*querypb.ResourceGroup, error)
+	SaveNodeResourceGroup(ctx context.Context, node int64, rgName string) error
+	RemoveNodeResourceGroup(ctx context.Context, node int64) error
+	GetNodeResourceGroups(ctx context.Context) (map[int64]string, error)
+	SaveResourceGroupLimit(ctx context.Context, rgName string, maxCapacity int32) error
+	RemoveResourceGroupLimit(ctx context.Context, rgName string) error
+	GetResourceGroupLimits(ctx context.Context) (map[string]int32, error)
+	SaveResourceGroupMinCapacity(ctx context.Context, rgName string, minCapacity int32) error
+	RemoveResourceGroupMinCapacity(ctx context.Context, rgName string) error
+	GetResourceGroupMinCapacities(ctx context.Context) (map[string]int32, error)
+	SaveResourceGroupLabels(ctx context.Context, rgName string, labels map[string]string) error
+	RemoveResourceGroupLabels(ctx context.Context, rgName string) error
+	GetResourceGroupLabels(ctx context.Context) (map[string]map[string]string, error)
+	SaveSealedResourceGroup(ctx context.Context, rgName string) error
+	RemoveSealedResourceGroup(ctx context.Context, rgName string) error
+	GetSealedResourceGroups(ctx context.Context) ([]string, error)
+	SaveOverlapResourceGroup(ctx context.Context, rgName string) error
+	RemoveOverlapResourceGroup(ctx context.Context, rgName string) error
+	GetOverlapResourceGroups(ctx context.Context) ([]string, error)
+	SaveResourceGroupProportion(ctx context.Context, rgName string, fraction float64) error
+	RemoveResourceGroupProportion(ctx context.Context, rgName string) error
+	GetResourceGroupProportions(ctx context.Context) (map[string]float64, error)
+	RemoveResourceGroupAttributes(ctx context.Context, rgName string) error
 }
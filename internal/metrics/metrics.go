@@ -76,6 +76,7 @@ const (
 	cacheStateLabelName      = "cache_state"
 	indexCountLabelName      = "indexed_field_count"
 	requestScope             = "scope"
+	resourceGroupLabelName   = "resource_group"
 )
 
 var (
@@ -92,6 +92,56 @@ var (
 			Name:      "querynode_num",
 			Help:      "number of QueryNodes managered by QueryCoord",
 		}, []string{})
+
+	QueryCoordResourceGroupCapacity = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "resource_group_capacity",
+			Help:      "capacity of resource group",
+		}, []string{
+			resourceGroupLabelName,
+		})
+
+	QueryCoordResourceGroupNodeNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "resource_group_node_num",
+			Help:      "number of QueryNodes in resource group",
+		}, []string{
+			resourceGroupLabelName,
+		})
+
+	QueryCoordResourceGroupLackNodeNum = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "resource_group_lack_node_num",
+			Help:      "number of QueryNodes which resource group lacks",
+		}, []string{
+			resourceGroupLabelName,
+		})
+
+	QueryCoordResourceGroupRecoverDroppedNodeNum = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "resource_group_recover_dropped_node_num",
+			Help:      "number of stored QueryNodes dropped from resource group in recovery since they no longer exist",
+		}, []string{
+			resourceGroupLabelName,
+		})
+
+	QueryCoordResourceGroupChurnRate = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: typeutil.QueryCoordRole,
+			Name:      "resource_group_churn_rate",
+			Help:      "number of QueryNode up and down events per minute in resource group",
+		}, []string{
+			resourceGroupLabelName,
+		})
 )
 
 //RegisterQueryCoord registers QueryCoord metrics
@@ -104,4 +154,9 @@ func RegisterQueryCoord(registry *prometheus.Registry) {
 	registry.MustRegister(QueryCoordReleaseLatency)
 	registry.MustRegister(QueryCoordTaskNum)
 	registry.MustRegister(QueryCoordNumQueryNodes)
+	registry.MustRegister(QueryCoordResourceGroupCapacity)
+	registry.MustRegister(QueryCoordResourceGroupNodeNum)
+	registry.MustRegister(QueryCoordResourceGroupLackNodeNum)
+	registry.MustRegister(QueryCoordResourceGroupRecoverDroppedNodeNum)
+	registry.MustRegister(QueryCoordResourceGroupChurnRate)
 }
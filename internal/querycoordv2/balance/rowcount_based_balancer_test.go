@@ -17,6 +17,7 @@
 package balance
 
 import (
+	"context"
 	"testing"
 
 	"github.com/milvus-io/milvus/internal/querycoordv2/task"
@@ -131,6 +132,7 @@ func (suite *RowCountBasedBalancerTestSuite) TestAssignSegment() {
 }
 
 func (suite *RowCountBasedBalancerTestSuite) TestBalance() {
+	ctx := context.Background()
 	cases := []struct {
 		name                 string
 		nodes                []int64
@@ -275,7 +277,7 @@ func (suite *RowCountBasedBalancerTestSuite) TestBalance() {
 				nodeInfo.UpdateStats(session.WithChannelCnt(len(c.distributionChannels[c.nodes[i]])))
 				nodeInfo.SetState(c.states[i])
 				suite.balancer.nodeManager.Add(nodeInfo)
-				suite.balancer.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, c.nodes[i])
+				suite.balancer.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, c.nodes[i])
 			}
 			segmentPlans, channelPlans := balancer.Balance()
 			suite.ElementsMatch(c.expectChannelPlans, channelPlans)
@@ -286,6 +288,7 @@ func (suite *RowCountBasedBalancerTestSuite) TestBalance() {
 }
 
 func (suite *RowCountBasedBalancerTestSuite) TestBalanceOutboundNodes() {
+	ctx := context.Background()
 	cases := []struct {
 		name                 string
 		nodes                []int64
@@ -379,9 +382,9 @@ func (suite *RowCountBasedBalancerTestSuite) TestBalanceOutboundNodes() {
 				suite.balancer.nodeManager.Add(nodeInfo)
 			}
 			// make node-3 outbound
-			err := balancer.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, 1)
+			err := balancer.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, 1)
 			suite.NoError(err)
-			err = balancer.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, 2)
+			err = balancer.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, 2)
 			suite.NoError(err)
 			segmentPlans, channelPlans := balancer.Balance()
 			suite.ElementsMatch(c.expectChannelPlans, channelPlans)
@@ -99,11 +99,12 @@ func (suite *ChannelCheckerTestSuite) createMockBalancer() balance.Balance {
 }
 
 func (suite *ChannelCheckerTestSuite) TestLoadChannel() {
+	ctx := context.Background()
 	checker := suite.checker
 	checker.meta.CollectionManager.PutCollection(utils.CreateTestCollection(1, 1))
 	checker.meta.ReplicaManager.Put(utils.CreateTestReplica(1, 1, []int64{1}))
 	suite.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
-	checker.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, 1)
+	checker.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, 1)
 
 	channels := []*datapb.VchannelInfo{
 		{
@@ -99,14 +99,15 @@ func (suite *SegmentCheckerTestSuite) createMockBalancer() balance.Balance {
 }
 
 func (suite *SegmentCheckerTestSuite) TestLoadSegments() {
+	ctx := context.Background()
 	checker := suite.checker
 	// set meta
 	checker.meta.CollectionManager.PutCollection(utils.CreateTestCollection(1, 1))
 	checker.meta.ReplicaManager.Put(utils.CreateTestReplica(1, 1, []int64{1, 2}))
 	suite.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
 	suite.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
-	checker.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, 1)
-	checker.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, 2)
+	checker.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, 1)
+	checker.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, 2)
 
 	// set target
 	segments := []*datapb.SegmentBinlogs{
@@ -196,6 +196,13 @@ func (job *LoadCollectionJob) Execute() error {
 		return err
 	}
 
+	err = checkReplicaQuota(job.meta, req.GetResourceGroups(), req.GetReplicaNumber())
+	if err != nil {
+		msg := "resource group can't accept more replicas"
+		log.Warn(msg, zap.Error(err))
+		return utils.WrapError(msg, err)
+	}
+
 	// Create replicas
 	replicas, err := utils.SpawnReplicasWithRG(job.meta,
 		req.GetCollectionID(),
@@ -412,6 +419,13 @@ func (job *LoadPartitionJob) Execute() error {
 		return err
 	}
 
+	err = checkReplicaQuota(job.meta, req.GetResourceGroups(), req.GetReplicaNumber())
+	if err != nil {
+		msg := "resource group can't accept more replicas"
+		log.Warn(msg, zap.Error(err))
+		return utils.WrapError(msg, err)
+	}
+
 	// Create replicas
 	replicas, err := utils.SpawnReplicasWithRG(job.meta,
 		req.GetCollectionID(),
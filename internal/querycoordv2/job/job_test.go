@@ -117,6 +117,7 @@ func (suite *JobSuite) SetupSuite() {
 }
 
 func (suite *JobSuite) SetupTest() {
+	ctx := context.Background()
 	config := GenerateEtcdConfig()
 	cli, err := etcd.GetEtcdClient(
 		config.UseEmbedEtcd.GetAsBool(),
@@ -148,11 +149,11 @@ func (suite *JobSuite) SetupTest() {
 	suite.nodeMgr.Add(session.NewNodeInfo(2000, "localhost"))
 	suite.nodeMgr.Add(session.NewNodeInfo(3000, "localhost"))
 
-	err = suite.meta.AssignNode(meta.DefaultResourceGroupName, 1000)
+	err = suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 1000)
 	suite.NoError(err)
-	err = suite.meta.AssignNode(meta.DefaultResourceGroupName, 2000)
+	err = suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 2000)
 	suite.NoError(err)
-	err = suite.meta.AssignNode(meta.DefaultResourceGroupName, 3000)
+	err = suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 3000)
 	suite.NoError(err)
 }
 
@@ -276,9 +277,9 @@ func (suite *JobSuite) TestLoadCollection() {
 		suite.ErrorIs(err, ErrLoadParameterMismatched)
 	}
 
-	suite.meta.ResourceManager.AddResourceGroup("rg1")
-	suite.meta.ResourceManager.AddResourceGroup("rg2")
-	suite.meta.ResourceManager.AddResourceGroup("rg3")
+	suite.meta.ResourceManager.AddResourceGroup(ctx, "rg1")
+	suite.meta.ResourceManager.AddResourceGroup(ctx, "rg2")
+	suite.meta.ResourceManager.AddResourceGroup(ctx, "rg3")
 
 	// Load with 3 replica on 1 rg
 	req := &querypb.LoadCollectionRequest{
@@ -347,6 +348,50 @@ func (suite *JobSuite) TestLoadCollectionWithReplicas() {
 	}
 }
 
+func (suite *JobSuite) TestLoadReplicaQuota() {
+	ctx := context.Background()
+	key := Params.QueryCoordCfg.RGMaxReplicaNum.Key
+	Params.BaseTable.Save(key, "2")
+	defer Params.BaseTable.Reset(key)
+
+	for _, collection := range suite.collections {
+		var job Job
+		if suite.loadTypes[collection] == querypb.LoadType_LoadCollection {
+			job = NewLoadCollectionJob(
+				ctx,
+				&querypb.LoadCollectionRequest{
+					CollectionID:  collection,
+					ReplicaNumber: 3,
+				},
+				suite.dist,
+				suite.meta,
+				suite.targetMgr,
+				suite.broker,
+				suite.nodeMgr,
+			)
+		} else {
+			job = NewLoadPartitionJob(
+				ctx,
+				&querypb.LoadPartitionsRequest{
+					CollectionID:  collection,
+					PartitionIDs:  suite.partitions[collection],
+					ReplicaNumber: 3,
+				},
+				suite.dist,
+				suite.meta,
+				suite.targetMgr,
+				suite.broker,
+				suite.nodeMgr,
+			)
+		}
+		suite.scheduler.Add(job)
+		err := job.Wait()
+		suite.ErrorIs(err, meta.ErrRGReplicaQuotaExceeded)
+		suite.False(suite.meta.Exist(collection))
+		suite.Empty(suite.meta.ReplicaManager.GetByCollection(collection))
+	}
+}
+
 func (suite *JobSuite) TestLoadCollectionWithDiffIndex() {
 	ctx := context.Background()
 
@@ -535,9 +580,9 @@ func (suite *JobSuite) TestLoadPartition() {
 		suite.ErrorIs(err, ErrLoadParameterMismatched)
 	}
 
-	suite.meta.ResourceManager.AddResourceGroup("rg1")
-	suite.meta.ResourceManager.AddResourceGroup("rg2")
-	suite.meta.ResourceManager.AddResourceGroup("rg3")
+	suite.meta.ResourceManager.AddResourceGroup(ctx, "rg1")
+	suite.meta.ResourceManager.AddResourceGroup(ctx, "rg2")
+	suite.meta.ResourceManager.AddResourceGroup(ctx, "rg3")
 
 	// test load 3 replica in 1 rg, should pass rg check
 	req := &querypb.LoadPartitionsRequest{
@@ -801,16 +846,17 @@ func (suite *JobSuite) TestReleasePartition() {
 }
 
 func (suite *JobSuite) TestLoadCollectionStoreFailed() {
+	ctx := context.Background()
 	// Store collection failed
 	store := meta.NewMockStore(suite.T())
 	suite.meta = meta.NewMeta(RandomIncrementIDAllocator(), store, suite.nodeMgr)
 
 	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil)
-	err := suite.meta.AssignNode(meta.DefaultResourceGroupName, 1000)
+	err := suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 1000)
 	suite.NoError(err)
-	err = suite.meta.AssignNode(meta.DefaultResourceGroupName, 2000)
+	err = suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 2000)
 	suite.NoError(err)
-	err = suite.meta.AssignNode(meta.DefaultResourceGroupName, 3000)
+	err = suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 3000)
 	suite.NoError(err)
 
 	for _, collection := range suite.collections {
@@ -846,16 +892,17 @@ func (suite *JobSuite) TestLoadCollectionStoreFailed() {
 }
 
 func (suite *JobSuite) TestLoadPartitionStoreFailed() {
+	ctx := context.Background()
 	// Store partition failed
 	store := meta.NewMockStore(suite.T())
 	suite.meta = meta.NewMeta(RandomIncrementIDAllocator(), store, suite.nodeMgr)
 
 	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil)
-	err := suite.meta.AssignNode(meta.DefaultResourceGroupName, 1000)
+	err := suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 1000)
 	suite.NoError(err)
-	err = suite.meta.AssignNode(meta.DefaultResourceGroupName, 2000)
+	err = suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 2000)
 	suite.NoError(err)
-	err = suite.meta.AssignNode(meta.DefaultResourceGroupName, 3000)
+	err = suite.meta.AssignNode(ctx, meta.DefaultResourceGroupName, 3000)
 	suite.NoError(err)
 
 	err = errors.New("failed to store collection")
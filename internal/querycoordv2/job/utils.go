@@ -17,6 +17,7 @@
 package job
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/milvus-io/milvus/internal/querycoordv2/meta"
@@ -49,3 +50,23 @@ func waitCollectionReleased(dist *meta.DistributionManager, collection int64, pa
 		time.Sleep(200 * time.Millisecond)
 	}
 }
+
+// checkReplicaQuota rejects the load if any target rg can't accept the replicas spawned into it,
+// missing rgs are left to replica spawning to report
+func checkReplicaQuota(m *meta.Meta, resourceGroups []string, replicaNumber int32) error {
+	if len(resourceGroups) == 0 {
+		resourceGroups = []string{meta.DefaultResourceGroupName}
+	}
+	// all replicas go to the only rg, otherwise each rg gets one replica
+	replicaNum := 1
+	if len(resourceGroups) == 1 {
+		replicaNum = int(replicaNumber)
+	}
+
+	for _, rgName := range resourceGroups {
+		if m.ResourceManager.ContainResourceGroup(rgName) && !m.ResourceManager.CanAcceptReplica(rgName, replicaNum) {
+			return fmt.Errorf("%w(rgName=%s, replicaNum=%d)", meta.ErrRGReplicaQuotaExceeded, rgName, replicaNum)
+		}
+	}
+	return nil
+}
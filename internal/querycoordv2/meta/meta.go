@@ -29,9 +29,12 @@ func NewMeta(
 	store Store,
 	nodeMgr *session.NodeManager,
 ) *Meta {
+	replicaMgr := NewReplicaManager(idAllocator, store)
+	resourceMgr := NewResourceManager(store, nodeMgr)
+	resourceMgr.SetReplicaHolder(replicaMgr)
 	return &Meta{
 		NewCollectionManager(store),
-		NewReplicaManager(idAllocator, store),
-		NewResourceManager(store, nodeMgr),
+		replicaMgr,
+		resourceMgr,
 	}
 }
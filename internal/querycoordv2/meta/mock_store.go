@@ -3,6 +3,8 @@
 package meta
 
 import (
+	context "context"
+
 	querypb "github.com/milvus-io/milvus/internal/proto/querypb"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -65,6 +67,98 @@ func (_c *MockStore_GetCollections_Call) Return(_a0 []*querypb.CollectionLoadInf
 	return _c
 }
 
+// GetNodeResourceGroups provides a mock function with given fields: ctx
+func (_m *MockStore) GetNodeResourceGroups(ctx context.Context) (map[int64]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[int64]string
+	if rf, ok := ret.Get(0).(func(context.Context) map[int64]string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int64]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStore_GetNodeResourceGroups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNodeResourceGroups'
+type MockStore_GetNodeResourceGroups_Call struct {
+	*mock.Call
+}
+
+// GetNodeResourceGroups is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockStore_Expecter) GetNodeResourceGroups(ctx interface{}) *MockStore_GetNodeResourceGroups_Call {
+	return &MockStore_GetNodeResourceGroups_Call{Call: _e.mock.On("GetNodeResourceGroups", ctx)}
+}
+
+func (_c *MockStore_GetNodeResourceGroups_Call) Run(run func(ctx context.Context)) *MockStore_GetNodeResourceGroups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStore_GetNodeResourceGroups_Call) Return(_a0 map[int64]string, _a1 error) *MockStore_GetNodeResourceGroups_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetOverlapResourceGroups provides a mock function with given fields: ctx
+func (_m *MockStore) GetOverlapResourceGroups(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStore_GetOverlapResourceGroups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetOverlapResourceGroups'
+type MockStore_GetOverlapResourceGroups_Call struct {
+	*mock.Call
+}
+
+// GetOverlapResourceGroups is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockStore_Expecter) GetOverlapResourceGroups(ctx interface{}) *MockStore_GetOverlapResourceGroups_Call {
+	return &MockStore_GetOverlapResourceGroups_Call{Call: _e.mock.On("GetOverlapResourceGroups", ctx)}
+}
+
+func (_c *MockStore_GetOverlapResourceGroups_Call) Run(run func(ctx context.Context)) *MockStore_GetOverlapResourceGroups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStore_GetOverlapResourceGroups_Call) Return(_a0 []string, _a1 error) *MockStore_GetOverlapResourceGroups_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
 // GetPartitions provides a mock function with given fields:
 func (_m *MockStore) GetPartitions() (map[int64][]*querypb.PartitionLoadInfo, error) {
 	ret := _m.Called()
@@ -155,13 +249,244 @@ func (_c *MockStore_GetReplicas_Call) Return(_a0 []*querypb.Replica, _a1 error)
 	return _c
 }
 
-// GetResourceGroups provides a mock function with given fields:
-func (_m *MockStore) GetResourceGroups() ([]*querypb.ResourceGroup, error) {
-	ret := _m.Called()
+// GetResourceGroup provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) GetResourceGroup(ctx context.Context, rgName string) (*querypb.ResourceGroup, error) {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 *querypb.ResourceGroup
+	if rf, ok := ret.Get(0).(func(context.Context, string) *querypb.ResourceGroup); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*querypb.ResourceGroup)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, rgName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStore_GetResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetResourceGroup'
+type MockStore_GetResourceGroup_Call struct {
+	*mock.Call
+}
+
+// GetResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) GetResourceGroup(ctx interface{}, rgName interface{}) *MockStore_GetResourceGroup_Call {
+	return &MockStore_GetResourceGroup_Call{Call: _e.mock.On("GetResourceGroup", ctx, rgName)}
+}
+
+func (_c *MockStore_GetResourceGroup_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_GetResourceGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_GetResourceGroup_Call) Return(_a0 *querypb.ResourceGroup, _a1 error) *MockStore_GetResourceGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetResourceGroupLabels provides a mock function with given fields: ctx
+func (_m *MockStore) GetResourceGroupLabels(ctx context.Context) (map[string]map[string]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string]map[string]string
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]map[string]string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]map[string]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStore_GetResourceGroupLabels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetResourceGroupLabels'
+type MockStore_GetResourceGroupLabels_Call struct {
+	*mock.Call
+}
+
+// GetResourceGroupLabels is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockStore_Expecter) GetResourceGroupLabels(ctx interface{}) *MockStore_GetResourceGroupLabels_Call {
+	return &MockStore_GetResourceGroupLabels_Call{Call: _e.mock.On("GetResourceGroupLabels", ctx)}
+}
+
+func (_c *MockStore_GetResourceGroupLabels_Call) Run(run func(ctx context.Context)) *MockStore_GetResourceGroupLabels_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStore_GetResourceGroupLabels_Call) Return(_a0 map[string]map[string]string, _a1 error) *MockStore_GetResourceGroupLabels_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetResourceGroupLimits provides a mock function with given fields: ctx
+func (_m *MockStore) GetResourceGroupLimits(ctx context.Context) (map[string]int32, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string]int32
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]int32); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int32)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStore_GetResourceGroupLimits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetResourceGroupLimits'
+type MockStore_GetResourceGroupLimits_Call struct {
+	*mock.Call
+}
+
+// GetResourceGroupLimits is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockStore_Expecter) GetResourceGroupLimits(ctx interface{}) *MockStore_GetResourceGroupLimits_Call {
+	return &MockStore_GetResourceGroupLimits_Call{Call: _e.mock.On("GetResourceGroupLimits", ctx)}
+}
+
+func (_c *MockStore_GetResourceGroupLimits_Call) Run(run func(ctx context.Context)) *MockStore_GetResourceGroupLimits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStore_GetResourceGroupLimits_Call) Return(_a0 map[string]int32, _a1 error) *MockStore_GetResourceGroupLimits_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetResourceGroupMinCapacities provides a mock function with given fields: ctx
+func (_m *MockStore) GetResourceGroupMinCapacities(ctx context.Context) (map[string]int32, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string]int32
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]int32); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int32)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStore_GetResourceGroupMinCapacities_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetResourceGroupMinCapacities'
+type MockStore_GetResourceGroupMinCapacities_Call struct {
+	*mock.Call
+}
+
+// GetResourceGroupMinCapacities is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockStore_Expecter) GetResourceGroupMinCapacities(ctx interface{}) *MockStore_GetResourceGroupMinCapacities_Call {
+	return &MockStore_GetResourceGroupMinCapacities_Call{Call: _e.mock.On("GetResourceGroupMinCapacities", ctx)}
+}
+
+func (_c *MockStore_GetResourceGroupMinCapacities_Call) Run(run func(ctx context.Context)) *MockStore_GetResourceGroupMinCapacities_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStore_GetResourceGroupMinCapacities_Call) Return(_a0 map[string]int32, _a1 error) *MockStore_GetResourceGroupMinCapacities_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetResourceGroupProportions provides a mock function with given fields: ctx
+func (_m *MockStore) GetResourceGroupProportions(ctx context.Context) (map[string]float64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 map[string]float64
+	if rf, ok := ret.Get(0).(func(context.Context) map[string]float64); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]float64)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStore_GetResourceGroupProportions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetResourceGroupProportions'
+type MockStore_GetResourceGroupProportions_Call struct {
+	*mock.Call
+}
+
+// GetResourceGroupProportions is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockStore_Expecter) GetResourceGroupProportions(ctx interface{}) *MockStore_GetResourceGroupProportions_Call {
+	return &MockStore_GetResourceGroupProportions_Call{Call: _e.mock.On("GetResourceGroupProportions", ctx)}
+}
+
+func (_c *MockStore_GetResourceGroupProportions_Call) Run(run func(ctx context.Context)) *MockStore_GetResourceGroupProportions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStore_GetResourceGroupProportions_Call) Return(_a0 map[string]float64, _a1 error) *MockStore_GetResourceGroupProportions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+// GetResourceGroups provides a mock function with given fields: ctx
+func (_m *MockStore) GetResourceGroups(ctx context.Context) ([]*querypb.ResourceGroup, error) {
+	ret := _m.Called(ctx)
 
 	var r0 []*querypb.ResourceGroup
-	if rf, ok := ret.Get(0).(func() []*querypb.ResourceGroup); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []*querypb.ResourceGroup); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*querypb.ResourceGroup)
@@ -169,8 +494,8 @@ func (_m *MockStore) GetResourceGroups() ([]*querypb.ResourceGroup, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -184,13 +509,14 @@ type MockStore_GetResourceGroups_Call struct {
 }
 
 // GetResourceGroups is a helper method to define mock.On call
-func (_e *MockStore_Expecter) GetResourceGroups() *MockStore_GetResourceGroups_Call {
-	return &MockStore_GetResourceGroups_Call{Call: _e.mock.On("GetResourceGroups")}
+//  - ctx context.Context
+func (_e *MockStore_Expecter) GetResourceGroups(ctx interface{}) *MockStore_GetResourceGroups_Call {
+	return &MockStore_GetResourceGroups_Call{Call: _e.mock.On("GetResourceGroups", ctx)}
 }
 
-func (_c *MockStore_GetResourceGroups_Call) Run(run func()) *MockStore_GetResourceGroups_Call {
+func (_c *MockStore_GetResourceGroups_Call) Run(run func(ctx context.Context)) *MockStore_GetResourceGroups_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run()
+		run(args[0].(context.Context))
 	})
 	return _c
 }
@@ -200,6 +526,52 @@ func (_c *MockStore_GetResourceGroups_Call) Return(_a0 []*querypb.ResourceGroup,
 	return _c
 }
 
+// GetSealedResourceGroups provides a mock function with given fields: ctx
+func (_m *MockStore) GetSealedResourceGroups(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockStore_GetSealedResourceGroups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSealedResourceGroups'
+type MockStore_GetSealedResourceGroups_Call struct {
+	*mock.Call
+}
+
+// GetSealedResourceGroups is a helper method to define mock.On call
+//  - ctx context.Context
+func (_e *MockStore_Expecter) GetSealedResourceGroups(ctx interface{}) *MockStore_GetSealedResourceGroups_Call {
+	return &MockStore_GetSealedResourceGroups_Call{Call: _e.mock.On("GetSealedResourceGroups", ctx)}
+}
+
+func (_c *MockStore_GetSealedResourceGroups_Call) Run(run func(ctx context.Context)) *MockStore_GetSealedResourceGroups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockStore_GetSealedResourceGroups_Call) Return(_a0 []string, _a1 error) *MockStore_GetSealedResourceGroups_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
 // ReleaseCollection provides a mock function with given fields: id
 func (_m *MockStore) ReleaseCollection(id int64) error {
 	ret := _m.Called(id)
@@ -364,13 +736,13 @@ func (_c *MockStore_ReleaseReplicas_Call) Return(_a0 error) *MockStore_ReleaseRe
 	return _c
 }
 
-// RemoveResourceGroup provides a mock function with given fields: rgName
-func (_m *MockStore) RemoveResourceGroup(rgName string) error {
-	ret := _m.Called(rgName)
+// RemoveNodeResourceGroup provides a mock function with given fields: ctx, node
+func (_m *MockStore) RemoveNodeResourceGroup(ctx context.Context, node int64) error {
+	ret := _m.Called(ctx, node)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(rgName)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, node)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -378,36 +750,37 @@ func (_m *MockStore) RemoveResourceGroup(rgName string) error {
 	return r0
 }
 
-// MockStore_RemoveResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveResourceGroup'
-type MockStore_RemoveResourceGroup_Call struct {
+// MockStore_RemoveNodeResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveNodeResourceGroup'
+type MockStore_RemoveNodeResourceGroup_Call struct {
 	*mock.Call
 }
 
-// RemoveResourceGroup is a helper method to define mock.On call
-//  - rgName string
-func (_e *MockStore_Expecter) RemoveResourceGroup(rgName interface{}) *MockStore_RemoveResourceGroup_Call {
-	return &MockStore_RemoveResourceGroup_Call{Call: _e.mock.On("RemoveResourceGroup", rgName)}
+// RemoveNodeResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
+//  - node int64
+func (_e *MockStore_Expecter) RemoveNodeResourceGroup(ctx interface{}, node interface{}) *MockStore_RemoveNodeResourceGroup_Call {
+	return &MockStore_RemoveNodeResourceGroup_Call{Call: _e.mock.On("RemoveNodeResourceGroup", ctx, node)}
 }
 
-func (_c *MockStore_RemoveResourceGroup_Call) Run(run func(rgName string)) *MockStore_RemoveResourceGroup_Call {
+func (_c *MockStore_RemoveNodeResourceGroup_Call) Run(run func(ctx context.Context, node int64)) *MockStore_RemoveNodeResourceGroup_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string))
+		run(args[0].(context.Context), args[1].(int64))
 	})
 	return _c
 }
 
-func (_c *MockStore_RemoveResourceGroup_Call) Return(_a0 error) *MockStore_RemoveResourceGroup_Call {
+func (_c *MockStore_RemoveNodeResourceGroup_Call) Return(_a0 error) *MockStore_RemoveNodeResourceGroup_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-// SaveCollection provides a mock function with given fields: info
-func (_m *MockStore) SaveCollection(info *querypb.CollectionLoadInfo) error {
-	ret := _m.Called(info)
+// RemoveOverlapResourceGroup provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) RemoveOverlapResourceGroup(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(*querypb.CollectionLoadInfo) error); ok {
-		r0 = rf(info)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -415,34 +788,415 @@ func (_m *MockStore) SaveCollection(info *querypb.CollectionLoadInfo) error {
 	return r0
 }
 
-// MockStore_SaveCollection_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveCollection'
-type MockStore_SaveCollection_Call struct {
+// MockStore_RemoveOverlapResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveOverlapResourceGroup'
+type MockStore_RemoveOverlapResourceGroup_Call struct {
 	*mock.Call
 }
 
-// SaveCollection is a helper method to define mock.On call
-//  - info *querypb.CollectionLoadInfo
-func (_e *MockStore_Expecter) SaveCollection(info interface{}) *MockStore_SaveCollection_Call {
-	return &MockStore_SaveCollection_Call{Call: _e.mock.On("SaveCollection", info)}
+// RemoveOverlapResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) RemoveOverlapResourceGroup(ctx interface{}, rgName interface{}) *MockStore_RemoveOverlapResourceGroup_Call {
+	return &MockStore_RemoveOverlapResourceGroup_Call{Call: _e.mock.On("RemoveOverlapResourceGroup", ctx, rgName)}
 }
 
-func (_c *MockStore_SaveCollection_Call) Run(run func(info *querypb.CollectionLoadInfo)) *MockStore_SaveCollection_Call {
+func (_c *MockStore_RemoveOverlapResourceGroup_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_RemoveOverlapResourceGroup_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*querypb.CollectionLoadInfo))
+		run(args[0].(context.Context), args[1].(string))
 	})
 	return _c
 }
 
-func (_c *MockStore_SaveCollection_Call) Return(_a0 error) *MockStore_SaveCollection_Call {
+func (_c *MockStore_RemoveOverlapResourceGroup_Call) Return(_a0 error) *MockStore_RemoveOverlapResourceGroup_Call {
 	_c.Call.Return(_a0)
 	return _c
 }
 
-// SavePartition provides a mock function with given fields: info
-func (_m *MockStore) SavePartition(info ...*querypb.PartitionLoadInfo) error {
-	_va := make([]interface{}, len(info))
-	for _i := range info {
-		_va[_i] = info[_i]
+// RemoveResourceGroup provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) RemoveResourceGroup(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_RemoveResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveResourceGroup'
+type MockStore_RemoveResourceGroup_Call struct {
+	*mock.Call
+}
+
+// RemoveResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) RemoveResourceGroup(ctx interface{}, rgName interface{}) *MockStore_RemoveResourceGroup_Call {
+	return &MockStore_RemoveResourceGroup_Call{Call: _e.mock.On("RemoveResourceGroup", ctx, rgName)}
+}
+
+func (_c *MockStore_RemoveResourceGroup_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_RemoveResourceGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_RemoveResourceGroup_Call) Return(_a0 error) *MockStore_RemoveResourceGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// RemoveResourceGroupAttributes provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) RemoveResourceGroupAttributes(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_RemoveResourceGroupAttributes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveResourceGroupAttributes'
+type MockStore_RemoveResourceGroupAttributes_Call struct {
+	*mock.Call
+}
+
+// RemoveResourceGroupAttributes is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) RemoveResourceGroupAttributes(ctx interface{}, rgName interface{}) *MockStore_RemoveResourceGroupAttributes_Call {
+	return &MockStore_RemoveResourceGroupAttributes_Call{Call: _e.mock.On("RemoveResourceGroupAttributes", ctx, rgName)}
+}
+
+func (_c *MockStore_RemoveResourceGroupAttributes_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_RemoveResourceGroupAttributes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_RemoveResourceGroupAttributes_Call) Return(_a0 error) *MockStore_RemoveResourceGroupAttributes_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// RemoveResourceGroupLabels provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) RemoveResourceGroupLabels(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_RemoveResourceGroupLabels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveResourceGroupLabels'
+type MockStore_RemoveResourceGroupLabels_Call struct {
+	*mock.Call
+}
+
+// RemoveResourceGroupLabels is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) RemoveResourceGroupLabels(ctx interface{}, rgName interface{}) *MockStore_RemoveResourceGroupLabels_Call {
+	return &MockStore_RemoveResourceGroupLabels_Call{Call: _e.mock.On("RemoveResourceGroupLabels", ctx, rgName)}
+}
+
+func (_c *MockStore_RemoveResourceGroupLabels_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_RemoveResourceGroupLabels_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_RemoveResourceGroupLabels_Call) Return(_a0 error) *MockStore_RemoveResourceGroupLabels_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// RemoveResourceGroupLimit provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) RemoveResourceGroupLimit(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_RemoveResourceGroupLimit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveResourceGroupLimit'
+type MockStore_RemoveResourceGroupLimit_Call struct {
+	*mock.Call
+}
+
+// RemoveResourceGroupLimit is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) RemoveResourceGroupLimit(ctx interface{}, rgName interface{}) *MockStore_RemoveResourceGroupLimit_Call {
+	return &MockStore_RemoveResourceGroupLimit_Call{Call: _e.mock.On("RemoveResourceGroupLimit", ctx, rgName)}
+}
+
+func (_c *MockStore_RemoveResourceGroupLimit_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_RemoveResourceGroupLimit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_RemoveResourceGroupLimit_Call) Return(_a0 error) *MockStore_RemoveResourceGroupLimit_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// RemoveResourceGroupMinCapacity provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) RemoveResourceGroupMinCapacity(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_RemoveResourceGroupMinCapacity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveResourceGroupMinCapacity'
+type MockStore_RemoveResourceGroupMinCapacity_Call struct {
+	*mock.Call
+}
+
+// RemoveResourceGroupMinCapacity is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) RemoveResourceGroupMinCapacity(ctx interface{}, rgName interface{}) *MockStore_RemoveResourceGroupMinCapacity_Call {
+	return &MockStore_RemoveResourceGroupMinCapacity_Call{Call: _e.mock.On("RemoveResourceGroupMinCapacity", ctx, rgName)}
+}
+
+func (_c *MockStore_RemoveResourceGroupMinCapacity_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_RemoveResourceGroupMinCapacity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_RemoveResourceGroupMinCapacity_Call) Return(_a0 error) *MockStore_RemoveResourceGroupMinCapacity_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// RemoveResourceGroupProportion provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) RemoveResourceGroupProportion(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_RemoveResourceGroupProportion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveResourceGroupProportion'
+type MockStore_RemoveResourceGroupProportion_Call struct {
+	*mock.Call
+}
+
+// RemoveResourceGroupProportion is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) RemoveResourceGroupProportion(ctx interface{}, rgName interface{}) *MockStore_RemoveResourceGroupProportion_Call {
+	return &MockStore_RemoveResourceGroupProportion_Call{Call: _e.mock.On("RemoveResourceGroupProportion", ctx, rgName)}
+}
+
+func (_c *MockStore_RemoveResourceGroupProportion_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_RemoveResourceGroupProportion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_RemoveResourceGroupProportion_Call) Return(_a0 error) *MockStore_RemoveResourceGroupProportion_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// RemoveSealedResourceGroup provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) RemoveSealedResourceGroup(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_RemoveSealedResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveSealedResourceGroup'
+type MockStore_RemoveSealedResourceGroup_Call struct {
+	*mock.Call
+}
+
+// RemoveSealedResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) RemoveSealedResourceGroup(ctx interface{}, rgName interface{}) *MockStore_RemoveSealedResourceGroup_Call {
+	return &MockStore_RemoveSealedResourceGroup_Call{Call: _e.mock.On("RemoveSealedResourceGroup", ctx, rgName)}
+}
+
+func (_c *MockStore_RemoveSealedResourceGroup_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_RemoveSealedResourceGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_RemoveSealedResourceGroup_Call) Return(_a0 error) *MockStore_RemoveSealedResourceGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// SaveCollection provides a mock function with given fields: info
+func (_m *MockStore) SaveCollection(info *querypb.CollectionLoadInfo) error {
+	ret := _m.Called(info)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*querypb.CollectionLoadInfo) error); ok {
+		r0 = rf(info)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_SaveCollection_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveCollection'
+type MockStore_SaveCollection_Call struct {
+	*mock.Call
+}
+
+// SaveCollection is a helper method to define mock.On call
+//  - info *querypb.CollectionLoadInfo
+func (_e *MockStore_Expecter) SaveCollection(info interface{}) *MockStore_SaveCollection_Call {
+	return &MockStore_SaveCollection_Call{Call: _e.mock.On("SaveCollection", info)}
+}
+
+func (_c *MockStore_SaveCollection_Call) Run(run func(info *querypb.CollectionLoadInfo)) *MockStore_SaveCollection_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*querypb.CollectionLoadInfo))
+	})
+	return _c
+}
+
+func (_c *MockStore_SaveCollection_Call) Return(_a0 error) *MockStore_SaveCollection_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// SaveNodeResourceGroup provides a mock function with given fields: ctx, node, rgName
+func (_m *MockStore) SaveNodeResourceGroup(ctx context.Context, node int64, rgName string) error {
+	ret := _m.Called(ctx, node, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, node, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_SaveNodeResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveNodeResourceGroup'
+type MockStore_SaveNodeResourceGroup_Call struct {
+	*mock.Call
+}
+
+// SaveNodeResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
+//  - node int64
+//  - rgName string
+func (_e *MockStore_Expecter) SaveNodeResourceGroup(ctx interface{}, node interface{}, rgName interface{}) *MockStore_SaveNodeResourceGroup_Call {
+	return &MockStore_SaveNodeResourceGroup_Call{Call: _e.mock.On("SaveNodeResourceGroup", ctx, node, rgName)}
+}
+
+func (_c *MockStore_SaveNodeResourceGroup_Call) Run(run func(ctx context.Context, node int64, rgName string)) *MockStore_SaveNodeResourceGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int64), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_SaveNodeResourceGroup_Call) Return(_a0 error) *MockStore_SaveNodeResourceGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// SaveOverlapResourceGroup provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) SaveOverlapResourceGroup(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_SaveOverlapResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveOverlapResourceGroup'
+type MockStore_SaveOverlapResourceGroup_Call struct {
+	*mock.Call
+}
+
+// SaveOverlapResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) SaveOverlapResourceGroup(ctx interface{}, rgName interface{}) *MockStore_SaveOverlapResourceGroup_Call {
+	return &MockStore_SaveOverlapResourceGroup_Call{Call: _e.mock.On("SaveOverlapResourceGroup", ctx, rgName)}
+}
+
+func (_c *MockStore_SaveOverlapResourceGroup_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_SaveOverlapResourceGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_SaveOverlapResourceGroup_Call) Return(_a0 error) *MockStore_SaveOverlapResourceGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// SavePartition provides a mock function with given fields: info
+func (_m *MockStore) SavePartition(info ...*querypb.PartitionLoadInfo) error {
+	_va := make([]interface{}, len(info))
+	for _i := range info {
+		_va[_i] = info[_i]
 	}
 	var _ca []interface{}
 	_ca = append(_ca, _va...)
@@ -525,19 +1279,20 @@ func (_c *MockStore_SaveReplica_Call) Return(_a0 error) *MockStore_SaveReplica_C
 	return _c
 }
 
-// SaveResourceGroup provides a mock function with given fields: rgs
-func (_m *MockStore) SaveResourceGroup(rgs ...*querypb.ResourceGroup) error {
+// SaveResourceGroup provides a mock function with given fields: ctx, rgs
+func (_m *MockStore) SaveResourceGroup(ctx context.Context, rgs ...*querypb.ResourceGroup) error {
 	_va := make([]interface{}, len(rgs))
 	for _i := range rgs {
 		_va[_i] = rgs[_i]
 	}
 	var _ca []interface{}
+	_ca = append(_ca, ctx)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(...*querypb.ResourceGroup) error); ok {
-		r0 = rf(rgs...)
+	if rf, ok := ret.Get(0).(func(context.Context, ...*querypb.ResourceGroup) error); ok {
+		r0 = rf(ctx, rgs...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -551,21 +1306,22 @@ type MockStore_SaveResourceGroup_Call struct {
 }
 
 // SaveResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
 //  - rgs ...*querypb.ResourceGroup
-func (_e *MockStore_Expecter) SaveResourceGroup(rgs ...interface{}) *MockStore_SaveResourceGroup_Call {
+func (_e *MockStore_Expecter) SaveResourceGroup(ctx interface{}, rgs ...interface{}) *MockStore_SaveResourceGroup_Call {
 	return &MockStore_SaveResourceGroup_Call{Call: _e.mock.On("SaveResourceGroup",
-		append([]interface{}{}, rgs...)...)}
+		append([]interface{}{ctx}, rgs...)...)}
 }
 
-func (_c *MockStore_SaveResourceGroup_Call) Run(run func(rgs ...*querypb.ResourceGroup)) *MockStore_SaveResourceGroup_Call {
+func (_c *MockStore_SaveResourceGroup_Call) Run(run func(ctx context.Context, rgs ...*querypb.ResourceGroup)) *MockStore_SaveResourceGroup_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		variadicArgs := make([]*querypb.ResourceGroup, len(args)-0)
-		for i, a := range args[0:] {
+		variadicArgs := make([]*querypb.ResourceGroup, len(args)-1)
+		for i, a := range args[1:] {
 			if a != nil {
 				variadicArgs[i] = a.(*querypb.ResourceGroup)
 			}
 		}
-		run(variadicArgs...)
+		run(args[0].(context.Context), variadicArgs...)
 	})
 	return _c
 }
@@ -575,6 +1331,200 @@ func (_c *MockStore_SaveResourceGroup_Call) Return(_a0 error) *MockStore_SaveRes
 	return _c
 }
 
+// SaveResourceGroupLabels provides a mock function with given fields: ctx, rgName, labels
+func (_m *MockStore) SaveResourceGroupLabels(ctx context.Context, rgName string, labels map[string]string) error {
+	ret := _m.Called(ctx, rgName, labels)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]string) error); ok {
+		r0 = rf(ctx, rgName, labels)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_SaveResourceGroupLabels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveResourceGroupLabels'
+type MockStore_SaveResourceGroupLabels_Call struct {
+	*mock.Call
+}
+
+// SaveResourceGroupLabels is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+//  - labels map[string]string
+func (_e *MockStore_Expecter) SaveResourceGroupLabels(ctx interface{}, rgName interface{}, labels interface{}) *MockStore_SaveResourceGroupLabels_Call {
+	return &MockStore_SaveResourceGroupLabels_Call{Call: _e.mock.On("SaveResourceGroupLabels", ctx, rgName, labels)}
+}
+
+func (_c *MockStore_SaveResourceGroupLabels_Call) Run(run func(ctx context.Context, rgName string, labels map[string]string)) *MockStore_SaveResourceGroupLabels_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(map[string]string))
+	})
+	return _c
+}
+
+func (_c *MockStore_SaveResourceGroupLabels_Call) Return(_a0 error) *MockStore_SaveResourceGroupLabels_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// SaveResourceGroupLimit provides a mock function with given fields: ctx, rgName, maxCapacity
+func (_m *MockStore) SaveResourceGroupLimit(ctx context.Context, rgName string, maxCapacity int32) error {
+	ret := _m.Called(ctx, rgName, maxCapacity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32) error); ok {
+		r0 = rf(ctx, rgName, maxCapacity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_SaveResourceGroupLimit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveResourceGroupLimit'
+type MockStore_SaveResourceGroupLimit_Call struct {
+	*mock.Call
+}
+
+// SaveResourceGroupLimit is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+//  - maxCapacity int32
+func (_e *MockStore_Expecter) SaveResourceGroupLimit(ctx interface{}, rgName interface{}, maxCapacity interface{}) *MockStore_SaveResourceGroupLimit_Call {
+	return &MockStore_SaveResourceGroupLimit_Call{Call: _e.mock.On("SaveResourceGroupLimit", ctx, rgName, maxCapacity)}
+}
+
+func (_c *MockStore_SaveResourceGroupLimit_Call) Run(run func(ctx context.Context, rgName string, maxCapacity int32)) *MockStore_SaveResourceGroupLimit_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockStore_SaveResourceGroupLimit_Call) Return(_a0 error) *MockStore_SaveResourceGroupLimit_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// SaveResourceGroupMinCapacity provides a mock function with given fields: ctx, rgName, minCapacity
+func (_m *MockStore) SaveResourceGroupMinCapacity(ctx context.Context, rgName string, minCapacity int32) error {
+	ret := _m.Called(ctx, rgName, minCapacity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int32) error); ok {
+		r0 = rf(ctx, rgName, minCapacity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_SaveResourceGroupMinCapacity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveResourceGroupMinCapacity'
+type MockStore_SaveResourceGroupMinCapacity_Call struct {
+	*mock.Call
+}
+
+// SaveResourceGroupMinCapacity is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+//  - minCapacity int32
+func (_e *MockStore_Expecter) SaveResourceGroupMinCapacity(ctx interface{}, rgName interface{}, minCapacity interface{}) *MockStore_SaveResourceGroupMinCapacity_Call {
+	return &MockStore_SaveResourceGroupMinCapacity_Call{Call: _e.mock.On("SaveResourceGroupMinCapacity", ctx, rgName, minCapacity)}
+}
+
+func (_c *MockStore_SaveResourceGroupMinCapacity_Call) Run(run func(ctx context.Context, rgName string, minCapacity int32)) *MockStore_SaveResourceGroupMinCapacity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int32))
+	})
+	return _c
+}
+
+func (_c *MockStore_SaveResourceGroupMinCapacity_Call) Return(_a0 error) *MockStore_SaveResourceGroupMinCapacity_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// SaveResourceGroupProportion provides a mock function with given fields: ctx, rgName, fraction
+func (_m *MockStore) SaveResourceGroupProportion(ctx context.Context, rgName string, fraction float64) error {
+	ret := _m.Called(ctx, rgName, fraction)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, float64) error); ok {
+		r0 = rf(ctx, rgName, fraction)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_SaveResourceGroupProportion_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveResourceGroupProportion'
+type MockStore_SaveResourceGroupProportion_Call struct {
+	*mock.Call
+}
+
+// SaveResourceGroupProportion is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+//  - fraction float64
+func (_e *MockStore_Expecter) SaveResourceGroupProportion(ctx interface{}, rgName interface{}, fraction interface{}) *MockStore_SaveResourceGroupProportion_Call {
+	return &MockStore_SaveResourceGroupProportion_Call{Call: _e.mock.On("SaveResourceGroupProportion", ctx, rgName, fraction)}
+}
+
+func (_c *MockStore_SaveResourceGroupProportion_Call) Run(run func(ctx context.Context, rgName string, fraction float64)) *MockStore_SaveResourceGroupProportion_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(float64))
+	})
+	return _c
+}
+
+func (_c *MockStore_SaveResourceGroupProportion_Call) Return(_a0 error) *MockStore_SaveResourceGroupProportion_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+// SaveSealedResourceGroup provides a mock function with given fields: ctx, rgName
+func (_m *MockStore) SaveSealedResourceGroup(ctx context.Context, rgName string) error {
+	ret := _m.Called(ctx, rgName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, rgName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockStore_SaveSealedResourceGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveSealedResourceGroup'
+type MockStore_SaveSealedResourceGroup_Call struct {
+	*mock.Call
+}
+
+// SaveSealedResourceGroup is a helper method to define mock.On call
+//  - ctx context.Context
+//  - rgName string
+func (_e *MockStore_Expecter) SaveSealedResourceGroup(ctx interface{}, rgName interface{}) *MockStore_SaveSealedResourceGroup_Call {
+	return &MockStore_SaveSealedResourceGroup_Call{Call: _e.mock.On("SaveSealedResourceGroup", ctx, rgName)}
+}
+
+func (_c *MockStore_SaveSealedResourceGroup_Call) Run(run func(ctx context.Context, rgName string)) *MockStore_SaveSealedResourceGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStore_SaveSealedResourceGroup_Call) Return(_a0 error) *MockStore_SaveSealedResourceGroup_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
 type mockConstructorTestingTNewMockStore interface {
 	mock.TestingT
 	Cleanup(func())
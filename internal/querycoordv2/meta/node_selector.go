@@ -0,0 +1,107 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package meta
+
+import "sort"
+
+// NodeSelector chooses which nodes to move out of a resource group,
+// used by TransferNode and AutoRecoverResourceGroup.
+type NodeSelector interface {
+	// Select returns at most count nodes from candidates
+	Select(candidates []int64, count int) []int64
+}
+
+// FirstNodeSelector selects the first count candidates
+type FirstNodeSelector struct{}
+
+func NewFirstNodeSelector() *FirstNodeSelector {
+	return &FirstNodeSelector{}
+}
+
+func (s *FirstNodeSelector) Select(candidates []int64, count int) []int64 {
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+	return candidates[:count]
+}
+
+// LoadProvider reports the load of a node, such as its segment num
+type LoadProvider interface {
+	NodeLoad(node int64) float64
+}
+
+// SegmentProvider reports the num of segments loaded on a node, which have to be migrated if node moves
+type SegmentProvider interface {
+	NodeSegmentNum(node int64) int
+}
+
+// LeastLoadNodeSelector selects the count candidates with the least load,
+// candidates with the same load keep their order
+type LeastLoadNodeSelector struct {
+	provider LoadProvider
+}
+
+func NewLeastLoadNodeSelector(provider LoadProvider) *LeastLoadNodeSelector {
+	return &LeastLoadNodeSelector{provider: provider}
+}
+
+func (s *LeastLoadNodeSelector) Select(candidates []int64, count int) []int64 {
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+
+	loads := make(map[int64]float64, len(candidates))
+	for _, node := range candidates {
+		loads[node] = s.provider.NodeLoad(node)
+	}
+	sorted := make([]int64, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return loads[sorted[i]] < loads[sorted[j]]
+	})
+	return sorted[:count]
+}
+
+// NodeTopologyProvider reports the physical rack which a node is placed in
+type NodeTopologyProvider interface {
+	Rack(node int64) string
+}
+
+// spreadByRack picks at most count nodes from the ordered candidates, each time it picks the first
+// candidate whose rack holds the fewest nodes among existing and picked ones, so picked nodes spread across racks
+func spreadByRack(topology NodeTopologyProvider, existing []int64, candidates []int64, count int) []int64 {
+	rackNodeNum := make(map[string]int)
+	for _, node := range existing {
+		rackNodeNum[topology.Rack(node)]++
+	}
+
+	remaining := make([]int64, len(candidates))
+	copy(remaining, candidates)
+	ret := make([]int64, 0, count)
+	for len(ret) < count && len(remaining) > 0 {
+		picked := 0
+		for i := 1; i < len(remaining); i++ {
+			if rackNodeNum[topology.Rack(remaining[i])] < rackNodeNum[topology.Rack(remaining[picked])] {
+				picked = i
+			}
+		}
+		ret = append(ret, remaining[picked])
+		rackNodeNum[topology.Rack(remaining[picked])]++
+		remaining = append(remaining[:picked], remaining[picked+1:]...)
+	}
+	return ret
+}
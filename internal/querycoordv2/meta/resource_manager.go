@@ -17,15 +17,26 @@
 package meta
 
 import (
+	"container/list"
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/milvus-io/milvus/internal/log"
+	"github.com/milvus-io/milvus/internal/metrics"
 	"github.com/milvus-io/milvus/internal/proto/querypb"
+	"github.com/milvus-io/milvus/internal/querycoordv2/params"
 	"github.com/milvus-io/milvus/internal/querycoordv2/session"
+	"github.com/milvus-io/milvus/internal/util/retry"
 	"github.com/milvus-io/milvus/internal/util/typeutil"
 	. "github.com/milvus-io/milvus/internal/util/typeutil"
 	"github.com/samber/lo"
+	"go.uber.org/atomic"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
 )
 
@@ -46,26 +57,248 @@ var (
 	ErrDeleteNonEmptyRG             = errors.New("delete non-empty rg is not permitted")
 	ErrNodeNotExist                 = errors.New("node does not exist")
 	ErrNodeStopped                  = errors.New("node has been stopped")
-	ErrRGLimit                      = errors.New("resource group num reach limit 1024")
+	ErrRGLimit                      = errors.New("resource group num reach limit")
 	ErrNodeNotEnough                = errors.New("nodes not enough")
+	ErrRGNameInvalid                = errors.New("resource group name is invalid")
+	ErrRGCapacityExceeded           = errors.New("resource group max capacity exceeded")
+	ErrRGMaxCapacityInvalid         = errors.New("resource group max capacity is invalid")
+	ErrRGMinCapacityInvalid         = errors.New("resource group min capacity is invalid")
+	ErrRGInUse                      = errors.New("resource group is in use by replicas")
+	ErrRGCapacityInvalid            = errors.New("resource group capacity is invalid")
+	ErrRGInconsistent               = errors.New("resource group meta is inconsistent")
+	ErrCapacityBelowNodeCount       = errors.New("resource group capacity is less than its node num")
+	ErrRGStoreMismatch              = errors.New("stored resource group mismatches the expected one")
+	ErrRGSealed                     = errors.New("resource group is sealed")
+	ErrSameResourceGroup            = errors.New("source and target resource group are the same")
+	ErrRGOverlapNotAllowed          = errors.New("resource group overlap is not allowed")
+	ErrManagerRecovering            = errors.New("resource manager is recovering")
+	ErrRGPartialRecovered           = errors.New("resource group partially recovered")
+	ErrSegmentProviderNotSet        = errors.New("segment provider is not set")
+	ErrRGProportionInvalid          = errors.New("resource group proportion is invalid")
+	ErrNodeNotAdmissible            = errors.New("node is not admissible by resource group")
+	ErrManagerClosed                = errors.New("resource manager is closed")
+	ErrRGReplicaQuotaExceeded       = errors.New("resource group replica quota exceeded")
+	ErrTransferPlanInvalid          = errors.New("transfer plan is invalid")
 )
 
+// NodeAlreadyAssignedError carries the rg which node has been assigned to,
+// it matches ErrNodeAlreadyAssign by errors.Is
+type NodeAlreadyAssignedError struct {
+	Node      int64
+	CurrentRG string
+}
+
+func (e *NodeAlreadyAssignedError) Error() string {
+	return fmt.Sprintf("%s(node=%d, rgName=%s)", ErrNodeAlreadyAssign.Error(), e.Node, e.CurrentRG)
+}
+
+func (e *NodeAlreadyAssignedError) Is(target error) bool {
+	return target == ErrNodeAlreadyAssign
+}
+
+// ResourceGroupNotFoundError carries the name of the missing rg,
+// it matches ErrRGNotExist by errors.Is
+type ResourceGroupNotFoundError struct {
+	Name string
+}
+
+func (e *ResourceGroupNotFoundError) Error() string {
+	return fmt.Sprintf("%s(rgName=%s)", ErrRGNotExist.Error(), e.Name)
+}
+
+func (e *ResourceGroupNotFoundError) Is(target error) bool {
+	return target == ErrRGNotExist
+}
+
+// storeError is returned after all retries of a store write failed,
+// it matches the given sentinel by errors.Is and unwraps to the last store error
+type storeError struct {
+	sentinel error
+	cause    error
+}
+
+func (e *storeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.sentinel.Error(), e.cause.Error())
+}
+
+func (e *storeError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *storeError) Unwrap() error {
+	return e.cause
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor attaches the actor of mutating operations to ctx, it's carried by the audit records
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+func auditActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(auditActorKey{}).(string); ok {
+		return actor
+	}
+	return "unknown"
+}
+
+type idempotencyTokenKey struct{}
+
+// WithIdempotencyToken attaches a token to ctx, retrying a succeeded mutating operation
+// with the same token returns success without applying it again
+func WithIdempotencyToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, idempotencyTokenKey{}, token)
+}
+
+func idempotencyTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(idempotencyTokenKey{}).(string)
+	return token, ok && len(token) > 0
+}
+
+// max num of recently succeeded operations remembered for dedup
+const recentOperationCacheSize = 1024
+
+// recentOperations is a bounded LRU of token -> succeeded operation,
+// it's not thread safe, guarded by the lock of ResourceManager
+type recentOperations struct {
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type recentOperation struct {
+	token     string
+	operation string
+}
+
+func newRecentOperations(capacity int) *recentOperations {
+	return &recentOperations{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// whether operation has succeeded with token. token reused by another operation doesn't match
+func (r *recentOperations) contains(token string, operation string) bool {
+	elem, ok := r.items[token]
+	if !ok || elem.Value.(*recentOperation).operation != operation {
+		return false
+	}
+	r.ll.MoveToFront(elem)
+	return true
+}
+
+func (r *recentOperations) add(token string, operation string) {
+	if elem, ok := r.items[token]; ok {
+		elem.Value.(*recentOperation).operation = operation
+		r.ll.MoveToFront(elem)
+		return
+	}
+
+	r.items[token] = r.ll.PushFront(&recentOperation{token: token, operation: operation})
+	if r.ll.Len() > r.capacity {
+		oldest := r.ll.Back()
+		r.ll.Remove(oldest)
+		delete(r.items, oldest.Value.(*recentOperation).token)
+	}
+}
+
 var DefaultResourceGroupName = "__default_resource_group"
 
+const maxResourceGroupNameLength = 255
+
+// default rg's capacity is large enough to hold all nodes
+const defaultResourceGroupCapacity = 1000000
+
 type ResourceGroup struct {
 	nodes    UniqueSet
 	capacity int
+	// 0 means unlimited
+	maxCapacity int
+	// rg below min capacity will be recovered prior to others
+	minCapacity int
+	// user defined tags, such as tenant=foo
+	labels map[string]string
+	// nodes of sealed rg can't be changed, except node down
+	sealed bool
+	// nodes of overlap rg could also belong to other rgs, they are not indexed in nodeToRG
+	overlap bool
+	// fraction of cluster size which capacity follows, 0 means capacity is fixed
+	proportion float64
+	// only nodes passing it could join rg, nil admits all nodes. it lives in memory only
+	admission func(node int64) bool
+	// time of node up and down events within churn rate window
+	churn []time.Time
+	clock func() time.Time
+	// recent node num of rg, recorded on every membership change
+	history *nodeCountHistory
 }
 
 func NewResourceGroup(capacity int) *ResourceGroup {
 	rg := &ResourceGroup{
 		nodes:    typeutil.NewUniqueSet(),
 		capacity: capacity,
+		history:  newNodeCountHistory(params.Params.QueryCoordCfg.RGNodeCountHistorySize.GetAsInt()),
+		clock:    time.Now,
 	}
 
 	return rg
 }
 
+// NodeCountSample is the node num of a rg at some point
+type NodeCountSample struct {
+	Timestamp time.Time
+	NodeCount int
+}
+
+// ring buffer which keeps the latest node count samples
+type nodeCountHistory struct {
+	samples []NodeCountSample
+	next    int
+	full    bool
+}
+
+func newNodeCountHistory(size int) *nodeCountHistory {
+	if size < 0 {
+		size = 0
+	}
+	return &nodeCountHistory{
+		samples: make([]NodeCountSample, size),
+	}
+}
+
+func (h *nodeCountHistory) record(nodeCount int) {
+	if len(h.samples) == 0 {
+		return
+	}
+
+	h.samples[h.next] = NodeCountSample{
+		Timestamp: time.Now(),
+		NodeCount: nodeCount,
+	}
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// return samples from the oldest to the latest
+func (h *nodeCountHistory) collect() []NodeCountSample {
+	if !h.full {
+		return append([]NodeCountSample{}, h.samples[:h.next]...)
+	}
+
+	ret := make([]NodeCountSample, 0, len(h.samples))
+	ret = append(ret, h.samples[h.next:]...)
+	return append(ret, h.samples[:h.next]...)
+}
+
+func (rg *ResourceGroup) recordNodeCount() {
+	rg.history.record(rg.nodes.Len())
+}
+
 // assign node to resource group
 func (rg *ResourceGroup) assignNode(id int64) error {
 	if rg.containsNode(id) {
@@ -74,6 +307,7 @@ func (rg *ResourceGroup) assignNode(id int64) error {
 
 	rg.nodes.Insert(id)
 	rg.capacity++
+	rg.recordNodeCount()
 
 	return nil
 }
@@ -86,24 +320,76 @@ func (rg *ResourceGroup) unassignNode(id int64) error {
 	}
 
 	rg.nodes.Remove(id)
-	rg.capacity--
+	if rg.capacity > 0 {
+		rg.capacity--
+	}
+	rg.recordNodeCount()
 
 	return nil
 }
 
+// assign nodes to resource group in one pass, capacity grows with the assigned nodes.
+// nodes already in rg, including duplicated ones in the batch, are skipped and reported
+func (rg *ResourceGroup) assignNodes(ids []int64) error {
+	var errs error
+	for _, id := range ids {
+		if rg.containsNode(id) {
+			errs = multierr.Append(errs, fmt.Errorf("%w(node=%d)", ErrNodeAlreadyAssign, id))
+			continue
+		}
+		rg.nodes.Insert(id)
+		rg.capacity++
+	}
+	rg.recordNodeCount()
+
+	return errs
+}
+
+// unassign nodes from resource group in one pass, capacity shrinks with the unassigned nodes.
+// nodes not in rg, including duplicated ones in the batch, are skipped and reported
+func (rg *ResourceGroup) unassignNodes(ids []int64) error {
+	var errs error
+	for _, id := range ids {
+		if !rg.containsNode(id) {
+			errs = multierr.Append(errs, fmt.Errorf("%w(node=%d)", ErrNodeNotAssignToRG, id))
+			continue
+		}
+		rg.nodes.Remove(id)
+		if rg.capacity > 0 {
+			rg.capacity--
+		}
+	}
+	rg.recordNodeCount()
+
+	return errs
+}
+
 func (rg *ResourceGroup) handleNodeUp(id int64) error {
 	if rg.LackOfNodes() == 0 {
 		return ErrRGIsFull
 	}
 
+	if rg.maxCapacity > 0 && len(rg.nodes) >= rg.maxCapacity {
+		return ErrRGCapacityExceeded
+	}
+
 	if rg.containsNode(id) {
 		return ErrNodeAlreadyAssign
 	}
 
+	if !rg.admits(id) {
+		return ErrNodeNotAdmissible
+	}
+
 	rg.nodes.Insert(id)
+	rg.recordNodeCount()
 	return nil
 }
 
+func (rg *ResourceGroup) admits(id int64) bool {
+	return rg.admission == nil || rg.admission(id)
+}
+
 func (rg *ResourceGroup) handleNodeDown(id int64) error {
 	if !rg.containsNode(id) {
 		// remove non exist node should be tolerable
@@ -111,9 +397,57 @@ func (rg *ResourceGroup) handleNodeDown(id int64) error {
 	}
 
 	rg.nodes.Remove(id)
+	rg.recordNodeCount()
 	return nil
 }
 
+// replace node out with node in, node num and capacity of rg are unchanged.
+// nodes should have been checked by caller, so it never fails
+func (rg *ResourceGroup) swapNode(out, in int64) {
+	rg.nodes.Remove(out)
+	rg.nodes.Insert(in)
+	rg.recordNodeCount()
+}
+
+// insert nodes into rg without changing its capacity.
+// nodes should have been checked by caller, so it never fails
+func (rg *ResourceGroup) insertNodes(ids []int64) {
+	rg.nodes.Insert(ids...)
+	rg.recordNodeCount()
+}
+
+// remove nodes from rg without changing its capacity
+func (rg *ResourceGroup) removeNodes(ids []int64) {
+	rg.nodes.Remove(ids...)
+	rg.recordNodeCount()
+}
+
+// record a node up or down event, events out of churn rate window are dropped.
+// only real node up and down are recorded, membership changes by node moves are not churn
+func (rg *ResourceGroup) recordChurn() {
+	now := rg.clock()
+	window := params.Params.QueryCoordCfg.RGChurnRateWindow.GetAsDuration(time.Second)
+	expired := 0
+	for expired < len(rg.churn) && now.Sub(rg.churn[expired]) > window {
+		expired++
+	}
+	rg.churn = append(rg.churn[expired:], now)
+}
+
+// return node up and down events per minute within churn rate window
+func (rg *ResourceGroup) churnRate() float64 {
+	window := params.Params.QueryCoordCfg.RGChurnRateWindow.GetAsDuration(time.Second)
+	if window <= 0 {
+		return 0
+	}
+
+	now := rg.clock()
+	events := lo.CountBy(rg.churn, func(t time.Time) bool {
+		return now.Sub(t) <= window
+	})
+	return float64(events) / window.Minutes()
+}
+
 func (rg *ResourceGroup) LackOfNodes() int {
 	return rg.capacity - len(rg.nodes)
 }
@@ -130,503 +464,4511 @@ func (rg *ResourceGroup) GetCapacity() int {
 	return rg.capacity
 }
 
-type ResourceManager struct {
-	groups  map[string]*ResourceGroup
-	store   Store
-	nodeMgr *session.NodeManager
-
-	rwmutex sync.RWMutex
+func (rg *ResourceGroup) GetMaxCapacity() int {
+	return rg.maxCapacity
 }
 
-func NewResourceManager(store Store, nodeMgr *session.NodeManager) *ResourceManager {
-	groupMap := make(map[string]*ResourceGroup)
-	groupMap[DefaultResourceGroupName] = NewResourceGroup(1000000)
-	return &ResourceManager{
-		groups:  groupMap,
-		store:   store,
-		nodeMgr: nodeMgr,
-	}
+func (rg *ResourceGroup) GetMinCapacity() int {
+	return rg.minCapacity
 }
 
-func (rm *ResourceManager) AddResourceGroup(rgName string) error {
-	rm.rwmutex.Lock()
-	defer rm.rwmutex.Unlock()
-	if len(rgName) == 0 {
-		return ErrRGNameIsEmpty
-	}
+func (rg *ResourceGroup) IsSealed() bool {
+	return rg.sealed
+}
 
-	if rm.groups[rgName] != nil {
-		return ErrRGAlreadyExist
-	}
+func (rg *ResourceGroup) IsOverlap() bool {
+	return rg.overlap
+}
 
-	if len(rm.groups) >= 1024 {
-		return ErrRGLimit
+func (rg *ResourceGroup) GetLabels() map[string]string {
+	labels := make(map[string]string, len(rg.labels))
+	for k, v := range rg.labels {
+		labels[k] = v
 	}
+	return labels
+}
 
-	err := rm.store.SaveResourceGroup(&querypb.ResourceGroup{
-		Name:     rgName,
-		Capacity: 0,
-	})
-	if err != nil {
-		log.Info("failed to add resource group",
-			zap.String("rgName", rgName),
-			zap.Error(err),
-		)
-		return err
+// num of nodes needed to reach min capacity
+func (rg *ResourceGroup) minCapacityDeficit() int {
+	if len(rg.nodes) >= rg.minCapacity {
+		return 0
 	}
-	rm.groups[rgName] = NewResourceGroup(0)
+	return rg.minCapacity - len(rg.nodes)
+}
 
-	log.Info("add resource group",
-		zap.String("rgName", rgName),
-	)
-	return nil
+// whether capacity will exceed max capacity after adding num nodes
+func (rg *ResourceGroup) exceedMaxCapacity(num int) bool {
+	return rg.maxCapacity > 0 && rg.capacity+num > rg.maxCapacity
 }
 
-func (rm *ResourceManager) RemoveResourceGroup(rgName string) error {
-	rm.rwmutex.Lock()
-	defer rm.rwmutex.Unlock()
-	if rgName == DefaultResourceGroupName {
-		return ErrDeleteDefaultRG
-	}
+type ResourceGroupStats struct {
+	Capacity      int
+	AssignedNodes int
+	// assigned nodes which are not in stopping state
+	AvailableNodes int
+	LackingNodes   int
+}
 
-	if rm.groups[rgName] == nil {
-		// delete a non-exist rg should be tolerable
-		return nil
-	}
+// overview of all rgs in cluster
+type ClusterSummary struct {
+	ResourceGroupNum int
+	// capacity of default rg is a placeholder, so it's excluded from capacity and lacking
+	TotalCapacity int
+	// live nodes assigned to any rg, node shared by overlap rgs is counted once
+	AssignedNodes int
+	// live nodes which are not in stopping state and not assigned to any rg
+	UnassignedNodes int
+	TotalLacking    int
+}
 
-	if rm.groups[rgName].GetCapacity() != 0 {
-		return ErrDeleteNonEmptyRG
-	}
+// difference of a rg between memory and store
+type ResourceGroupDiff struct {
+	Name     string
+	InMemory bool
+	InStore  bool
+	// capacity is 0 on the side where rg is missing
+	MemoryCapacity int
+	StoredCapacity int
+	// nodes in memory but not in store
+	NodesMissingInStore []int64
+	// nodes in store but not in memory
+	NodesMissingInMemory []int64
+}
 
-	err := rm.store.RemoveResourceGroup(rgName)
-	if err != nil {
-		log.Info("failed to remove resource group",
-			zap.String("rgName", rgName),
-			zap.Error(err),
-		)
-		return err
-	}
-	delete(rm.groups, rgName)
+// serializable description of a resource group for admin apis, json field names are stable
+type ResourceGroupInfo struct {
+	Name     string            `json:"name"`
+	Capacity int               `json:"capacity"`
+	Nodes    []int64           `json:"nodes"`
+	Lacking  int               `json:"lacking"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
 
-	log.Info("remove resource group",
-		zap.String("rgName", rgName),
-	)
-	return nil
+// point-in-time view of a resource group, it's copied from rg so never changes along with rg
+type ResourceGroupSnapshot struct {
+	Capacity    int               `json:"capacity"`
+	MaxCapacity int               `json:"max_capacity"`
+	MinCapacity int               `json:"min_capacity"`
+	Nodes       []int64           `json:"nodes"`
+	Sealed      bool              `json:"sealed,omitempty"`
+	Overlap     bool              `json:"overlap,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
 }
 
-func (rm *ResourceManager) AssignNode(rgName string, node int64) error {
-	rm.rwmutex.Lock()
-	defer rm.rwmutex.Unlock()
-	return rm.assignNode(rgName, node)
+func (s ResourceGroupSnapshot) GetCapacity() int {
+	return s.Capacity
 }
 
-func (rm *ResourceManager) assignNode(rgName string, node int64) error {
-	if rm.groups[rgName] == nil {
-		return ErrRGNotExist
-	}
+func (s ResourceGroupSnapshot) GetMaxCapacity() int {
+	return s.MaxCapacity
+}
 
-	if rm.nodeMgr.Get(node) == nil {
-		return ErrNodeNotExist
-	}
+func (s ResourceGroupSnapshot) GetMinCapacity() int {
+	return s.MinCapacity
+}
 
-	if ok, _ := rm.nodeMgr.IsStoppingNode(node); ok {
-		return ErrNodeStopped
-	}
+func (s ResourceGroupSnapshot) GetNodes() []int64 {
+	return s.Nodes
+}
 
-	rm.checkRGNodeStatus(rgName)
-	if rm.checkNodeAssigned(node) {
-		return ErrNodeAlreadyAssign
-	}
+func (s ResourceGroupSnapshot) LackOfNodes() int {
+	return s.Capacity - len(s.Nodes)
+}
 
-	newNodes := rm.groups[rgName].GetNodes()
-	newNodes = append(newNodes, node)
-	err := rm.store.SaveResourceGroup(&querypb.ResourceGroup{
-		Name:     rgName,
-		Capacity: int32(rm.groups[rgName].GetCapacity()) + 1,
-		Nodes:    newNodes,
-	})
-	if err != nil {
-		log.Info("failed to add node to resource group",
-			zap.String("rgName", rgName),
-			zap.Int64("node", node),
-			zap.Error(err),
-		)
-		return err
-	}
+func (s ResourceGroupSnapshot) IsSealed() bool {
+	return s.Sealed
+}
 
-	err = rm.groups[rgName].assignNode(node)
-	if err != nil {
-		return err
-	}
+func (s ResourceGroupSnapshot) IsOverlap() bool {
+	return s.Overlap
+}
 
-	log.Info("add node to resource group",
-		zap.String("rgName", rgName),
-		zap.Int64("node", node),
-	)
+func (s ResourceGroupSnapshot) GetLabels() map[string]string {
+	return s.Labels
+}
 
-	return nil
+// estimated cost of transferring nodes between rgs
+type TransferCost struct {
+	// nodes which would be transferred
+	Nodes []int64
+	// num of segments which would be migrated
+	SegmentNum int
+	// node -> num of segments on it
+	NodeSegmentNum map[int64]int
 }
 
-func (rm *ResourceManager) checkNodeAssigned(node int64) bool {
-	for _, group := range rm.groups {
-		if group.containsNode(node) {
-			return true
-		}
-	}
+type ResourceGroupEventType int32
+
+const (
+	NodeAdded ResourceGroupEventType = iota + 1
+	NodeRemoved
+	RGCreated
+	RGRemoved
+)
 
-	return false
+// buffer size of each subscriber's channel, events will be dropped if subscriber can't catch up
+const resourceGroupEventBufferSize = 1024
+
+type ResourceGroupEvent struct {
+	RGName string
+	Type   ResourceGroupEventType
+	// only set for NodeAdded and NodeRemoved
+	Node int64
 }
 
-func (rm *ResourceManager) UnassignNode(rgName string, node int64) error {
-	rm.rwmutex.Lock()
-	defer rm.rwmutex.Unlock()
+// ReplicaHolder provides replicas which reference a rg
+type ReplicaHolder interface {
+	GetByResourceGroup(rgName string) []*Replica
+}
 
-	return rm.unassignNode(rgName, node)
+// ResourceManagerView exposes the read-only methods of ResourceManager,
+// for subsystems which only inspect resource groups
+type ResourceManagerView interface {
+	GetNodes(rgName string) ([]int64, error)
+	ListResourceGroups() []string
+	GetResourceGroup(rgName string) (ResourceGroupSnapshot, error)
+	FindResourceGroupByNode(node int64) (string, error)
+	ContainsNode(rgName string, node int64) bool
+	CheckLackOfNode(rgName string) int
+	CheckOutboundNodes(replica *Replica) typeutil.UniqueSet
 }
 
-func (rm *ResourceManager) unassignNode(rgName string, node int64) error {
-	if rm.groups[rgName] == nil {
-		return ErrRGNotExist
-	}
+var _ ResourceManagerView = (*ResourceManager)(nil)
 
-	if rm.nodeMgr.Get(node) == nil {
-		// remove non exist node should be tolerable
-		return nil
-	}
+type ResourceManager struct {
+	// name of the rg which holds all unassigned nodes
+	defaultRGName string
+	groups        map[string]*ResourceGroup
+	// reverse index from node to the resource group which it belongs to
+	nodeToRG map[int64]string
+	// the non-default resource group which node has been placed into, survives node restart
+	nodeHomeRG map[int64]string
+	store      Store
+	nodeMgr    *session.NodeManager
+	selector   NodeSelector
+	// used to check whether rg is still in use before removing it, and to enforce the replica quota
+	replicas ReplicaHolder
+	// operations succeeded recently with idempotency token, retries of them are deduped
+	recentOps *recentOperations
 
-	newNodes := make([]int64, 0)
-	for nid := range rm.groups[rgName].nodes {
-		if nid != node {
-			newNodes = append(newNodes, nid)
-		}
-	}
+	maxResourceGroupNum int
 
-	err := rm.store.SaveResourceGroup(&querypb.ResourceGroup{
-		Name:     rgName,
-		Capacity: int32(rm.groups[rgName].GetCapacity()) - 1,
-		Nodes:    newNodes,
-	})
-	if err != nil {
-		log.Info("remove node from resource group",
-			zap.String("rgName", rgName),
-			zap.Int64("node", node),
-			zap.Error(err),
-		)
-		return err
-	}
+	rwmutex sync.RWMutex
 
-	rm.checkRGNodeStatus(rgName)
-	err = rm.groups[rgName].unassignNode(node)
-	if err != nil {
-		return err
-	}
+	subscriberMutex  sync.Mutex
+	subscribers      map[int64]chan ResourceGroupEvent
+	nextSubscriberID int64
+	// set on close, new subscribers get a closed channel
+	subscriberClosed bool
 
-	log.Info("remove node from resource group",
-		zap.String("rgName", rgName),
-		zap.Int64("node", node),
-	)
+	lackListenerMutex sync.Mutex
+	lackListeners     []func(rgName string, lack int)
+	// tracks running lack listeners, close waits for them
+	lackListenerWg sync.WaitGroup
 
-	return nil
-}
+	// rgName -> *atomic.Uint64, counts lookups of rg without taking the write lock
+	accessStats sync.Map
 
-func (rm *ResourceManager) GetNodes(rgName string) ([]int64, error) {
-	rm.rwmutex.RLock()
-	defer rm.rwmutex.RUnlock()
-	if rm.groups[rgName] == nil {
-		return nil, ErrRGNotExist
-	}
+	// whether overlap rgs could be created
+	allowOverlap bool
+	// used to spread nodes selected for a rg across racks, nil means no preference
+	topology NodeTopologyProvider
 
-	rm.checkRGNodeStatus(rgName)
+	// used to estimate segments migrated by transfer
+	segments SegmentProvider
 
-	return rm.groups[rgName].GetNodes(), nil
+	// logger of audit records, nil means the global logger
+	auditLogger *zap.Logger
+
+	// set while Recover is running, mutating methods are rejected meanwhile
+	recovering bool
+
+	// rg -> stored nodes dropped in last recovery since they don't exist in node manager
+	recoverDroppedNodes map[string][]int64
+
+	// node -> timer of the deferred removal, cancelled if node comes back within grace period
+	pendingNodeDown map[int64]*time.Timer
+	closed          bool
 }
 
-// return all outbound node
-func (rm *ResourceManager) CheckOutboundNodes(replica *Replica) typeutil.UniqueSet {
-	rm.rwmutex.RLock()
-	defer rm.rwmutex.RUnlock()
+func NewResourceManager(store Store, nodeMgr *session.NodeManager) *ResourceManager {
+	return NewResourceManagerWithDefaultRG(store, nodeMgr, DefaultResourceGroupName)
+}
 
-	if rm.groups[replica.GetResourceGroup()] == nil {
-		return typeutil.NewUniqueSet()
+// create manager whose default rg is named defaultRGName, empty name falls back to DefaultResourceGroupName
+func NewResourceManagerWithDefaultRG(store Store, nodeMgr *session.NodeManager, defaultRGName string) *ResourceManager {
+	if len(defaultRGName) == 0 {
+		defaultRGName = DefaultResourceGroupName
 	}
-	rg := rm.groups[replica.GetResourceGroup()]
+	groupMap := make(map[string]*ResourceGroup)
+	groupMap[defaultRGName] = NewResourceGroup(defaultResourceGroupCapacity)
+	return &ResourceManager{
+		defaultRGName: defaultRGName,
+		groups:        groupMap,
+		nodeToRG:      make(map[int64]string),
+		nodeHomeRG:    make(map[int64]string),
+		store:         store,
+		nodeMgr:       nodeMgr,
+		selector:      NewFirstNodeSelector(),
 
-	ret := typeutil.NewUniqueSet()
-	for _, node := range replica.GetNodes() {
-		if !rg.containsNode(node) {
-			ret.Insert(node)
-		}
+		maxResourceGroupNum: params.Params.QueryCoordCfg.MaxResourceGroupNum.GetAsInt(),
+		subscribers:         make(map[int64]chan ResourceGroupEvent),
+		pendingNodeDown:     make(map[int64]*time.Timer),
+		recentOps:           newRecentOperations(recentOperationCacheSize),
 	}
+}
 
-	return ret
+// create manager which allows overlap rgs, whose nodes could be shared with other rgs
+func NewResourceManagerWithOverlap(store Store, nodeMgr *session.NodeManager) *ResourceManager {
+	rm := NewResourceManager(store, nodeMgr)
+	rm.allowOverlap = true
+	return rm
 }
 
-// return outgoing node num on each rg from this replica
-func (rm *ResourceManager) GetOutgoingNodeNumByReplica(replica *Replica) map[string]int32 {
-	rm.rwmutex.RLock()
-	defer rm.rwmutex.RUnlock()
+func (rm *ResourceManager) GetDefaultResourceGroupName() string {
+	return rm.defaultRGName
+}
 
-	if rm.groups[replica.GetResourceGroup()] == nil {
-		return nil
-	}
+func (rm *ResourceManager) SetNodeSelector(selector NodeSelector) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	rm.selector = selector
+}
 
-	rg := rm.groups[replica.GetResourceGroup()]
-	ret := make(map[string]int32)
-	for _, node := range replica.GetNodes() {
-		if !rg.containsNode(node) {
-			rgName, err := rm.findResourceGroupByNode(node)
-			if err == nil {
-				ret[rgName]++
-			}
-		}
+// select the least loaded nodes to transfer or recover by provider,
+// nil provider falls back to select the first nodes
+func (rm *ResourceManager) SetLoadProvider(provider LoadProvider) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if provider == nil {
+		rm.selector = NewFirstNodeSelector()
+		return
 	}
+	rm.selector = NewLeastLoadNodeSelector(provider)
+}
 
-	return ret
+// set topology provider, so nodes selected by TransferNode and AutoRecover spread across racks.
+// nil provider disables the spreading
+func (rm *ResourceManager) SetTopologyProvider(provider NodeTopologyProvider) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	rm.topology = provider
 }
 
-func (rm *ResourceManager) ContainsNode(rgName string, node int64) bool {
-	rm.rwmutex.RLock()
-	defer rm.rwmutex.RUnlock()
-	if rm.groups[rgName] == nil {
-		return false
-	}
+func (rm *ResourceManager) SetSegmentProvider(provider SegmentProvider) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	rm.segments = provider
+}
 
-	rm.checkRGNodeStatus(rgName)
-	return rm.groups[rgName].containsNode(node)
+// SetAuditLogger routes audit records to logger, such as a dedicated audit file. nil means the global logger
+func (rm *ResourceManager) SetAuditLogger(logger *zap.Logger) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	rm.auditLogger = logger
 }
 
-func (rm *ResourceManager) ContainResourceGroup(rgName string) bool {
-	rm.rwmutex.RLock()
-	defer rm.rwmutex.RUnlock()
-	return rm.groups[rgName] != nil
+func (rm *ResourceManager) SetReplicaHolder(replicas ReplicaHolder) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	rm.replicas = replicas
 }
 
-func (rm *ResourceManager) GetResourceGroup(rgName string) (*ResourceGroup, error) {
+// CanAcceptReplica returns whether replicaNum more replicas could be loaded into rg without exceeding the quota,
+// replicas already in rg are counted by the replica holder
+func (rm *ResourceManager) CanAcceptReplica(rgName string, replicaNum int) bool {
 	rm.rwmutex.RLock()
 	defer rm.rwmutex.RUnlock()
-
 	if rm.groups[rgName] == nil {
-		return nil, ErrRGNotExist
+		return false
 	}
 
-	rm.checkRGNodeStatus(rgName)
-	return rm.groups[rgName], nil
+	maxReplicaNum := params.Params.QueryCoordCfg.RGMaxReplicaNum.GetAsInt()
+	if maxReplicaNum <= 0 {
+		return true
+	}
+	loaded := 0
+	if rm.replicas != nil {
+		loaded = len(rm.replicas.GetByResourceGroup(rgName))
+	}
+	return loaded+replicaNum <= maxReplicaNum
 }
 
-func (rm *ResourceManager) ListResourceGroups() []string {
-	rm.rwmutex.RLock()
-	defer rm.rwmutex.RUnlock()
+// Subscribe returns a channel which receives resource group membership changes,
+// and a cancel func to unregister it. The channel will be closed after cancel.
+func (rm *ResourceManager) Subscribe() (<-chan ResourceGroupEvent, func()) {
+	rm.subscriberMutex.Lock()
+	defer rm.subscriberMutex.Unlock()
 
-	return lo.Keys(rm.groups)
+	ch := make(chan ResourceGroupEvent, resourceGroupEventBufferSize)
+	if rm.subscriberClosed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	id := rm.nextSubscriberID
+	rm.nextSubscriberID++
+	rm.subscribers[id] = ch
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			rm.subscriberMutex.Lock()
+			defer rm.subscriberMutex.Unlock()
+			// channel has been closed if manager is closed
+			if _, ok := rm.subscribers[id]; ok {
+				delete(rm.subscribers, id)
+				close(ch)
+			}
+		})
+	}
+	return ch, cancel
 }
 
-func (rm *ResourceManager) FindResourceGroupByNode(node int64) (string, error) {
-	rm.rwmutex.RLock()
-	defer rm.rwmutex.RUnlock()
+// OnLack registers a listener which is called when a rg turns from fully provisioned to lacking of nodes.
+// listeners are called asynchronously without holding the lock, so they could call back into the manager
+func (rm *ResourceManager) OnLack(listener func(rgName string, lack int)) {
+	rm.lackListenerMutex.Lock()
+	defer rm.lackListenerMutex.Unlock()
+	rm.lackListeners = append(rm.lackListeners, listener)
+}
 
-	return rm.findResourceGroupByNode(node)
+// fire lack listeners if rg starts lacking of nodes, lackBefore is rg's lack before the change
+func (rm *ResourceManager) checkLackTransition(rgName string, lackBefore int) {
+	lack := rm.groups[rgName].LackOfNodes()
+	if lackBefore > 0 || lack <= 0 {
+		return
+	}
+
+	rm.lackListenerMutex.Lock()
+	listeners := make([]func(string, int), len(rm.lackListeners))
+	copy(listeners, rm.lackListeners)
+	rm.lackListenerMutex.Unlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+	log.Info("resource group starts lacking of nodes",
+		zap.String("rgName", rgName),
+		zap.Int("lack", lack),
+	)
+	rm.lackListenerWg.Add(1)
+	go func() {
+		defer rm.lackListenerWg.Done()
+		for _, listener := range listeners {
+			listener(rgName, lack)
+		}
+	}()
 }
 
-func (rm *ResourceManager) findResourceGroupByNode(node int64) (string, error) {
-	for name, group := range rm.groups {
-		if group.containsNode(node) {
-			return name, nil
+// notify never blocks, events will be dropped for subscribers whose channel is full
+func (rm *ResourceManager) notify(events ...ResourceGroupEvent) {
+	rm.subscriberMutex.Lock()
+	defer rm.subscriberMutex.Unlock()
+
+	for _, ch := range rm.subscribers {
+		for _, event := range events {
+			select {
+			case ch <- event:
+			default:
+				log.Warn("resource group event channel is full, drop event",
+					zap.String("rgName", event.RGName),
+					zap.Int32("type", int32(event.Type)),
+					zap.Int64("node", event.Node),
+				)
+			}
 		}
 	}
+}
 
-	return "", ErrNodeNotAssignToRG
+func (rm *ResourceManager) AddResourceGroup(ctx context.Context, rgName string) error {
+	return rm.AddResourceGroupWithLimit(ctx, rgName, 0)
 }
 
-func (rm *ResourceManager) HandleNodeUp(node int64) (string, error) {
+// add rg which holds at most maxCapacity nodes, 0 means unlimited
+func (rm *ResourceManager) AddResourceGroupWithLimit(ctx context.Context, rgName string, maxCapacity int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	rm.rwmutex.Lock()
 	defer rm.rwmutex.Unlock()
-
-	if rm.nodeMgr.Get(node) == nil {
-		return "", ErrNodeNotExist
+	if err := rm.checkRecovering(); err != nil {
+		return err
 	}
 
-	if ok, _ := rm.nodeMgr.IsStoppingNode(node); ok {
-		return "", ErrNodeStopped
-	}
+	operation := fmt.Sprintf("AddResourceGroup(rgName=%s, maxCapacity=%d)", rgName, maxCapacity)
+	return rm.runIdempotent(ctx, operation, func() error {
+		if len(rgName) == 0 {
+			return ErrRGNameIsEmpty
+		}
 
-	// if node already assign to rg
-	rgName, err := rm.findResourceGroupByNode(node)
-	if err == nil {
-		log.Info("HandleNodeUp: node already assign to resource group",
-			zap.String("rgName", rgName),
-			zap.Int64("node", node),
-		)
-		return rgName, nil
-	}
+		if err := rm.checkResourceGroupName(rgName); err != nil {
+			return err
+		}
 
-	// add new node to default rg
-	rm.groups[DefaultResourceGroupName].handleNodeUp(node)
-	log.Info("HandleNodeUp: assign node to default resource group",
-		zap.String("rgName", DefaultResourceGroupName),
-		zap.Int64("node", node),
-	)
-	return DefaultResourceGroupName, nil
+		if rm.groups[rgName] != nil {
+			return ErrRGAlreadyExist
+		}
+
+		return rm.addResourceGroup(ctx, rgName, 0, maxCapacity, nil, false)
+	})
 }
 
-func (rm *ResourceManager) HandleNodeDown(node int64) (string, error) {
+// add rg with initial capacity, lacking nodes will be populated by auto recover.
+// adding an existing rg with the same capacity is a no-op
+func (rm *ResourceManager) AddResourceGroupWithCapacity(ctx context.Context, rgName string, capacity int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	rm.rwmutex.Lock()
 	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+	if len(rgName) == 0 {
+		return ErrRGNameIsEmpty
+	}
 
-	if rm.nodeMgr.Get(node) == nil {
-		return "", ErrNodeNotExist
+	if err := rm.checkResourceGroupName(rgName); err != nil {
+		return err
 	}
 
-	rgName, err := rm.findResourceGroupByNode(node)
-	if err == nil {
-		log.Info("HandleNodeDown: remove node from resource group",
-			zap.String("rgName", rgName),
-			zap.Int64("node", node),
-		)
-		return rgName, rm.groups[rgName].handleNodeDown(node)
+	if capacity < 0 {
+		return fmt.Errorf("%w(capacity=%d)", ErrRGCapacityInvalid, capacity)
 	}
 
-	return "", ErrNodeNotAssignToRG
+	if rm.groups[rgName] != nil {
+		if rm.groups[rgName].GetCapacity() == capacity {
+			return nil
+		}
+		return fmt.Errorf("%w(rgName=%s): capacity %d conflicts with existing capacity %d",
+			ErrRGAlreadyExist, rgName, capacity, rm.groups[rgName].GetCapacity())
+	}
+
+	return rm.addResourceGroup(ctx, rgName, capacity, 0, nil, false)
 }
 
-func (rm *ResourceManager) TransferNode(from, to string) error {
+// create rg and assign nodes to it in one store write, so the rg never shows up without its nodes.
+// nothing changes if the rg already exists or any node can't be assigned
+func (rm *ResourceManager) AssignNodesToNewResourceGroup(ctx context.Context, rgName string, nodes []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	rm.rwmutex.Lock()
 	defer rm.rwmutex.Unlock()
-
-	if rm.groups[from] == nil || rm.groups[to] == nil {
-		return ErrRGNotExist
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+	if len(rgName) == 0 {
+		return ErrRGNameIsEmpty
 	}
 
-	if len(rm.groups[from].nodes) == 0 {
-		return ErrRGIsEmpty
+	if err := rm.checkResourceGroupName(rgName); err != nil {
+		return err
 	}
 
-	rm.checkRGNodeStatus(from)
-	rm.checkRGNodeStatus(to)
+	if rm.groups[rgName] != nil {
+		return fmt.Errorf("%w(rgName=%s)", ErrRGAlreadyExist, rgName)
+	}
 
-	//todo: a better way to choose a node with least balance cost
-	node := rm.groups[from].GetNodes()[0]
-	if err := rm.transferNodeInStore(from, to, node); err != nil {
+	if err := rm.checkNodesAssignable(nodes, false); err != nil {
+		log.Info("failed to add resource group with nodes",
+			zap.String("rgName", rgName),
+			zap.Int64s("nodes", nodes),
+			zap.Error(err),
+		)
 		return err
 	}
 
-	err := rm.groups[from].unassignNode(node)
-	if err != nil {
-		// interrupt transfer, unreachable logic path
+	return rm.addResourceGroup(ctx, rgName, len(nodes), 0, nodes, false)
+}
+
+// add rg whose nodes could also belong to other rgs, only permitted if manager allows overlap.
+// nodes of overlap rg can't be transferred or auto recovered
+func (rm *ResourceManager) AddOverlapResourceGroup(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
-	err = rm.groups[to].assignNode(node)
-	if err != nil {
-		// interrupt transfer, unreachable logic path
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
 		return err
 	}
+	if !rm.allowOverlap {
+		return fmt.Errorf("%w(rgName=%s)", ErrRGOverlapNotAllowed, rgName)
+	}
 
-	return nil
-}
-
-func (rm *ResourceManager) transferNodeInStore(from string, to string, node int64) error {
-	fromNodeList := make([]int64, 0)
-	for nid := range rm.groups[from].nodes {
-		if nid != node {
-			fromNodeList = append(fromNodeList, nid)
-		}
+	if len(rgName) == 0 {
+		return ErrRGNameIsEmpty
 	}
-	toNodeList := rm.groups[to].GetNodes()
-	toNodeList = append(toNodeList, node)
 
-	fromRG := &querypb.ResourceGroup{
-		Name:     from,
-		Capacity: int32(rm.groups[from].GetCapacity()) - 1,
-		Nodes:    fromNodeList,
+	if err := rm.checkResourceGroupName(rgName); err != nil {
+		return err
 	}
 
-	toRG := &querypb.ResourceGroup{
-		Name:     to,
-		Capacity: int32(rm.groups[to].GetCapacity()) + 1,
-		Nodes:    toNodeList,
+	if rm.groups[rgName] != nil {
+		return fmt.Errorf("%w(rgName=%s)", ErrRGAlreadyExist, rgName)
 	}
 
-	return rm.store.SaveResourceGroup(fromRG, toRG)
+	return rm.addResourceGroup(ctx, rgName, 0, 0, nil, true)
 }
 
-// auto recover rg, return recover used node num
-func (rm *ResourceManager) AutoRecoverResourceGroup(rgName string) (int, error) {
-	rm.rwmutex.Lock()
-	defer rm.rwmutex.Unlock()
+func (rm *ResourceManager) addResourceGroup(ctx context.Context, rgName string, capacity int, maxCapacity int, nodes []int64, overlap bool) error {
+	if len(rm.groups) >= rm.maxResourceGroupNum {
+		return fmt.Errorf("%w %d", ErrRGLimit, rm.maxResourceGroupNum)
+	}
 
-	if rm.groups[rgName] == nil {
-		return 0, ErrRGNotExist
+	if maxCapacity < 0 {
+		return fmt.Errorf("%w(maxCapacity=%d)", ErrRGMaxCapacityInvalid, maxCapacity)
 	}
 
-	rm.checkRGNodeStatus(rgName)
-	lackNodesNum := rm.groups[rgName].LackOfNodes()
-	nodesInDefault := rm.groups[DefaultResourceGroupName].GetNodes()
-	for i := 0; i < len(nodesInDefault) && i < lackNodesNum; i++ {
-		//todo: a better way to choose a node with least balance cost
-		node := nodesInDefault[i]
-		err := rm.unassignNode(DefaultResourceGroupName, node)
-		if err != nil {
-			// interrupt transfer, unreachable logic path
-			return i + 1, err
-		}
+	// records left by a former rg with the same name would attach to this rg in recover, clear them first
+	if err := rm.store.RemoveResourceGroupAttributes(ctx, rgName); err != nil {
+		log.Info("failed to add resource group",
+			zap.String("rgName", rgName),
+			zap.Error(err),
+		)
+		return err
+	}
 
-		err = rm.groups[rgName].handleNodeUp(node)
+	// overlap record and limit are saved before rg, so recover never sees the rg without them
+	if overlap {
+		if err := rm.store.SaveOverlapResourceGroup(ctx, rgName); err != nil {
+			log.Info("failed to add resource group",
+				zap.String("rgName", rgName),
+				zap.Error(err),
+			)
+			rm.removeResourceGroupAttributes(ctx, rgName)
+			return err
+		}
+	}
+	if maxCapacity > 0 {
+		err := rm.store.SaveResourceGroupLimit(ctx, rgName, int32(maxCapacity))
 		if err != nil {
-			// roll back, unreachable logic path
-			rm.assignNode(DefaultResourceGroupName, node)
+			log.Info("failed to add resource group",
+				zap.String("rgName", rgName),
+				zap.Error(err),
+			)
+			rm.removeResourceGroupAttributes(ctx, rgName)
+			return err
 		}
 	}
 
-	return lackNodesNum, nil
-}
-
-func (rm *ResourceManager) Recover() error {
-	rm.rwmutex.Lock()
-	defer rm.rwmutex.Unlock()
-	rgs, err := rm.store.GetResourceGroups()
+	err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+		Name:     rgName,
+		Capacity: int32(capacity),
+		Nodes:    nodes,
+	})
 	if err != nil {
-		return ErrRecoverResourceGroupToStore
+		log.Info("failed to add resource group",
+			zap.String("rgName", rgName),
+			zap.Error(err),
+		)
+		rm.removeResourceGroupAttributes(ctx, rgName)
+		return err
 	}
 
-	for _, rg := range rgs {
-		rm.groups[rg.GetName()] = NewResourceGroup(0)
-		for _, node := range rg.GetNodes() {
-			rm.groups[rg.GetName()].assignNode(node)
-		}
-		rm.checkRGNodeStatus(rg.GetName())
-		log.Info("Recover resource group",
-			zap.String("rgName", rg.GetName()),
-			zap.Int64s("nodes", rg.GetNodes()),
-			zap.Int32("capacity", rg.GetCapacity()),
+	// only create rg in memory after the stored one is confirmed, otherwise roll back the store write
+	if err := rm.verifyStoredResourceGroup(ctx, rgName, capacity, nodes); err != nil {
+		log.Warn("failed to verify stored resource group, roll back it",
+			zap.String("rgName", rgName),
+			zap.Error(err),
 		)
+		if rollbackErr := rm.store.RemoveResourceGroup(ctx, rgName); rollbackErr != nil {
+			log.Warn("failed to roll back stored resource group",
+				zap.String("rgName", rgName),
+				zap.Error(rollbackErr),
+			)
+		}
+		rm.removeResourceGroupAttributes(ctx, rgName)
+		return err
+	}
+	rm.groups[rgName] = NewResourceGroup(capacity)
+	rm.groups[rgName].maxCapacity = maxCapacity
+	rm.groups[rgName].overlap = overlap
+	// nodes are counted in capacity already, so don't assign them one by one
+	rm.groups[rgName].nodes.Insert(nodes...)
+	rm.groups[rgName].recordNodeCount()
+	rm.notify(ResourceGroupEvent{RGName: rgName, Type: RGCreated})
+	for _, node := range nodes {
+		rm.indexNode(ctx, node, rgName)
+		rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeAdded, Node: node})
 	}
+	rm.updateResourceGroupMetrics(rgName)
 
+	log.Info("add resource group",
+		zap.String("rgName", rgName),
+		zap.Int("capacity", capacity),
+		zap.Int("maxCapacity", maxCapacity),
+		zap.Int64s("nodes", nodes),
+	)
+	rm.audit(ctx, "AddResourceGroup",
+		zap.String("rgName", rgName),
+		zap.Int("capacity", capacity),
+		zap.Int64s("nodes", nodes),
+	)
 	return nil
 }
 
-// every operation which involves nodes access, should check nodes status first
-func (rm *ResourceManager) checkRGNodeStatus(rgName string) {
-	for _, node := range rm.groups[rgName].GetNodes() {
-		if rm.nodeMgr.Get(node) == nil {
-			log.Info("found node down, remove it",
-				zap.String("rgName", rgName),
-				zap.Int64("nodeID", node),
-			)
-
-			rm.groups[rgName].handleNodeDown(node)
-		}
+// create dst with the same capacity and max capacity as src. if manager allows overlap,
+// dst is created as overlap rg sharing nodes of src, otherwise it starts with no node
+// since a node can't belong to two rgs, and lacking nodes will be populated by auto recover
+func (rm *ResourceManager) CloneResourceGroup(ctx context.Context, src, dst string) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-}
 
-// return lack of nodes num
-func (rm *ResourceManager) CheckLackOfNode(rgName string) int {
 	rm.rwmutex.Lock()
 	defer rm.rwmutex.Unlock()
-	if rm.groups[rgName] == nil {
-		return 0
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+	if rm.groups[src] == nil {
+		return &ResourceGroupNotFoundError{Name: src}
+	}
+
+	if len(dst) == 0 {
+		return ErrRGNameIsEmpty
 	}
 
-	rm.checkRGNodeStatus(rgName)
+	if err := rm.checkResourceGroupName(dst); err != nil {
+		return err
+	}
+
+	if rm.groups[dst] != nil {
+		return fmt.Errorf("%w(rgName=%s)", ErrRGAlreadyExist, dst)
+	}
 
-	return rm.groups[rgName].LackOfNodes()
+	srcRG := rm.groups[src]
+	if !rm.allowOverlap {
+		return rm.addResourceGroup(ctx, dst, srcRG.GetCapacity(), srcRG.GetMaxCapacity(), nil, false)
+	}
+
+	nodes := srcRG.GetNodes()
+	if err := rm.checkNodesAssignable(nodes, true); err != nil {
+		log.Info("failed to clone resource group",
+			zap.String("src", src),
+			zap.String("dst", dst),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	// capacity of rg never drops below its node num
+	capacity := srcRG.GetCapacity()
+	if len(nodes) > capacity {
+		capacity = len(nodes)
+	}
+	return rm.addResourceGroup(ctx, dst, capacity, srcRG.GetMaxCapacity(), nodes, true)
+}
+
+// check the newly added rg has been stored with the expected capacity and nodes,
+// only the written key is read back so it's cheap under the write lock
+func (rm *ResourceManager) verifyStoredResourceGroup(ctx context.Context, rgName string, capacity int, nodes []int64) error {
+	rg, err := rm.store.GetResourceGroup(ctx, rgName)
+	if err != nil {
+		return err
+	}
+	if rg == nil {
+		return fmt.Errorf("%w(rgName=%s): not found in store", ErrRGStoreMismatch, rgName)
+	}
+
+	if int(rg.GetCapacity()) != capacity || len(rg.GetNodes()) != len(nodes) ||
+		!typeutil.NewUniqueSet(rg.GetNodes()...).Contain(nodes...) {
+		return fmt.Errorf("%w(rgName=%s, capacity=%d, nodes=%v, storedCapacity=%d, storedNodes=%v)",
+			ErrRGStoreMismatch, rgName, capacity, nodes, rg.GetCapacity(), rg.GetNodes())
+	}
+	return nil
+}
+
+// update max capacity of rg, 0 means unlimited. lowering it below capacity shrinks capacity as well,
+// nodes beyond the new capacity stay in rg until AutoShedResourceGroup returns them to default rg
+func (rm *ResourceManager) SetResourceGroupLimit(ctx context.Context, rgName string, maxCapacity int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rgName == rm.defaultRGName {
+		return fmt.Errorf("%w: limit default rg is not permitted", ErrRGMaxCapacityInvalid)
+	}
+
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if maxCapacity < 0 {
+		return fmt.Errorf("%w(maxCapacity=%d)", ErrRGMaxCapacityInvalid, maxCapacity)
+	}
+
+	rg := rm.groups[rgName]
+	if maxCapacity > 0 && maxCapacity < rg.GetMinCapacity() {
+		return fmt.Errorf("%w(maxCapacity=%d): less than min capacity %d",
+			ErrRGMaxCapacityInvalid, maxCapacity, rg.GetMinCapacity())
+	}
+
+	oldMaxCapacity := rg.GetMaxCapacity()
+	if err := rm.saveResourceGroupLimit(ctx, rgName, maxCapacity); err != nil {
+		log.Info("failed to set resource group limit",
+			zap.String("rgName", rgName),
+			zap.Int("maxCapacity", maxCapacity),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if maxCapacity > 0 && maxCapacity < rg.GetCapacity() {
+		err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+			Name:     rgName,
+			Capacity: int32(maxCapacity),
+			Nodes:    rg.GetNodes(),
+		})
+		if err != nil {
+			log.Info("failed to shrink resource group capacity to limit",
+				zap.String("rgName", rgName),
+				zap.Int("maxCapacity", maxCapacity),
+				zap.Error(err),
+			)
+			// roll back the limit, so recover never sees a limit below the stored capacity
+			if rollbackErr := rm.saveResourceGroupLimit(ctx, rgName, oldMaxCapacity); rollbackErr != nil {
+				log.Warn("failed to roll back resource group limit",
+					zap.String("rgName", rgName),
+					zap.Error(rollbackErr),
+				)
+			}
+			return err
+		}
+		rg.capacity = maxCapacity
+		rm.updateResourceGroupMetrics(rgName)
+	}
+	rg.maxCapacity = maxCapacity
+
+	log.Info("set resource group limit",
+		zap.String("rgName", rgName),
+		zap.Int("maxCapacity", maxCapacity),
+	)
+	return nil
+}
+
+// save limit record of rg, 0 removes it
+func (rm *ResourceManager) saveResourceGroupLimit(ctx context.Context, rgName string, maxCapacity int) error {
+	if maxCapacity == 0 {
+		return rm.store.RemoveResourceGroupLimit(ctx, rgName)
+	}
+	return rm.store.SaveResourceGroupLimit(ctx, rgName, int32(maxCapacity))
+}
+
+// change rg's capacity without touching its nodes, the lack of nodes will be filled by auto recover
+func (rm *ResourceManager) SetResourceGroupCapacity(ctx context.Context, rgName string, capacity int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rgName == rm.defaultRGName {
+		return fmt.Errorf("%w: resize default rg is not permitted", ErrRGCapacityInvalid)
+	}
+
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if capacity < 0 {
+		return fmt.Errorf("%w(capacity=%d)", ErrRGCapacityInvalid, capacity)
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	if err := rm.checkResourceGroupCapacity(rgName, capacity); err != nil {
+		return err
+	}
+
+	if capacity == rm.groups[rgName].GetCapacity() {
+		return nil
+	}
+
+	return rm.setResourceGroupCapacity(ctx, rgName, capacity)
+}
+
+// check rg could be resized to capacity, which should hold all nodes of rg and respect its limit
+func (rm *ResourceManager) checkResourceGroupCapacity(rgName string, capacity int) error {
+	rg := rm.groups[rgName]
+	if capacity < len(rg.nodes) {
+		return fmt.Errorf("%w(rgName=%s, capacity=%d, nodeNum=%d)", ErrCapacityBelowNodeCount, rgName, capacity, len(rg.nodes))
+	}
+
+	if rg.GetMaxCapacity() > 0 && capacity > rg.GetMaxCapacity() {
+		return fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, rgName, rg.GetMaxCapacity())
+	}
+	return nil
+}
+
+func (rm *ResourceManager) setResourceGroupCapacity(ctx context.Context, rgName string, capacity int) error {
+	rg := rm.groups[rgName]
+	err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+		Name:     rgName,
+		Capacity: int32(capacity),
+		Nodes:    rg.GetNodes(),
+	})
+	if err != nil {
+		log.Info("failed to set resource group capacity",
+			zap.String("rgName", rgName),
+			zap.Int("capacity", capacity),
+			zap.Error(err),
+		)
+		return err
+	}
+	lackBefore := rg.LackOfNodes()
+	rg.capacity = capacity
+	rm.updateResourceGroupMetrics(rgName)
+	rm.checkLackTransition(rgName, lackBefore)
+
+	log.Info("set resource group capacity",
+		zap.String("rgName", rgName),
+		zap.Int("capacity", capacity),
+	)
+	return nil
+}
+
+// desired state of a resource group in a declarative spec
+type ResourceGroupSpec struct {
+	Name     string
+	Capacity int
+}
+
+// apply the desired state of rgs: missing rgs are created, capacities are updated, and rgs absent
+// from spec are removed if removeAbsent is set, default rg is never removed and can't be in spec.
+// the whole spec is validated before any store write, and only rgs differing from spec are written,
+// so applying a spec matching current state writes nothing. a failed write interrupts the apply,
+// and applying the spec again continues with what's left
+func (rm *ResourceManager) ApplySpec(ctx context.Context, spec []ResourceGroupSpec, removeAbsent bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	desired := make(map[string]int, len(spec))
+	toAdd := make([]ResourceGroupSpec, 0)
+	toUpdate := make([]ResourceGroupSpec, 0)
+	for _, rgSpec := range spec {
+		if err := rm.checkResourceGroupName(rgSpec.Name); err != nil {
+			return err
+		}
+		if _, ok := desired[rgSpec.Name]; ok {
+			return fmt.Errorf("%w(name=%s): duplicated in spec", ErrRGNameInvalid, rgSpec.Name)
+		}
+		if rgSpec.Capacity < 0 {
+			return fmt.Errorf("%w(rgName=%s, capacity=%d)", ErrRGCapacityInvalid, rgSpec.Name, rgSpec.Capacity)
+		}
+		desired[rgSpec.Name] = rgSpec.Capacity
+
+		if rm.groups[rgSpec.Name] == nil {
+			toAdd = append(toAdd, rgSpec)
+			continue
+		}
+		rm.checkRGNodeStatus(ctx, rgSpec.Name)
+		if rgSpec.Capacity == rm.groups[rgSpec.Name].GetCapacity() {
+			continue
+		}
+		if err := rm.checkResourceGroupCapacity(rgSpec.Name, rgSpec.Capacity); err != nil {
+			return err
+		}
+		toUpdate = append(toUpdate, rgSpec)
+	}
+
+	toRemove := make([]string, 0)
+	if removeAbsent {
+		for rgName, rg := range rm.groups {
+			if _, ok := desired[rgName]; ok || rgName == rm.defaultRGName {
+				continue
+			}
+			if err := rm.checkRGInUse(rgName); err != nil {
+				return err
+			}
+			if rg.GetCapacity() != 0 {
+				return fmt.Errorf("%w(rgName=%s)", ErrDeleteNonEmptyRG, rgName)
+			}
+			toRemove = append(toRemove, rgName)
+		}
+		sort.Strings(toRemove)
+	}
+
+	if len(rm.groups)-len(toRemove)+len(toAdd) > rm.maxResourceGroupNum {
+		return fmt.Errorf("%w %d", ErrRGLimit, rm.maxResourceGroupNum)
+	}
+
+	// remove first, so the rgs to add won't hit the rg num limit
+	for _, rgName := range toRemove {
+		if err := rm.removeResourceGroup(ctx, rgName); err != nil {
+			return err
+		}
+	}
+	for _, rgSpec := range toAdd {
+		if err := rm.addResourceGroup(ctx, rgSpec.Name, rgSpec.Capacity, 0, nil, false); err != nil {
+			return err
+		}
+	}
+	for _, rgSpec := range toUpdate {
+		if err := rm.setResourceGroupCapacity(ctx, rgSpec.Name, rgSpec.Capacity); err != nil {
+			return err
+		}
+	}
+
+	log.Info("apply resource group spec",
+		zap.Int("addedNum", len(toAdd)),
+		zap.Int("updatedNum", len(toUpdate)),
+		zap.Strings("removed", toRemove),
+	)
+	return nil
+}
+
+// set the predicate which nodes should pass to join rg by assign, node up or auto recover, nil admits
+// all nodes. nodes already in rg are kept. predicate isn't persisted, so it should be set again after restart
+func (rm *ResourceManager) SetResourceGroupAdmission(rgName string, predicate func(node int64) bool) error {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	rm.groups[rgName].admission = predicate
+	log.Info("set resource group admission",
+		zap.String("rgName", rgName),
+		zap.Bool("enabled", predicate != nil),
+	)
+	return nil
+}
+
+// make capacity of rg follow the given fraction of live query nodes, which is recomputed on
+// every AutoRecoverAll, so rg always targets the fraction while cluster scales. 0 fixes the capacity
+func (rm *ResourceManager) SetResourceGroupProportion(ctx context.Context, rgName string, fraction float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rgName == rm.defaultRGName {
+		return fmt.Errorf("%w: proportion of default rg is not permitted", ErrRGProportionInvalid)
+	}
+
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if rm.groups[rgName].overlap {
+		return fmt.Errorf("%w(rgName=%s): proportion of overlap rg is not permitted", ErrRGOverlapNotAllowed, rgName)
+	}
+
+	if math.IsNaN(fraction) || fraction < 0 || fraction > 1 {
+		return fmt.Errorf("%w(rgName=%s, fraction=%v)", ErrRGProportionInvalid, rgName, fraction)
+	}
+
+	var err error
+	if fraction == 0 {
+		err = rm.store.RemoveResourceGroupProportion(ctx, rgName)
+	} else {
+		err = rm.store.SaveResourceGroupProportion(ctx, rgName, fraction)
+	}
+	if err != nil {
+		log.Info("failed to set resource group proportion",
+			zap.String("rgName", rgName),
+			zap.Float64("fraction", fraction),
+			zap.Error(err),
+		)
+		return err
+	}
+	rm.groups[rgName].proportion = fraction
+
+	log.Info("set resource group proportion",
+		zap.String("rgName", rgName),
+		zap.Float64("fraction", fraction),
+	)
+	return nil
+}
+
+// update capacity of proportional rgs by live query node num, capacity never drops below
+// node num of rg, and never exceeds its max capacity
+func (rm *ResourceManager) refreshProportionalCapacity(ctx context.Context) error {
+	clusterSize := 0
+	for _, info := range rm.nodeMgr.GetAll() {
+		if !info.IsStoppingState() {
+			clusterSize++
+		}
+	}
+
+	rgNames := lo.Keys(rm.groups)
+	sort.Strings(rgNames)
+	for _, rgName := range rgNames {
+		rg := rm.groups[rgName]
+		if rg.proportion == 0 || rg.sealed || rg.overlap {
+			continue
+		}
+
+		rm.checkRGNodeStatus(ctx, rgName)
+		// tolerate float error, such as 0.29 * 100 = 28.999999999999996
+		capacity := int(math.Floor(rg.proportion*float64(clusterSize) + 1e-9))
+		if capacity < len(rg.nodes) {
+			capacity = len(rg.nodes)
+		}
+		if rg.GetMaxCapacity() > 0 && capacity > rg.GetMaxCapacity() {
+			capacity = rg.GetMaxCapacity()
+		}
+		if capacity == rg.GetCapacity() {
+			continue
+		}
+
+		if err := rm.setResourceGroupCapacity(ctx, rgName, capacity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seal or unseal rg, nodes of sealed rg can't be assigned, unassigned, transferred or recovered
+func (rm *ResourceManager) SealResourceGroup(ctx context.Context, rgName string, sealed bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if rm.groups[rgName].sealed == sealed {
+		return nil
+	}
+
+	var err error
+	if sealed {
+		err = rm.store.SaveSealedResourceGroup(ctx, rgName)
+	} else {
+		err = rm.store.RemoveSealedResourceGroup(ctx, rgName)
+	}
+	if err != nil {
+		log.Info("failed to seal resource group",
+			zap.String("rgName", rgName),
+			zap.Bool("sealed", sealed),
+			zap.Error(err),
+		)
+		return err
+	}
+	rm.groups[rgName].sealed = sealed
+
+	log.Info("seal resource group",
+		zap.String("rgName", rgName),
+		zap.Bool("sealed", sealed),
+	)
+	return nil
+}
+
+// should be called with write lock held
+func (rm *ResourceManager) checkRecovering() error {
+	if rm.recovering {
+		return ErrManagerRecovering
+	}
+	return nil
+}
+
+// return ErrRGSealed if any of the given rgs is sealed
+func (rm *ResourceManager) checkRGSealed(rgNames ...string) error {
+	for _, rgName := range rgNames {
+		if rm.groups[rgName] != nil && rm.groups[rgName].sealed {
+			return fmt.Errorf("%w(rgName=%s)", ErrRGSealed, rgName)
+		}
+	}
+
+	return nil
+}
+
+// overwrite rg's labels, empty labels clear all existing ones
+func (rm *ResourceManager) SetResourceGroupLabels(ctx context.Context, rgName string, labels map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	newLabels := make(map[string]string, len(labels))
+	for k, v := range labels {
+		newLabels[k] = v
+	}
+
+	var err error
+	if len(newLabels) == 0 {
+		err = rm.store.RemoveResourceGroupLabels(ctx, rgName)
+	} else {
+		err = rm.store.SaveResourceGroupLabels(ctx, rgName, newLabels)
+	}
+	if err != nil {
+		log.Info("failed to set resource group labels",
+			zap.String("rgName", rgName),
+			zap.Any("labels", labels),
+			zap.Error(err),
+		)
+		return err
+	}
+	rm.groups[rgName].labels = newLabels
+
+	log.Info("set resource group labels",
+		zap.String("rgName", rgName),
+		zap.Any("labels", labels),
+	)
+	return nil
+}
+
+// return names of rgs which have label key=value, in sorted order
+func (rm *ResourceManager) ListResourceGroupsByLabel(key, value string) []string {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make([]string, 0)
+	for rgName, rg := range rm.groups {
+		if v, ok := rg.labels[key]; ok && v == value {
+			ret = append(ret, rgName)
+		}
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// return ResourceGroupNotFoundError of the first missing rg
+func (rm *ResourceManager) checkResourceGroupsExist(rgNames ...string) error {
+	for _, rgName := range rgNames {
+		if rm.groups[rgName] == nil {
+			return &ResourceGroupNotFoundError{Name: rgName}
+		}
+	}
+	return nil
+}
+
+// rg name is part of the store key, only alphanumerics, underscores and hyphens are allowed
+func (rm *ResourceManager) checkResourceGroupName(rgName string) error {
+	if rgName == rm.defaultRGName {
+		return fmt.Errorf("%w(name=%s): name is reserved for default resource group", ErrRGNameInvalid, rgName)
+	}
+
+	if len(rgName) > maxResourceGroupNameLength {
+		return fmt.Errorf("%w(name=%s): length should be no more than %d", ErrRGNameInvalid, rgName, maxResourceGroupNameLength)
+	}
+
+	for _, c := range rgName {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' && c != '-' {
+			return fmt.Errorf("%w(name=%s): only alphanumerics, underscores and hyphens are allowed", ErrRGNameInvalid, rgName)
+		}
+	}
+
+	return nil
+}
+
+func (rm *ResourceManager) RemoveResourceGroup(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+	if rgName == rm.defaultRGName {
+		return ErrDeleteDefaultRG
+	}
+
+	if rm.groups[rgName] == nil {
+		// delete a non-exist rg should be tolerable
+		return nil
+	}
+
+	if err := rm.checkRGInUse(rgName); err != nil {
+		return err
+	}
+
+	if rm.groups[rgName].GetCapacity() != 0 {
+		return ErrDeleteNonEmptyRG
+	}
+
+	return rm.removeResourceGroup(ctx, rgName)
+}
+
+// move all nodes of rg back to default rg, then remove the drained rg
+func (rm *ResourceManager) RemoveResourceGroupForce(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+	if rgName == rm.defaultRGName {
+		return ErrDeleteDefaultRG
+	}
+
+	if rm.groups[rgName] == nil {
+		// delete a non-exist rg should be tolerable
+		return nil
+	}
+
+	if err := rm.checkRGInUse(rgName); err != nil {
+		return err
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	if rm.groups[rgName].GetCapacity() != 0 {
+		nodes, err := rm.moveAllNodes(ctx, rgName, rm.defaultRGName)
+		if err != nil {
+			log.Info("failed to force remove resource group",
+				zap.String("rgName", rgName),
+				zap.Error(err),
+			)
+			return err
+		}
+		log.Info("move all nodes to default resource group before removing resource group",
+			zap.String("rgName", rgName),
+			zap.Int64s("nodes", nodes),
+		)
+	}
+
+	return rm.removeResourceGroup(ctx, rgName)
+}
+
+// check whether rg is still referenced by replicas
+func (rm *ResourceManager) checkRGInUse(rgName string) error {
+	if rm.replicas == nil {
+		return nil
+	}
+
+	if replicas := rm.replicas.GetByResourceGroup(rgName); len(replicas) > 0 {
+		collections := lo.Uniq(lo.Map(replicas, func(replica *Replica, _ int) int64 {
+			return replica.GetCollectionID()
+		}))
+		return fmt.Errorf("%w(rgName=%s, collections=%v)", ErrRGInUse, rgName, collections)
+	}
+	return nil
+}
+
+func (rm *ResourceManager) removeResourceGroup(ctx context.Context, rgName string) error {
+	err := rm.removeResourceGroupFromStore(ctx, rgName)
+	if err != nil {
+		log.Info("failed to remove resource group",
+			zap.String("rgName", rgName),
+			zap.Error(err),
+		)
+		return err
+	}
+	rm.removeResourceGroupAttributes(ctx, rgName)
+	delete(rm.groups, rgName)
+	rm.accessStats.Delete(rgName)
+	rm.notify(ResourceGroupEvent{RGName: rgName, Type: RGRemoved})
+	removeResourceGroupMetrics(rgName)
+
+	log.Info("remove resource group",
+		zap.String("rgName", rgName),
+	)
+	rm.audit(ctx, "RemoveResourceGroup", zap.String("rgName", rgName))
+	return nil
+}
+
+// removeResourceGroupAttributes is best effort, stale records are cleared again before an rg with the same name is added
+func (rm *ResourceManager) removeResourceGroupAttributes(ctx context.Context, rgName string) {
+	if err := rm.store.RemoveResourceGroupAttributes(ctx, rgName); err != nil {
+		log.Warn("failed to remove resource group attributes",
+			zap.String("rgName", rgName),
+			zap.Error(err),
+		)
+	}
+}
+
+func (rm *ResourceManager) AssignNode(ctx context.Context, rgName string, node int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	operation := fmt.Sprintf("AssignNode(rgName=%s, node=%d)", rgName, node)
+	return rm.runIdempotent(ctx, operation, func() error {
+		return rm.assignNode(ctx, rgName, node)
+	})
+}
+
+func (rm *ResourceManager) assignNode(ctx context.Context, rgName string, node int64) error {
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if err := rm.checkRGSealed(rgName); err != nil {
+		return err
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	// node already in the rg, nothing to do
+	if rm.groups[rgName].containsNode(node) {
+		return nil
+	}
+
+	if err := rm.checkNodeAssignable(node, rm.groups[rgName].overlap); err != nil {
+		return err
+	}
+
+	if err := rm.checkNodesAdmissible(rgName, node); err != nil {
+		return err
+	}
+
+	if rm.groups[rgName].exceedMaxCapacity(1) {
+		return fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, rgName, rm.groups[rgName].GetMaxCapacity())
+	}
+
+	newNodes := rm.groups[rgName].GetNodes()
+	newNodes = append(newNodes, node)
+	capacity := rm.groups[rgName].GetCapacity() + 1
+	err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+		Name:     rgName,
+		Capacity: int32(capacity),
+		Nodes:    newNodes,
+	})
+	if err != nil {
+		log.Info("failed to add node to resource group",
+			zap.String("rgName", rgName),
+			zap.Int64("node", node),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	// membership has been validated and the store is already updated, so the node is in rg
+	// anyway, align capacity with the store instead of failing with store ahead of memory
+	if err := rm.groups[rgName].assignNode(node); err != nil {
+		log.Warn("node is already in resource group after store write, align capacity with store",
+			zap.String("rgName", rgName),
+			zap.Int64("node", node),
+			zap.Int("capacity", capacity),
+			zap.Error(err),
+		)
+		rm.groups[rgName].capacity = capacity
+	}
+	rm.indexNode(ctx, node, rgName)
+	rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeAdded, Node: node})
+	rm.updateResourceGroupMetrics(rgName)
+
+	log.Info("add node to resource group",
+		zap.String("rgName", rgName),
+		zap.Int64("node", node),
+	)
+	rm.audit(ctx, "AssignNode",
+		zap.String("rgName", rgName),
+		zap.Int64("node", node),
+	)
+
+	return nil
+}
+
+// assign nodes to rg in one store write, nothing changes if any node can't be assigned
+func (rm *ResourceManager) AssignNodes(ctx context.Context, rgName string, nodes []int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	operation := fmt.Sprintf("AssignNodes(rgName=%s, nodes=%v)", rgName, nodes)
+	return rm.runIdempotent(ctx, operation, func() error {
+		return rm.assignNodes(ctx, rgName, nodes)
+	})
+}
+
+func (rm *ResourceManager) assignNodes(ctx context.Context, rgName string, nodes []int64) error {
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if err := rm.checkRGSealed(rgName); err != nil {
+		return err
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	if err := rm.checkNodesAssignable(nodes, rm.groups[rgName].overlap); err != nil {
+		log.Info("failed to add nodes to resource group",
+			zap.String("rgName", rgName),
+			zap.Int64s("nodes", nodes),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if err := rm.checkNodesAdmissible(rgName, nodes...); err != nil {
+		return err
+	}
+
+	if rm.groups[rgName].exceedMaxCapacity(len(nodes)) {
+		return fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, rgName, rm.groups[rgName].GetMaxCapacity())
+	}
+
+	newNodes := rm.groups[rgName].GetNodes()
+	newNodes = append(newNodes, nodes...)
+	err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+		Name:     rgName,
+		Capacity: int32(rm.groups[rgName].GetCapacity() + len(nodes)),
+		Nodes:    newNodes,
+	})
+	if err != nil {
+		log.Info("failed to add nodes to resource group",
+			zap.String("rgName", rgName),
+			zap.Int64s("nodes", nodes),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	_ = rm.groups[rgName].assignNodes(nodes)
+	for _, node := range nodes {
+		rm.indexNode(ctx, node, rgName)
+		rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeAdded, Node: node})
+	}
+	rm.updateResourceGroupMetrics(rgName)
+
+	log.Info("add nodes to resource group",
+		zap.String("rgName", rgName),
+		zap.Int64s("nodes", nodes),
+	)
+	rm.audit(ctx, "AssignNodes",
+		zap.String("rgName", rgName),
+		zap.Int64s("nodes", nodes),
+	)
+
+	return nil
+}
+
+// record the rg which node belongs to, nodes of overlap rg aren't indexed.
+// the index is only modified under write lock
+func (rm *ResourceManager) indexNode(ctx context.Context, node int64, rgName string) {
+	if rm.groups[rgName].overlap {
+		return
+	}
+	rm.nodeToRG[node] = rgName
+	rm.saveNodeHomeRG(ctx, node, rgName)
+}
+
+// drop node from the index if it's indexed to rgName, return whether it's dropped.
+// the index is only modified under write lock
+func (rm *ResourceManager) unindexNode(node int64, rgName string) bool {
+	if rm.nodeToRG[node] != rgName {
+		return false
+	}
+	delete(rm.nodeToRG, node)
+	return true
+}
+
+// check all nodes pass the admission predicate of rg
+func (rm *ResourceManager) checkNodesAdmissible(rgName string, nodes ...int64) error {
+	for _, node := range nodes {
+		if !rm.groups[rgName].admits(node) {
+			return fmt.Errorf("%w(node=%d, rgName=%s)", ErrNodeNotAdmissible, node, rgName)
+		}
+	}
+	return nil
+}
+
+// check all nodes are assignable and not duplicated, errors of every invalid node are combined
+func (rm *ResourceManager) checkNodesAssignable(nodes []int64, overlap bool) error {
+	var errs error
+	toAssign := typeutil.NewUniqueSet()
+	for _, node := range nodes {
+		err := rm.checkNodeAssignable(node, overlap)
+		if err == nil && toAssign.Contain(node) {
+			err = ErrNodeAlreadyAssign
+		}
+		if err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("%w(node=%d)", err, node))
+			continue
+		}
+		toAssign.Insert(node)
+	}
+	return errs
+}
+
+// check whether node is alive and hasn't been assigned to any rg.
+// assigned node is still assignable to overlap rg
+func (rm *ResourceManager) checkNodeAssignable(node int64, overlap bool) error {
+	if rm.nodeMgr.Get(node) == nil {
+		return ErrNodeNotExist
+	}
+
+	if ok, _ := rm.nodeMgr.IsStoppingNode(node); ok {
+		return ErrNodeStopped
+	}
+
+	if overlap {
+		return nil
+	}
+
+	if rgName, ok := rm.nodeToRG[node]; ok {
+		return &NodeAlreadyAssignedError{Node: node, CurrentRG: rgName}
+	}
+
+	return nil
+}
+
+// IsNodeAssigned returns whether node belongs to any rg, including the default rg.
+// nodes only held by overlap rgs are shared rather than assigned, so they are not counted
+func (rm *ResourceManager) IsNodeAssigned(node int64) bool {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	return rm.checkNodeAssigned(node)
+}
+
+func (rm *ResourceManager) checkNodeAssigned(node int64) bool {
+	_, ok := rm.nodeToRG[node]
+	return ok
+}
+
+func (rm *ResourceManager) UnassignNode(ctx context.Context, rgName string, node int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	operation := fmt.Sprintf("UnassignNode(rgName=%s, node=%d)", rgName, node)
+	return rm.runIdempotent(ctx, operation, func() error {
+		if err := rm.unassignNode(ctx, rgName, node); err != nil {
+			return err
+		}
+		rm.audit(ctx, "UnassignNode",
+			zap.String("rgName", rgName),
+			zap.Int64("node", node),
+		)
+		return nil
+	})
+}
+
+func (rm *ResourceManager) unassignNode(ctx context.Context, rgName string, node int64) error {
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if err := rm.checkRGSealed(rgName); err != nil {
+		return err
+	}
+
+	if rm.nodeMgr.Get(node) == nil {
+		// remove non exist node should be tolerable
+		return nil
+	}
+
+	if !rm.groups[rgName].containsNode(node) {
+		// remove non member node should be tolerable, and rg's capacity should keep unchanged
+		return nil
+	}
+
+	newNodes := make([]int64, 0)
+	for nid := range rm.groups[rgName].nodes {
+		if nid != node {
+			newNodes = append(newNodes, nid)
+		}
+	}
+
+	err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+		Name:     rgName,
+		Capacity: int32(rm.decreasedCapacity(rgName, 1)),
+		Nodes:    newNodes,
+	})
+	if err != nil {
+		log.Info("remove node from resource group",
+			zap.String("rgName", rgName),
+			zap.Int64("node", node),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	err = rm.groups[rgName].unassignNode(node)
+	if err != nil {
+		return err
+	}
+	if rm.unindexNode(node, rgName) {
+		rm.removeNodeHomeRG(ctx, node)
+	}
+	rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeRemoved, Node: node})
+	rm.updateResourceGroupMetrics(rgName)
+
+	log.Info("remove node from resource group",
+		zap.String("rgName", rgName),
+		zap.Int64("node", node),
+	)
+
+	return nil
+}
+
+func (rm *ResourceManager) GetNodes(rgName string) ([]int64, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	if rm.groups[rgName] == nil {
+		return nil, &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	rm.recordAccess(rgName)
+	return rm.getLiveNodes(rgName), nil
+}
+
+// split nodes of rg into live ones and stopping ones, both are sorted
+func (rm *ResourceManager) GetNodesByState(rgName string) ([]int64, []int64, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	if rm.groups[rgName] == nil {
+		return nil, nil, &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	live := make([]int64, 0)
+	stopping := make([]int64, 0)
+	for _, node := range rm.getLiveNodes(rgName) {
+		if ok, _ := rm.nodeMgr.IsStoppingNode(node); ok {
+			stopping = append(stopping, node)
+		} else {
+			live = append(live, node)
+		}
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i] < live[j] })
+	sort.Slice(stopping, func(i, j int) bool { return stopping[i] < stopping[j] })
+
+	return live, stopping, nil
+}
+
+// return all outbound node
+func (rm *ResourceManager) CheckOutboundNodes(replica *Replica) typeutil.UniqueSet {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[replica.GetResourceGroup()] == nil {
+		return typeutil.NewUniqueSet()
+	}
+	rg := rm.groups[replica.GetResourceGroup()]
+
+	ret := typeutil.NewUniqueSet()
+	for _, node := range replica.GetNodes() {
+		if !rg.containsNode(node) {
+			ret.Insert(node)
+		}
+	}
+
+	return ret
+}
+
+// return outgoing node num on each rg from this replica
+func (rm *ResourceManager) GetOutgoingNodeNumByReplica(replica *Replica) map[string]int32 {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[replica.GetResourceGroup()] == nil {
+		return nil
+	}
+
+	rg := rm.groups[replica.GetResourceGroup()]
+	ret := make(map[string]int32)
+	for _, node := range replica.GetNodes() {
+		if !rg.containsNode(node) {
+			rgName, err := rm.findResourceGroupByNode(node)
+			if err == nil {
+				ret[rgName]++
+			}
+		}
+	}
+
+	return ret
+}
+
+// return sorted nodes of replica which belong to replica's rg, they're the complement of CheckOutboundNodes
+func (rm *ResourceManager) GetInRGNodes(replica *Replica) []int64 {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[replica.GetResourceGroup()] == nil {
+		return nil
+	}
+	rg := rm.groups[replica.GetResourceGroup()]
+
+	ret := lo.Filter(replica.GetNodes(), func(node int64, _ int) bool {
+		return rg.containsNode(node)
+	})
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+
+	return ret
+}
+
+// return replica's nodes which are in replica's rg, and outbound nodes grouped by the rg they belong to.
+// nodes which don't belong to any rg are ignored
+func (rm *ResourceManager) GetReplicaNodeDistribution(replica *Replica) ([]int64, map[string][]int64) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[replica.GetResourceGroup()] == nil {
+		return nil, nil
+	}
+
+	rg := rm.groups[replica.GetResourceGroup()]
+	nodes := replica.GetNodes()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	inRG := make([]int64, 0)
+	outbound := make(map[string][]int64)
+	for _, node := range nodes {
+		if rg.containsNode(node) {
+			inRG = append(inRG, node)
+			continue
+		}
+
+		rgName, err := rm.findResourceGroupByNode(node)
+		if err == nil {
+			outbound[rgName] = append(outbound[rgName], node)
+		}
+	}
+
+	return inRG, outbound
+}
+
+func (rm *ResourceManager) ContainsNode(rgName string, node int64) bool {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	if rm.groups[rgName] == nil {
+		return false
+	}
+
+	rm.recordAccess(rgName)
+	return rm.groups[rgName].containsNode(node) && rm.nodeMgr.Get(node) != nil
+}
+
+func (rm *ResourceManager) ContainResourceGroup(rgName string) bool {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	return rm.groups[rgName] != nil
+}
+
+// return snapshot of rg, the live rg is never handed out since it's mutated under lock
+func (rm *ResourceManager) GetResourceGroup(rgName string) (ResourceGroupSnapshot, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[rgName] == nil {
+		return ResourceGroupSnapshot{}, &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	rm.recordAccess(rgName)
+	return rm.snapshotResourceGroup(rgName), nil
+}
+
+// return node up and down events per minute of rg within churn rate window, 0 if rg doesn't exist
+func (rm *ResourceManager) GetChurnRate(rgName string) float64 {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[rgName] == nil {
+		return 0
+	}
+	return rm.groups[rgName].churnRate()
+}
+
+// return capacity of rg, which is copied under read lock
+func (rm *ResourceManager) GetResourceGroupCapacity(rgName string) (int, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[rgName] == nil {
+		return 0, &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	rm.recordAccess(rgName)
+	return rm.groups[rgName].GetCapacity(), nil
+}
+
+func (rm *ResourceManager) DescribeResourceGroup(rgName string) (ResourceGroupInfo, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[rgName] == nil {
+		return ResourceGroupInfo{}, &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	rm.recordAccess(rgName)
+	snapshot := rm.snapshotResourceGroup(rgName)
+	return ResourceGroupInfo{
+		Name:     rgName,
+		Capacity: snapshot.Capacity,
+		Nodes:    snapshot.Nodes,
+		Lacking:  snapshot.LackOfNodes(),
+		Labels:   snapshot.Labels,
+	}, nil
+}
+
+func (rm *ResourceManager) GetResourceGroupStats(rgName string) (ResourceGroupStats, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.groups[rgName] == nil {
+		return ResourceGroupStats{}, &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	return rm.getResourceGroupStats(rgName), nil
+}
+
+// call fn with stats of every rg in name order, and stop once fn returns false.
+// read lock is held during the whole iteration, so rgs never change in the middle of it.
+// fn must not call back into any method of rm, which would deadlock on the held lock
+func (rm *ResourceManager) ForEachResourceGroup(fn func(name string, stats ResourceGroupStats) bool) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	rgNames := lo.Keys(rm.groups)
+	sort.Strings(rgNames)
+	for _, rgName := range rgNames {
+		if !fn(rgName, rm.getResourceGroupStats(rgName)) {
+			return
+		}
+	}
+}
+
+func (rm *ResourceManager) getResourceGroupStats(rgName string) ResourceGroupStats {
+	rg := rm.groups[rgName]
+	nodes := rm.getLiveNodes(rgName)
+	availableNodes := 0
+	for _, node := range nodes {
+		if ok, _ := rm.nodeMgr.IsStoppingNode(node); !ok {
+			availableNodes++
+		}
+	}
+
+	return ResourceGroupStats{
+		Capacity:       rg.GetCapacity(),
+		AssignedNodes:  len(nodes),
+		AvailableNodes: availableNodes,
+		LackingNodes:   rg.GetCapacity() - len(nodes),
+	}
+}
+
+// Snapshot returns all rgs' state captured under a single read lock, nodes are sorted
+func (rm *ResourceManager) Snapshot() map[string]ResourceGroupSnapshot {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make(map[string]ResourceGroupSnapshot, len(rm.groups))
+	for rgName := range rm.groups {
+		ret[rgName] = rm.snapshotResourceGroup(rgName)
+	}
+	return ret
+}
+
+// copy state of rg, nodes are sorted
+func (rm *ResourceManager) snapshotResourceGroup(rgName string) ResourceGroupSnapshot {
+	rg := rm.groups[rgName]
+	nodes := rm.getLiveNodes(rgName)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	snapshot := ResourceGroupSnapshot{
+		Capacity:    rg.GetCapacity(),
+		MaxCapacity: rg.GetMaxCapacity(),
+		MinCapacity: rg.GetMinCapacity(),
+		Nodes:       nodes,
+		Sealed:      rg.IsSealed(),
+		Overlap:     rg.IsOverlap(),
+	}
+	if len(rg.labels) > 0 {
+		snapshot.Labels = rg.GetLabels()
+	}
+	return snapshot
+}
+
+func (rm *ResourceManager) ListResourceGroups() []string {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	return lo.Keys(rm.groups)
+}
+
+// return all rgs with their live nodes in one call, nodes are sorted
+func (rm *ResourceManager) ListResourceGroupsDetailed() map[string][]int64 {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make(map[string][]int64, len(rm.groups))
+	for rgName := range rm.groups {
+		nodes := rm.getLiveNodes(rgName)
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+		ret[rgName] = nodes
+	}
+
+	return ret
+}
+
+// block until live node num of rg reaches target, return ctx.Err() if ctx is done before that.
+// node num is rechecked on each membership event, instead of polling
+func (rm *ResourceManager) WaitForCapacity(ctx context.Context, rgName string, target int) error {
+	// subscribe before the first check, so no event is missed in between
+	events, cancel := rm.Subscribe()
+	defer cancel()
+
+	for {
+		nodes, err := rm.GetNodes(rgName)
+		if err != nil {
+			return fmt.Errorf("%w(rgName=%s)", err, rgName)
+		}
+		if len(nodes) >= target {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-events:
+			if !ok {
+				return ErrManagerClosed
+			}
+		}
+	}
+}
+
+// report whether manager is recovered with default rg present and its store reachable,
+// the reason is returned if it's unhealthy
+func (rm *ResourceManager) Healthy(ctx context.Context) (bool, string) {
+	rm.rwmutex.RLock()
+	recovering := rm.recovering
+	hasDefaultRG := rm.groups[rm.defaultRGName] != nil
+	rm.rwmutex.RUnlock()
+
+	if recovering {
+		return false, "resource manager is recovering"
+	}
+	if !hasDefaultRG {
+		return false, fmt.Sprintf("default resource group %s doesn't exist", rm.defaultRGName)
+	}
+	// probe store by loading a single key instead of scanning a prefix, it's done without lock since it may block
+	if _, err := rm.store.GetResourceGroup(ctx, rm.defaultRGName); err != nil {
+		return false, fmt.Sprintf("resource group store is unreachable: %s", err.Error())
+	}
+	return true, ""
+}
+
+// return live node num of all rgs in one call, including the default rg
+func (rm *ResourceManager) CountNodes() map[string]int {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make(map[string]int, len(rm.groups))
+	for rgName := range rm.groups {
+		ret[rgName] = len(rm.getLiveNodes(rgName))
+	}
+
+	return ret
+}
+
+// return all non-default rgs whose capacity is 0
+func (rm *ResourceManager) ListEmptyResourceGroups() []string {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make([]string, 0)
+	for name, group := range rm.groups {
+		if name != rm.defaultRGName && group.GetCapacity() == 0 {
+			ret = append(ret, name)
+		}
+	}
+
+	return ret
+}
+
+// return the non-overlap rg which node belongs to, use FindResourceGroupsByNode to get overlap rgs as well
+func (rm *ResourceManager) FindResourceGroupByNode(node int64) (string, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	return rm.findResourceGroupByNode(node)
+}
+
+func (rm *ResourceManager) findResourceGroupByNode(node int64) (string, error) {
+	if rgName, ok := rm.nodeToRG[node]; ok {
+		return rgName, nil
+	}
+
+	return "", ErrNodeNotAssignToRG
+}
+
+// return all rgs which hold the node, including overlap rgs, sorted by name
+func (rm *ResourceManager) FindResourceGroupsByNode(node int64) []string {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make([]string, 0)
+	for rgName, rg := range rm.groups {
+		if rg.containsNode(node) {
+			ret = append(ret, rgName)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// FindResourceGroupByNodes returns the rg of each given node, unassigned nodes are omitted
+func (rm *ResourceManager) FindResourceGroupByNodes(nodes []int64) map[int64]string {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make(map[int64]string)
+	for _, node := range nodes {
+		if rgName, err := rm.findResourceGroupByNode(node); err == nil {
+			ret[node] = rgName
+		}
+	}
+
+	return ret
+}
+
+// return overview of all rgs, which is computed under a single read lock
+func (rm *ResourceManager) GetClusterSummary() ClusterSummary {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	summary := ClusterSummary{
+		ResourceGroupNum: len(rm.groups),
+	}
+	assigned := typeutil.NewUniqueSet()
+	for rgName, rg := range rm.groups {
+		nodes := rm.getLiveNodes(rgName)
+		assigned.Insert(nodes...)
+		if rgName == rm.defaultRGName {
+			continue
+		}
+		summary.TotalCapacity += rg.GetCapacity()
+		if lack := rg.GetCapacity() - len(nodes); lack > 0 {
+			summary.TotalLacking += lack
+		}
+	}
+	summary.AssignedNodes = assigned.Len()
+
+	for _, info := range rm.nodeMgr.GetAll() {
+		if info.IsStoppingState() || assigned.Contain(info.ID()) {
+			continue
+		}
+		summary.UnassignedNodes++
+	}
+
+	return summary
+}
+
+// ListUnassignedNodes returns the alive nodes which don't belong to any rg.
+// nodes in default rg are assigned explicitly, so they won't be listed here;
+// the listed nodes are spare ones, such as nodes unassigned from a rg or failed to go up
+func (rm *ResourceManager) ListUnassignedNodes() []int64 {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make([]int64, 0)
+	for _, info := range rm.nodeMgr.GetAll() {
+		if info.IsStoppingState() || rm.checkNodeAssigned(info.ID()) {
+			continue
+		}
+		ret = append(ret, info.ID())
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+
+	return ret
+}
+
+func (rm *ResourceManager) HandleNodeUp(ctx context.Context, node int64) (string, error) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+
+	if rm.nodeMgr.Get(node) == nil {
+		return "", ErrNodeNotExist
+	}
+
+	if ok, _ := rm.nodeMgr.IsStoppingNode(node); ok {
+		return "", ErrNodeStopped
+	}
+
+	// node comes back within grace period, it still stays in its rg
+	if timer, ok := rm.pendingNodeDown[node]; ok {
+		timer.Stop()
+		delete(rm.pendingNodeDown, node)
+		log.Info("HandleNodeUp: cancel pending removal of node",
+			zap.Int64("node", node),
+		)
+	}
+
+	// if node already assign to rg
+	rgName, err := rm.findResourceGroupByNode(node)
+	if err == nil {
+		log.Info("HandleNodeUp: node already assign to resource group",
+			zap.String("rgName", rgName),
+			zap.Int64("node", node),
+		)
+		return rgName, nil
+	}
+
+	// node has been placed into a rg before restart, put it back if the rg still lacks of node.
+	// sealed rg accepts no node, the node goes elsewhere and the home record is replaced
+	if homeRG, ok := rm.nodeHomeRG[node]; ok {
+		if rm.groups[homeRG] == nil {
+			rm.removeNodeHomeRG(ctx, node)
+		} else if rg := rm.groups[homeRG]; !rg.sealed && rg.LackOfNodes() > 0 && rg.admits(node) &&
+			(rg.maxCapacity == 0 || len(rg.nodes) < rg.maxCapacity) {
+			// persist the membership before changing memory, nothing needs to roll back if store write fails
+			err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+				Name:     homeRG,
+				Capacity: int32(rm.groups[homeRG].GetCapacity()),
+				Nodes:    append(rm.groups[homeRG].GetNodes(), node),
+			})
+			if err != nil {
+				log.Warn("HandleNodeUp: failed to assign node back to previous resource group",
+					zap.String("rgName", homeRG),
+					zap.Int64("node", node),
+					zap.Error(err),
+				)
+				return "", err
+			}
+			if err := rm.groups[homeRG].handleNodeUp(node); err != nil {
+				return "", err
+			}
+			rm.groups[homeRG].recordChurn()
+			rm.nodeToRG[node] = homeRG
+			rm.notify(ResourceGroupEvent{RGName: homeRG, Type: NodeAdded, Node: node})
+			rm.updateResourceGroupMetrics(homeRG)
+			log.Info("HandleNodeUp: assign node back to previous resource group",
+				zap.String("rgName", homeRG),
+				zap.Int64("node", node),
+			)
+			return homeRG, nil
+		}
+	}
+
+	// route new node to the most lacking rg directly, instead of waiting for auto recover
+	if params.Params.QueryCoordCfg.RGNodeUpAutoPlacement.GetAsBool() {
+		if rgName := rm.selectNodeUpPlacement(node); rgName != "" {
+			if err := rm.groups[rgName].handleNodeUp(node); err != nil {
+				return "", err
+			}
+			rm.groups[rgName].recordChurn()
+			rm.nodeToRG[node] = rgName
+			rm.saveNodeHomeRG(ctx, node, rgName)
+			rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeAdded, Node: node})
+			rm.updateResourceGroupMetrics(rgName)
+			log.Info("HandleNodeUp: assign node to most lacking resource group",
+				zap.String("rgName", rgName),
+				zap.Int64("node", node),
+			)
+			return rgName, nil
+		}
+	}
+
+	// add new node to default rg
+	if err := rm.groups[rm.defaultRGName].handleNodeUp(node); err != nil {
+		return "", err
+	}
+	rm.groups[rm.defaultRGName].recordChurn()
+	rm.nodeToRG[node] = rm.defaultRGName
+	rm.removeNodeHomeRG(ctx, node)
+	rm.notify(ResourceGroupEvent{RGName: rm.defaultRGName, Type: NodeAdded, Node: node})
+	rm.updateResourceGroupMetrics(rm.defaultRGName)
+	log.Info("HandleNodeUp: assign node to default resource group",
+		zap.String("rgName", rm.defaultRGName),
+		zap.Int64("node", node),
+	)
+	return rm.defaultRGName, nil
+}
+
+// select the non-default rg which lacks most nodes and accepts node, return empty if all rgs are satisfied.
+// like auto recover, sealed and overlap rgs are skipped, and reserved nodes of default rg are kept
+func (rm *ResourceManager) selectNodeUpPlacement(node int64) string {
+	aliveInDefault := lo.CountBy(rm.groups[rm.defaultRGName].GetNodes(), func(node int64) bool {
+		return rm.nodeMgr.Get(node) != nil
+	})
+	if aliveInDefault < params.Params.QueryCoordCfg.DefaultRGReservedNodeNum.GetAsInt() {
+		return ""
+	}
+
+	selected := ""
+	for rgName, rg := range rm.groups {
+		if rgName == rm.defaultRGName || rg.sealed || rg.overlap {
+			continue
+		}
+		if rg.LackOfNodes() == 0 || (rg.maxCapacity > 0 && len(rg.nodes) >= rg.maxCapacity) || !rg.admits(node) {
+			continue
+		}
+		// break tie by rg name, so the placement is deterministic
+		if selected == "" || rg.LackOfNodes() > rm.groups[selected].LackOfNodes() ||
+			(rg.LackOfNodes() == rm.groups[selected].LackOfNodes() && rgName < selected) {
+			selected = rgName
+		}
+	}
+	return selected
+}
+
+// node has been removed from node manager before HandleNodeDown is called, so it's not checked here
+func (rm *ResourceManager) HandleNodeDown(ctx context.Context, node int64) (string, error) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+
+	rgName, err := rm.findResourceGroupByNode(node)
+	if err != nil {
+		return "", ErrNodeNotAssignToRG
+	}
+
+	// defer the removal, so a flapping node doesn't cause membership churn
+	gracePeriod := params.Params.QueryCoordCfg.RGNodeDownGracePeriod.GetAsDuration(time.Millisecond)
+	if gracePeriod > 0 && !rm.closed {
+		if _, ok := rm.pendingNodeDown[node]; !ok {
+			log.Info("HandleNodeDown: defer removing node from resource group",
+				zap.String("rgName", rgName),
+				zap.Int64("node", node),
+				zap.Duration("gracePeriod", gracePeriod),
+			)
+			var timer *time.Timer
+			timer = time.AfterFunc(gracePeriod, func() {
+				rm.handleDeferredNodeDown(node, timer)
+			})
+			rm.pendingNodeDown[node] = timer
+		}
+		return rgName, nil
+	}
+
+	if err := rm.handleNodeDown(ctx, rgName, node); err != nil {
+		return "", err
+	}
+	return rgName, nil
+}
+
+// remove node if its removal hasn't been cancelled by HandleNodeUp or Close
+func (rm *ResourceManager) handleDeferredNodeDown(node int64, timer *time.Timer) {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+
+	if rm.pendingNodeDown[node] != timer {
+		return
+	}
+	delete(rm.pendingNodeDown, node)
+
+	rgName, err := rm.findResourceGroupByNode(node)
+	if err != nil {
+		return
+	}
+	// fired by timer, there is no caller ctx
+	if err := rm.handleNodeDown(context.Background(), rgName, node); err != nil {
+		log.Warn("HandleNodeDown: failed to remove node from resource group",
+			zap.String("rgName", rgName),
+			zap.Int64("node", node),
+			zap.Error(err),
+		)
+	}
+}
+
+// remove down node from rg, it's called by HandleNodeDown, deferred removal and Close
+func (rm *ResourceManager) handleNodeDown(ctx context.Context, rgName string, node int64) error {
+	log.Info("HandleNodeDown: remove node from resource group",
+		zap.String("rgName", rgName),
+		zap.Int64("node", node),
+	)
+	lackBefore := rm.groups[rgName].LackOfNodes()
+	err := rm.groups[rgName].handleNodeDown(node)
+	if err != nil {
+		return err
+	}
+	rm.groups[rgName].recordChurn()
+	rm.unindexNode(node, rgName)
+	rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeRemoved, Node: node})
+	rm.updateResourceGroupMetrics(rgName)
+	rm.checkLackTransition(rgName, lackBefore)
+	// persist the removal, otherwise the down node comes back after restart
+	rm.savePrunedResourceGroup(ctx, rgName)
+	return nil
+}
+
+// flush all deferred node removals, close all subscription channels and wait for running lack listeners, no more removal
+// will be deferred after close. removals left are dropped if ctx is done while flushing
+func (rm *ResourceManager) Close(ctx context.Context) error {
+	errs := rm.shutdown(ctx)
+	// listeners may call back into the manager, so wait for them without holding the lock
+	rm.lackListenerWg.Wait()
+	return errs
+}
+
+func (rm *ResourceManager) shutdown(ctx context.Context) error {
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+
+	rm.closed = true
+	nodes := lo.Keys(rm.pendingNodeDown)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	for _, node := range nodes {
+		// the fired ones are waiting for the lock, they'll find themselves no longer pending
+		rm.pendingNodeDown[node].Stop()
+		delete(rm.pendingNodeDown, node)
+	}
+
+	var errs error
+	for i, node := range nodes {
+		if err := ctx.Err(); err != nil {
+			log.Warn("drop deferred node removals on close",
+				zap.Int64s("nodes", nodes[i:]),
+				zap.Error(err),
+			)
+			errs = multierr.Append(errs, err)
+			break
+		}
+
+		rgName, err := rm.findResourceGroupByNode(node)
+		if err != nil {
+			continue
+		}
+		if err := rm.handleNodeDown(ctx, rgName, node); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to remove node %d from rg %s: %w", node, rgName, err))
+		}
+	}
+
+	rm.subscriberMutex.Lock()
+	defer rm.subscriberMutex.Unlock()
+	rm.subscriberClosed = true
+	for id, ch := range rm.subscribers {
+		delete(rm.subscribers, id)
+		close(ch)
+	}
+
+	return errs
+}
+
+// transfer one node from one rg to another, return the moved node id
+func (rm *ResourceManager) TransferNode(ctx context.Context, from, to string) (int64, error) {
+	nodes, err := rm.transferNodes(ctx, from, to, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(nodes) == 0 {
+		return 0, ErrNodeNotEnough
+	}
+	return nodes[0], nil
+}
+
+// transfer `count` nodes from one rg to another, both rgs are saved in one store write
+func (rm *ResourceManager) TransferNodes(ctx context.Context, from, to string, count int) error {
+	_, err := rm.transferNodes(ctx, from, to, count)
+	return err
+}
+
+func (rm *ResourceManager) transferNodes(ctx context.Context, from, to string, count int) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if from == to {
+		return nil, fmt.Errorf("%w(rgName=%s)", ErrSameResourceGroup, from)
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return nil, err
+	}
+
+	if err := rm.checkResourceGroupsExist(from, to); err != nil {
+		return nil, err
+	}
+
+	if err := rm.checkRGSealed(from, to); err != nil {
+		return nil, err
+	}
+
+	// fail fast before pruning down nodes, which writes store. capacity doesn't change
+	// along with node down, so the check result stays the same after pruning
+	if rm.groups[to].exceedMaxCapacity(count) {
+		return nil, fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, to, rm.groups[to].GetMaxCapacity())
+	}
+
+	if len(rm.groups[from].nodes) == 0 {
+		return nil, ErrRGIsEmpty
+	}
+
+	rm.checkRGNodeStatus(ctx, from)
+	rm.checkRGNodeStatus(ctx, to)
+
+	// all nodes of source rg may be pruned as down nodes
+	if len(rm.groups[from].nodes) == 0 {
+		return nil, ErrRGIsEmpty
+	}
+
+	if len(rm.groups[from].nodes) < count {
+		return nil, ErrNodeNotEnough
+	}
+
+	nodes := rm.selectNodes(rm.groups[from].GetNodes(), count, to)
+	if len(nodes) < count {
+		return nil, ErrNodeNotEnough
+	}
+	for _, node := range nodes {
+		log.Debug("select node to transfer",
+			zap.Int64("node", node),
+			zap.String("from", from),
+			zap.String("to", to),
+			zap.String("reason", fmt.Sprintf("picked by %T among sorted nodes of source rg", rm.selector)),
+		)
+	}
+	if err := rm.checkMoveNodes(from, to, nodes); err != nil {
+		return nil, err
+	}
+	if err := rm.transferNodesInStore(ctx, from, to, nodes); err != nil {
+		return nil, err
+	}
+
+	rm.moveNodes(ctx, from, to, nodes)
+
+	log.Info("transfer nodes between resource groups",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int64s("nodes", nodes),
+	)
+	rm.audit(ctx, "TransferNodes",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int64s("nodes", nodes),
+	)
+
+	return nodes, nil
+}
+
+// estimate segments to migrate if count nodes are transferred from one rg to another,
+// nodes are picked as TransferNodes does, without any store writes or membership changes
+func (rm *ResourceManager) EstimateTransferCost(from, to string, count int) (TransferCost, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if rm.segments == nil {
+		return TransferCost{}, ErrSegmentProviderNotSet
+	}
+
+	if err := rm.checkResourceGroupsExist(from, to); err != nil {
+		return TransferCost{}, err
+	}
+
+	if from == to {
+		return TransferCost{}, fmt.Errorf("%w(rgName=%s)", ErrSameResourceGroup, from)
+	}
+
+	if err := rm.checkRGSealed(from, to); err != nil {
+		return TransferCost{}, err
+	}
+
+	if rm.groups[to].exceedMaxCapacity(count) {
+		return TransferCost{}, fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, to, rm.groups[to].GetMaxCapacity())
+	}
+
+	// down nodes are skipped instead of being removed, so it doesn't change any state
+	candidates := lo.Filter(rm.groups[from].GetNodes(), func(node int64, _ int) bool {
+		return rm.nodeMgr.Get(node) != nil
+	})
+	if len(candidates) < count {
+		return TransferCost{}, ErrNodeNotEnough
+	}
+
+	nodes := rm.selectNodes(candidates, count, to)
+	if len(nodes) < count {
+		return TransferCost{}, ErrNodeNotEnough
+	}
+	if err := rm.checkMoveNodes(from, to, nodes); err != nil {
+		return TransferCost{}, err
+	}
+
+	cost := TransferCost{
+		Nodes:          nodes,
+		NodeSegmentNum: make(map[int64]int, len(nodes)),
+	}
+	for _, node := range nodes {
+		num := rm.segments.NodeSegmentNum(node)
+		cost.NodeSegmentNum[node] = num
+		cost.SegmentNum += num
+	}
+	return cost, nil
+}
+
+// transfer the given node from one rg to another, node should be alive and belong to `from`
+func (rm *ResourceManager) TransferSpecificNode(ctx context.Context, from, to string, node int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	return rm.transferSpecificNode(ctx, from, to, node)
+}
+
+// move node to rg no matter which rg it belongs to currently, both rgs are saved in one store write.
+// unassigned node will be assigned to rg directly
+func (rm *ResourceManager) MoveNode(ctx context.Context, to string, node int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rm.groups[to] == nil {
+		return &ResourceGroupNotFoundError{Name: to}
+	}
+
+	from, err := rm.findResourceGroupByNode(node)
+	if err != nil {
+		return rm.assignNode(ctx, to, node)
+	}
+
+	return rm.transferSpecificNode(ctx, from, to, node)
+}
+
+func (rm *ResourceManager) transferSpecificNode(ctx context.Context, from, to string, node int64) error {
+	if err := rm.checkResourceGroupsExist(from, to); err != nil {
+		return err
+	}
+
+	if err := rm.checkRGSealed(from, to); err != nil {
+		return err
+	}
+
+	if rm.nodeMgr.Get(node) == nil {
+		return fmt.Errorf("%w(node=%d)", ErrNodeNotExist, node)
+	}
+
+	if ok, _ := rm.nodeMgr.IsStoppingNode(node); ok {
+		return fmt.Errorf("%w(node=%d)", ErrNodeStopped, node)
+	}
+
+	// fail fast before pruning down nodes, which writes store
+	if from != to && rm.groups[to].exceedMaxCapacity(1) {
+		return fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, to, rm.groups[to].GetMaxCapacity())
+	}
+
+	rm.checkRGNodeStatus(ctx, from)
+	rm.checkRGNodeStatus(ctx, to)
+	if !rm.groups[from].containsNode(node) {
+		return fmt.Errorf("%w(node=%d, rgName=%s)", ErrNodeNotAssignToRG, node, from)
+	}
+
+	if from == to {
+		return nil
+	}
+
+	if err := rm.checkMoveNodes(from, to, []int64{node}); err != nil {
+		return err
+	}
+	if err := rm.transferNodesInStore(ctx, from, to, []int64{node}); err != nil {
+		return err
+	}
+
+	rm.moveNodes(ctx, from, to, []int64{node})
+
+	log.Info("transfer node between resource groups",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int64("node", node),
+	)
+	rm.audit(ctx, "TransferNode",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int64("node", node),
+	)
+
+	return nil
+}
+
+// NodeMove is a step of transfer plan, which moves Node from rg From to rg To
+type NodeMove struct {
+	Node int64
+	From string
+	To   string
+}
+
+// ValidateTransferPlan checks whether all moves of plan could be applied together, without applying them
+func (rm *ResourceManager) ValidateTransferPlan(moves []NodeMove) error {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	return rm.validateTransferPlan(moves)
+}
+
+// ApplyTransferPlan applies all moves of plan, all involved rgs are saved in one store write.
+// nothing is applied if any move is invalid
+func (rm *ResourceManager) ApplyTransferPlan(ctx context.Context, moves []NodeMove) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if err := rm.validateTransferPlan(moves); err != nil {
+		log.Info("failed to apply transfer plan",
+			zap.Any("moves", moves),
+			zap.Error(err),
+		)
+		return err
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+
+	// rg -> nodes after applying plan, and the change of its capacity
+	nodes := make(map[string]typeutil.UniqueSet)
+	delta := make(map[string]int)
+	for _, move := range moves {
+		for _, rgName := range []string{move.From, move.To} {
+			if nodes[rgName] == nil {
+				nodes[rgName] = typeutil.NewUniqueSet(rm.groups[rgName].GetNodes()...)
+			}
+		}
+		nodes[move.From].Remove(move.Node)
+		nodes[move.To].Insert(move.Node)
+		delta[move.From]--
+		delta[move.To]++
+	}
+
+	rgNames := lo.Keys(nodes)
+	sort.Strings(rgNames)
+	rgs := make([]*querypb.ResourceGroup, 0, len(rgNames))
+	for _, rgName := range rgNames {
+		capacity := rm.groups[rgName].GetCapacity() + delta[rgName]
+		if delta[rgName] < 0 {
+			capacity = rm.decreasedCapacity(rgName, -delta[rgName])
+		}
+		rgs = append(rgs, &querypb.ResourceGroup{
+			Name:     rgName,
+			Capacity: int32(capacity),
+			Nodes:    nodes[rgName].Collect(),
+		})
+	}
+	if err := rm.saveResourceGroupsToStore(ctx, rgs...); err != nil {
+		log.Info("failed to apply transfer plan",
+			zap.Any("moves", moves),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	for _, move := range moves {
+		rm.moveNodes(ctx, move.From, move.To, []int64{move.Node})
+	}
+
+	log.Info("apply transfer plan",
+		zap.Any("moves", moves),
+	)
+	rm.audit(ctx, "ApplyTransferPlan",
+		zap.Any("moves", moves),
+	)
+	return nil
+}
+
+// every move should be a valid node transfer, no node is moved twice,
+// and no target exceeds its max capacity after all moves are applied
+func (rm *ResourceManager) validateTransferPlan(moves []NodeMove) error {
+	moved := typeutil.NewUniqueSet()
+	delta := make(map[string]int)
+	for _, move := range moves {
+		if move.From == move.To {
+			return fmt.Errorf("%w(rgName=%s)", ErrSameResourceGroup, move.From)
+		}
+
+		if err := rm.checkResourceGroupsExist(move.From, move.To); err != nil {
+			return err
+		}
+
+		if err := rm.checkRGSealed(move.From, move.To); err != nil {
+			return err
+		}
+
+		if moved.Contain(move.Node) {
+			return fmt.Errorf("%w: node %d is moved more than once", ErrTransferPlanInvalid, move.Node)
+		}
+		moved.Insert(move.Node)
+
+		if rm.nodeMgr.Get(move.Node) == nil {
+			return fmt.Errorf("%w(node=%d)", ErrNodeNotExist, move.Node)
+		}
+
+		if ok, _ := rm.nodeMgr.IsStoppingNode(move.Node); ok {
+			return fmt.Errorf("%w(node=%d)", ErrNodeStopped, move.Node)
+		}
+
+		if err := rm.checkMoveNodes(move.From, move.To, []int64{move.Node}); err != nil {
+			return err
+		}
+		delta[move.From]--
+		delta[move.To]++
+	}
+
+	for rgName, num := range delta {
+		if num > 0 && rm.groups[rgName].exceedMaxCapacity(num) {
+			return fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, rgName, rm.groups[rgName].GetMaxCapacity())
+		}
+	}
+
+	return nil
+}
+
+// swap nodeA in rgA with nodeB in rgB, both rgs are saved in one store write and their capacities stay unchanged.
+// node num of both rgs is unchanged either, so the swap never breaks capacity limit; only admission is checked
+func (rm *ResourceManager) SwapNodes(ctx context.Context, rgA string, nodeA int64, rgB string, nodeB int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if err := rm.checkResourceGroupsExist(rgA, rgB); err != nil {
+		return err
+	}
+
+	if rgA == rgB {
+		return fmt.Errorf("%w(rgName=%s)", ErrSameResourceGroup, rgA)
+	}
+
+	if err := rm.checkRGSealed(rgA, rgB); err != nil {
+		return err
+	}
+
+	rm.checkRGNodeStatus(ctx, rgA)
+	rm.checkRGNodeStatus(ctx, rgB)
+	if err := rm.checkMoveNodes(rgA, rgB, []int64{nodeA}); err != nil {
+		return err
+	}
+	if err := rm.checkMoveNodes(rgB, rgA, []int64{nodeB}); err != nil {
+		return err
+	}
+	if err := rm.checkNodesAdmissible(rgB, nodeA); err != nil {
+		return err
+	}
+	if err := rm.checkNodesAdmissible(rgA, nodeB); err != nil {
+		return err
+	}
+
+	nodesA := lo.Filter(rm.groups[rgA].GetNodes(), func(node int64, _ int) bool { return node != nodeA })
+	nodesB := lo.Filter(rm.groups[rgB].GetNodes(), func(node int64, _ int) bool { return node != nodeB })
+	err := rm.saveResourceGroupsToStore(ctx,
+		&querypb.ResourceGroup{
+			Name:     rgA,
+			Capacity: int32(rm.groups[rgA].GetCapacity()),
+			Nodes:    append(nodesA, nodeB),
+		},
+		&querypb.ResourceGroup{
+			Name:     rgB,
+			Capacity: int32(rm.groups[rgB].GetCapacity()),
+			Nodes:    append(nodesB, nodeA),
+		},
+	)
+	if err != nil {
+		log.Info("failed to swap nodes between resource groups",
+			zap.String("rgA", rgA),
+			zap.Int64("nodeA", nodeA),
+			zap.String("rgB", rgB),
+			zap.Int64("nodeB", nodeB),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	rm.groups[rgA].swapNode(nodeA, nodeB)
+	rm.groups[rgB].swapNode(nodeB, nodeA)
+	rm.nodeToRG[nodeA] = rgB
+	rm.nodeToRG[nodeB] = rgA
+	rm.saveNodeHomeRG(ctx, nodeA, rgB)
+	rm.saveNodeHomeRG(ctx, nodeB, rgA)
+	rm.notify(
+		ResourceGroupEvent{RGName: rgA, Type: NodeRemoved, Node: nodeA},
+		ResourceGroupEvent{RGName: rgB, Type: NodeRemoved, Node: nodeB},
+		ResourceGroupEvent{RGName: rgA, Type: NodeAdded, Node: nodeB},
+		ResourceGroupEvent{RGName: rgB, Type: NodeAdded, Node: nodeA},
+	)
+	rm.updateResourceGroupMetrics(rgA, rgB)
+
+	log.Info("swap nodes between resource groups",
+		zap.String("rgA", rgA),
+		zap.Int64("nodeA", nodeA),
+		zap.String("rgB", rgB),
+		zap.Int64("nodeB", nodeB),
+	)
+	rm.audit(ctx, "SwapNodes",
+		zap.String("rgA", rgA),
+		zap.Int64("nodeA", nodeA),
+		zap.String("rgB", rgB),
+		zap.Int64("nodeB", nodeB),
+	)
+	return nil
+}
+
+// check whether nodes could be moved from one rg to another, it should be called
+// before writing store, so that the memory mutation never fails after the write succeeds
+func (rm *ResourceManager) checkMoveNodes(from, to string, nodes []int64) error {
+	for _, rgName := range []string{from, to} {
+		if rm.groups[rgName].overlap {
+			return fmt.Errorf("%w(rgName=%s): move nodes of overlap rg is not permitted", ErrRGOverlapNotAllowed, rgName)
+		}
+	}
+
+	checked := typeutil.NewUniqueSet()
+	for _, node := range nodes {
+		if checked.Contain(node) || !rm.groups[from].containsNode(node) {
+			return fmt.Errorf("%w(node=%d, rgName=%s)", ErrNodeNotAssignToRG, node, from)
+		}
+		if rm.groups[to].containsNode(node) {
+			return &NodeAlreadyAssignedError{Node: node, CurrentRG: to}
+		}
+		checked.Insert(node)
+	}
+
+	return nil
+}
+
+// move nodes between rgs in memory, capacity moves along with nodes.
+// nodes should have been checked by checkMoveNodes, so every step here succeeds
+func (rm *ResourceManager) moveNodes(ctx context.Context, from, to string, nodes []int64) {
+	defer rm.updateResourceGroupMetrics(from, to)
+	_ = rm.groups[from].unassignNodes(nodes)
+	_ = rm.groups[to].assignNodes(nodes)
+	for _, node := range nodes {
+		rm.nodeToRG[node] = to
+		rm.saveNodeHomeRG(ctx, node, to)
+		rm.notify(
+			ResourceGroupEvent{RGName: from, Type: NodeRemoved, Node: node},
+			ResourceGroupEvent{RGName: to, Type: NodeAdded, Node: node},
+		)
+	}
+}
+
+// move all nodes in rg back to default rg, and reset rg's capacity to 0
+func (rm *ResourceManager) RemoveAllNodes(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rgName == rm.defaultRGName {
+		return ErrDeleteDefaultRG
+	}
+
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	nodes, err := rm.moveAllNodes(ctx, rgName, rm.defaultRGName)
+	if err != nil {
+		log.Info("failed to remove all nodes from resource group",
+			zap.String("rgName", rgName),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	log.Info("remove all nodes from resource group",
+		zap.String("rgName", rgName),
+		zap.Int64s("nodes", nodes),
+	)
+	return nil
+}
+
+// drain all nodes from one rg to another, source rg's capacity is reset to 0. return the moved node num
+func (rm *ResourceManager) TransferAllNodes(ctx context.Context, from, to string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return 0, err
+	}
+
+	if err := rm.checkResourceGroupsExist(from, to); err != nil {
+		return 0, err
+	}
+
+	if from == rm.defaultRGName {
+		return 0, fmt.Errorf("%w: drain default rg is not permitted", ErrRGNameInvalid)
+	}
+
+	if from == to {
+		return 0, nil
+	}
+
+	rm.checkRGNodeStatus(ctx, from)
+	rm.checkRGNodeStatus(ctx, to)
+	if rm.groups[to].exceedMaxCapacity(len(rm.groups[from].nodes)) {
+		return 0, fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, to, rm.groups[to].GetMaxCapacity())
+	}
+
+	nodes, err := rm.moveAllNodes(ctx, from, to)
+	if err != nil {
+		log.Info("failed to transfer all nodes between resource groups",
+			zap.String("from", from),
+			zap.String("to", to),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	log.Info("transfer all nodes between resource groups",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int64s("nodes", nodes),
+	)
+	return len(nodes), nil
+}
+
+// move all nodes of rg `from` to rg `to` with a single store write, and reset `from`'s capacity to 0
+func (rm *ResourceManager) moveAllNodes(ctx context.Context, from, to string) ([]int64, error) {
+	if err := rm.checkRGSealed(from, to); err != nil {
+		return nil, err
+	}
+
+	nodes := rm.groups[from].GetNodes()
+	if err := rm.checkMoveNodes(from, to, nodes); err != nil {
+		return nil, err
+	}
+	toNodes := rm.groups[to].GetNodes()
+	toNodes = append(toNodes, nodes...)
+	err := rm.saveResourceGroupsToStore(ctx,
+		&querypb.ResourceGroup{
+			Name:     from,
+			Capacity: 0,
+			Nodes:    []int64{},
+		},
+		&querypb.ResourceGroup{
+			Name:     to,
+			Capacity: int32(rm.groups[to].GetCapacity() + len(nodes)),
+			Nodes:    toNodes,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rm.moveNodes(ctx, from, to, nodes)
+	rm.groups[from].capacity = 0
+	rm.updateResourceGroupMetrics(from)
+	rm.audit(ctx, "TransferAllNodes",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.Int64s("nodes", nodes),
+	)
+
+	return nodes, nil
+}
+
+// emit audit record of a succeeded mutating operation, along with the actor carried by ctx
+func (rm *ResourceManager) audit(ctx context.Context, operation string, fields ...zap.Field) {
+	if !params.Params.QueryCoordCfg.RGAuditLogEnabled.GetAsBool() {
+		return
+	}
+
+	logger := rm.auditLogger
+	if logger == nil {
+		logger = log.L()
+	}
+	logger.Info("resource group audit",
+		append([]zap.Field{
+			zap.String("actor", auditActorFromContext(ctx)),
+			zap.String("operation", operation),
+		}, fields...)...,
+	)
+}
+
+// run fn unless the same operation has succeeded with the idempotency token in ctx,
+// so a retry of succeeded request after timeout doesn't fail. ctx without token always runs fn
+func (rm *ResourceManager) runIdempotent(ctx context.Context, operation string, fn func() error) error {
+	token, ok := idempotencyTokenFromContext(ctx)
+	if !ok {
+		return fn()
+	}
+
+	if rm.recentOps.contains(token, operation) {
+		log.Info("skip duplicated operation with the same idempotency token",
+			zap.String("token", token),
+			zap.String("operation", operation),
+		)
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+	rm.recentOps.add(token, operation)
+	return nil
+}
+
+// retry store write with backoff, transient store failure shouldn't fail the whole operation.
+// the write is always tried at least once, even if retry num is configured below 1
+func (rm *ResourceManager) retryStoreWrite(ctx context.Context, sentinel error, fn func() error) error {
+	attempts := params.Params.QueryCoordCfg.RGStoreRetryNum.GetAsInt()
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	err := retry.Do(ctx, func() error {
+		lastErr = fn()
+		return lastErr
+	},
+		retry.Attempts(uint(attempts)),
+		retry.Sleep(params.Params.QueryCoordCfg.RGStoreRetryInterval.GetAsDuration(time.Millisecond)),
+	)
+	if err != nil {
+		if lastErr == nil {
+			lastErr = err
+		}
+		return &storeError{sentinel: sentinel, cause: lastErr}
+	}
+	return nil
+}
+
+func (rm *ResourceManager) saveResourceGroupsToStore(ctx context.Context, rgs ...*querypb.ResourceGroup) error {
+	return rm.retryStoreWrite(ctx, ErrSaveResourceGroupToStore, func() error {
+		return rm.store.SaveResourceGroup(ctx, rgs...)
+	})
+}
+
+func (rm *ResourceManager) removeResourceGroupFromStore(ctx context.Context, rgName string) error {
+	return rm.retryStoreWrite(ctx, ErrRemoveResourceGroupFromStore, func() error {
+		return rm.store.RemoveResourceGroup(ctx, rgName)
+	})
+}
+
+func (rm *ResourceManager) transferNodesInStore(ctx context.Context, from string, to string, nodes []int64) error {
+	moved := typeutil.NewUniqueSet(nodes...)
+	fromNodeList := make([]int64, 0)
+	for nid := range rm.groups[from].nodes {
+		if !moved.Contain(nid) {
+			fromNodeList = append(fromNodeList, nid)
+		}
+	}
+	toNodeList := rm.groups[to].GetNodes()
+	toNodeList = append(toNodeList, nodes...)
+
+	fromRG := &querypb.ResourceGroup{
+		Name:     from,
+		Capacity: int32(rm.decreasedCapacity(from, len(nodes))),
+		Nodes:    fromNodeList,
+	}
+
+	toRG := &querypb.ResourceGroup{
+		Name:     to,
+		Capacity: int32(rm.groups[to].GetCapacity() + len(nodes)),
+		Nodes:    toNodeList,
+	}
+
+	return rm.saveResourceGroupsToStore(ctx, fromRG, toRG)
+}
+
+// select at most count nodes from candidates by node selector,
+// candidates are sorted so the selection is deterministic
+func (rm *ResourceManager) selectNodes(candidates []int64, count int, to string) []int64 {
+	if count <= 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i] < candidates[j]
+	})
+
+	// with topology, all candidates are ordered by selector, then picked across racks
+	selectNum := count
+	if rm.topology != nil {
+		selectNum = len(candidates)
+	}
+
+	// the selector is pluggable, make sure it only returns distinct candidates
+	valid := typeutil.NewUniqueSet(candidates...)
+	ret := make([]int64, 0, selectNum)
+	for _, node := range rm.selector.Select(candidates, selectNum) {
+		if len(ret) >= selectNum {
+			break
+		}
+		if valid.Contain(node) {
+			ret = append(ret, node)
+			valid.Remove(node)
+		}
+	}
+
+	if rm.topology != nil {
+		return spreadByRack(rm.topology, rm.groups[to].GetNodes(), ret, count)
+	}
+	return ret
+}
+
+// return rg's capacity after removing num nodes, capacity should never be negative
+func (rm *ResourceManager) decreasedCapacity(rgName string, num int) int {
+	capacity := rm.groups[rgName].GetCapacity() - num
+	if capacity < 0 {
+		log.Warn("resource group capacity underflow, reset it to 0",
+			zap.String("rgName", rgName),
+			zap.Int("capacity", rm.groups[rgName].GetCapacity()),
+			zap.Int("removedNum", num),
+		)
+		return 0
+	}
+
+	return capacity
+}
+
+// auto recover rg, return recover used node num, and whether the recovery is limited by
+// the reserved node num of default rg
+func (rm *ResourceManager) AutoRecoverResourceGroup(ctx context.Context, rgName string) (int, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return 0, false, err
+	}
+
+	if err := rm.checkAutoRecover(rgName); err != nil {
+		return 0, false, err
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	return rm.autoRecoverResourceGroup(ctx, rgName, rm.groups[rgName].LackOfNodes())
+}
+
+// check rg could be recovered with nodes in default rg, shared by real recovery and dry run
+func (rm *ResourceManager) checkAutoRecover(rgName string) error {
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if err := rm.checkRGSealed(rgName, rm.defaultRGName); err != nil {
+		return err
+	}
+
+	if rm.groups[rgName].overlap {
+		return fmt.Errorf("%w(rgName=%s): recover overlap rg is not permitted", ErrRGOverlapNotAllowed, rgName)
+	}
+
+	return nil
+}
+
+// recover rg with nodes in default rg, preferred nodes are used first if they're in default rg,
+// return recover used node num. unavailable preferred nodes are skipped
+func (rm *ResourceManager) AutoRecoverResourceGroupWithHint(ctx context.Context, rgName string, preferred []int64) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return 0, err
+	}
+
+	if err := rm.checkAutoRecover(rgName); err != nil {
+		return 0, err
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	num, _, err := rm.autoRecoverResourceGroupWithHint(ctx, rgName, rm.groups[rgName].LackOfNodes(), preferred)
+	return num, err
+}
+
+// recover at most num nodes for rg from default rg
+func (rm *ResourceManager) autoRecoverResourceGroup(ctx context.Context, rgName string, num int) (int, bool, error) {
+	return rm.autoRecoverResourceGroupWithHint(ctx, rgName, num, nil)
+}
+
+func (rm *ResourceManager) autoRecoverResourceGroupWithHint(ctx context.Context, rgName string, num int, preferred []int64) (int, bool, error) {
+	rm.checkRGNodeStatus(ctx, rm.defaultRGName)
+	nodesInDefault, limited := rm.selectRecoverNodes(rgName, num, preferred)
+	recoveredNum := 0
+	for _, node := range nodesInDefault {
+		defaultCapacity := rm.groups[rm.defaultRGName].GetCapacity()
+		err := rm.unassignNode(ctx, rm.defaultRGName, node)
+		if err != nil {
+			// interrupt transfer, unreachable logic path
+			return recoveredNum, limited, err
+		}
+
+		err = rm.groups[rgName].handleNodeUp(node)
+		if err != nil {
+			// roll back, unreachable logic path
+			if rollbackErr := rm.restoreNode(ctx, rm.defaultRGName, node, defaultCapacity); rollbackErr != nil {
+				log.Warn("failed to roll back node to default resource group",
+					zap.Int64("node", node),
+					zap.Error(rollbackErr),
+				)
+			}
+			return recoveredNum, limited, err
+		}
+		rm.nodeToRG[node] = rgName
+		rm.saveNodeHomeRG(ctx, node, rgName)
+		rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeAdded, Node: node})
+		recoveredNum++
+	}
+	rm.updateResourceGroupMetrics(rgName, rm.defaultRGName)
+
+	log.Info("auto recover resource group",
+		zap.String("rgName", rgName),
+		zap.Int("lackNodesNum", rm.groups[rgName].LackOfNodes()+recoveredNum),
+		zap.Int("recoveredNum", recoveredNum),
+		zap.Bool("limitedByReserve", limited),
+	)
+
+	return recoveredNum, limited, nil
+}
+
+// recover rg with nodes of donor rgs in the given order until it's not lacking, return recovered node num.
+// only nodes exceeding the capacity of a donor are taken, so donors never become lacking.
+// default rg as donor is drained the same way as AutoRecoverResourceGroup does
+func (rm *ResourceManager) AutoRecoverResourceGroupFrom(ctx context.Context, rgName string, donors []string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return 0, err
+	}
+
+	for _, name := range append([]string{rgName}, donors...) {
+		if rm.groups[name] == nil {
+			return 0, &ResourceGroupNotFoundError{Name: name}
+		}
+		if rm.groups[name].overlap {
+			return 0, fmt.Errorf("%w(rgName=%s): recover overlap rg is not permitted", ErrRGOverlapNotAllowed, name)
+		}
+	}
+
+	if err := rm.checkRGSealed(append([]string{rgName}, donors...)...); err != nil {
+		return 0, err
+	}
+
+	if lo.Contains(donors, rgName) {
+		return 0, fmt.Errorf("%w(rgName=%s)", ErrSameResourceGroup, rgName)
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	recoveredNum := 0
+	for _, donor := range donors {
+		lack := rm.groups[rgName].LackOfNodes()
+		if maxCapacity := rm.groups[rgName].GetMaxCapacity(); maxCapacity > 0 && maxCapacity-len(rm.groups[rgName].nodes) < lack {
+			lack = maxCapacity - len(rm.groups[rgName].nodes)
+		}
+		if lack <= 0 {
+			break
+		}
+
+		if donor == rm.defaultRGName {
+			num, _, err := rm.autoRecoverResourceGroup(ctx, rgName, lack)
+			recoveredNum += num
+			if err != nil {
+				return recoveredNum, err
+			}
+			continue
+		}
+
+		rm.checkRGNodeStatus(ctx, donor)
+		num := len(rm.groups[donor].nodes) - rm.groups[donor].GetCapacity()
+		if num > lack {
+			num = lack
+		}
+		candidates := lo.Filter(rm.groups[donor].GetNodes(), func(node int64, _ int) bool {
+			return rm.groups[rgName].admits(node)
+		})
+		nodes := rm.selectNodes(candidates, num, rgName)
+		if len(nodes) == 0 {
+			continue
+		}
+		if err := rm.recoverNodesFromDonor(ctx, donor, rgName, nodes); err != nil {
+			return recoveredNum, err
+		}
+		recoveredNum += len(nodes)
+	}
+
+	log.Info("auto recover resource group from donors",
+		zap.String("rgName", rgName),
+		zap.Strings("donors", donors),
+		zap.Int("recoveredNum", recoveredNum),
+		zap.Int("lackNodesNum", rm.groups[rgName].LackOfNodes()),
+	)
+	return recoveredNum, nil
+}
+
+// move surplus nodes of donor to rg, capacities of both rgs stay unchanged.
+// nodes are checked before the store write, so memory is always updated along with store
+func (rm *ResourceManager) recoverNodesFromDonor(ctx context.Context, donor, rgName string, nodes []int64) error {
+	if err := rm.checkMoveNodes(donor, rgName, nodes); err != nil {
+		return err
+	}
+	if err := rm.checkNodesAdmissible(rgName, nodes...); err != nil {
+		return err
+	}
+	if len(nodes) > rm.groups[rgName].LackOfNodes() {
+		return fmt.Errorf("%w(rgName=%s, lackNodesNum=%d)", ErrRGIsFull, rgName, rm.groups[rgName].LackOfNodes())
+	}
+	if maxCapacity := rm.groups[rgName].GetMaxCapacity(); maxCapacity > 0 && len(rm.groups[rgName].nodes)+len(nodes) > maxCapacity {
+		return fmt.Errorf("%w(rgName=%s, maxCapacity=%d)", ErrRGCapacityExceeded, rgName, maxCapacity)
+	}
+
+	moved := typeutil.NewUniqueSet(nodes...)
+	donorRG := &querypb.ResourceGroup{
+		Name:     donor,
+		Capacity: int32(rm.groups[donor].GetCapacity()),
+		Nodes: lo.Filter(rm.groups[donor].GetNodes(), func(node int64, _ int) bool {
+			return !moved.Contain(node)
+		}),
+	}
+	rg := &querypb.ResourceGroup{
+		Name:     rgName,
+		Capacity: int32(rm.groups[rgName].GetCapacity()),
+		Nodes:    append(rm.groups[rgName].GetNodes(), nodes...),
+	}
+	if err := rm.saveResourceGroupsToStore(ctx, donorRG, rg); err != nil {
+		log.Info("failed to recover resource group from donor",
+			zap.String("rgName", rgName),
+			zap.String("donor", donor),
+			zap.Int64s("nodes", nodes),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	rm.groups[donor].removeNodes(nodes)
+	rm.groups[rgName].insertNodes(nodes)
+	for _, node := range nodes {
+		rm.nodeToRG[node] = rgName
+		rm.saveNodeHomeRG(ctx, node, rgName)
+		rm.notify(
+			ResourceGroupEvent{RGName: donor, Type: NodeRemoved, Node: node},
+			ResourceGroupEvent{RGName: rgName, Type: NodeAdded, Node: node},
+		)
+	}
+	rm.updateResourceGroupMetrics(donor, rgName)
+
+	log.Info("recover resource group from donor",
+		zap.String("rgName", rgName),
+		zap.String("donor", donor),
+		zap.Int64s("nodes", nodes),
+	)
+	return nil
+}
+
+// move nodes exceeding the capacity of rg back to default rg, return shed node num. nodes exceed
+// capacity after the limit of rg is lowered below its node num by SetResourceGroupLimit.
+// capacity of rg stays unchanged, and capacity of default rg grows with the shed nodes
+func (rm *ResourceManager) AutoShedResourceGroup(ctx context.Context, rgName string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return 0, err
+	}
+
+	if rm.groups[rgName] == nil {
+		return 0, &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	if rgName == rm.defaultRGName {
+		return 0, fmt.Errorf("%w(rgName=%s)", ErrSameResourceGroup, rgName)
+	}
+
+	if err := rm.checkRGSealed(rgName, rm.defaultRGName); err != nil {
+		return 0, err
+	}
+
+	if rm.groups[rgName].overlap {
+		return 0, fmt.Errorf("%w(rgName=%s): shed overlap rg is not permitted", ErrRGOverlapNotAllowed, rgName)
+	}
+
+	rm.checkRGNodeStatus(ctx, rgName)
+	rg := rm.groups[rgName]
+	surplus := len(rg.nodes) - rg.GetCapacity()
+	if surplus <= 0 {
+		return 0, nil
+	}
+
+	nodes := rm.selectNodes(rg.GetNodes(), surplus, rm.defaultRGName)
+	if err := rm.checkMoveNodes(rgName, rm.defaultRGName, nodes); err != nil {
+		return 0, err
+	}
+
+	shed := typeutil.NewUniqueSet(nodes...)
+	defaultRG := rm.groups[rm.defaultRGName]
+	err := rm.saveResourceGroupsToStore(ctx,
+		&querypb.ResourceGroup{
+			Name:     rgName,
+			Capacity: int32(rg.GetCapacity()),
+			Nodes: lo.Filter(rg.GetNodes(), func(node int64, _ int) bool {
+				return !shed.Contain(node)
+			}),
+		},
+		&querypb.ResourceGroup{
+			Name:     rm.defaultRGName,
+			Capacity: int32(defaultRG.GetCapacity() + len(nodes)),
+			Nodes:    append(defaultRG.GetNodes(), nodes...),
+		},
+	)
+	if err != nil {
+		log.Info("failed to shed resource group",
+			zap.String("rgName", rgName),
+			zap.Int64s("nodes", nodes),
+			zap.Error(err),
+		)
+		return 0, err
+	}
+
+	for _, node := range nodes {
+		_ = rg.handleNodeDown(node)
+		_ = defaultRG.assignNode(node)
+		rm.nodeToRG[node] = rm.defaultRGName
+		rm.saveNodeHomeRG(ctx, node, rm.defaultRGName)
+		rm.notify(
+			ResourceGroupEvent{RGName: rgName, Type: NodeRemoved, Node: node},
+			ResourceGroupEvent{RGName: rm.defaultRGName, Type: NodeAdded, Node: node},
+		)
+	}
+	rm.updateResourceGroupMetrics(rgName, rm.defaultRGName)
+	rm.audit(ctx, "ShedResourceGroup", zap.String("rgName", rgName), zap.Int64s("nodes", nodes))
+
+	log.Info("auto shed resource group",
+		zap.String("rgName", rgName),
+		zap.Int("capacity", rg.GetCapacity()),
+		zap.Int64s("shedNodes", nodes),
+	)
+	return len(nodes), nil
+}
+
+// return nodes in default rg which would be used to recover rg, without any store writes or membership changes
+func (rm *ResourceManager) AutoRecoverResourceGroupDryRun(rgName string) ([]int64, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	if err := rm.checkAutoRecover(rgName); err != nil {
+		return nil, err
+	}
+
+	// the same checks and selection as AutoRecoverResourceGroup, with down nodes filtered instead of pruned
+	nodes, _ := rm.selectRecoverNodes(rgName, rm.getLackOfNodes(rgName), nil)
+	return nodes, nil
+}
+
+// select at most num nodes in default rg to recover rg. down nodes are skipped
+// instead of being removed, so it doesn't change any state.
+// at least the reserved num of nodes are left in default rg, return whether the selection is limited by it
+func (rm *ResourceManager) selectRecoverNodes(rgName string, num int, preferred []int64) ([]int64, bool) {
+	isAlive := func(node int64, _ int) bool {
+		return rm.nodeMgr.Get(node) != nil
+	}
+
+	lackNodesNum := rm.getLackOfNodes(rgName)
+	if num < lackNodesNum {
+		lackNodesNum = num
+	}
+
+	candidates := lo.Filter(rm.groups[rm.defaultRGName].GetNodes(), func(node int64, i int) bool {
+		return isAlive(node, i) && rm.groups[rgName].admits(node)
+	})
+	wanted := lackNodesNum
+	if wanted > len(candidates) {
+		wanted = len(candidates)
+	}
+	allowed := len(candidates) - params.Params.QueryCoordCfg.DefaultRGReservedNodeNum.GetAsInt()
+	if allowed < 0 {
+		allowed = 0
+	}
+	limited := allowed < wanted
+	if limited {
+		wanted = allowed
+	}
+
+	// take available preferred nodes first, then select the others as usual
+	candidateSet := typeutil.NewUniqueSet(candidates...)
+	selected := make([]int64, 0, wanted)
+	for _, node := range preferred {
+		if len(selected) >= wanted {
+			break
+		}
+		if candidateSet.Contain(node) {
+			selected = append(selected, node)
+			candidateSet.Remove(node)
+		}
+	}
+	candidates = lo.Filter(candidates, func(node int64, _ int) bool {
+		return candidateSet.Contain(node)
+	})
+	return append(selected, rm.selectNodes(candidates, wanted-len(selected), rgName)...), limited
+}
+
+// set min capacity of rg, 0 means no min capacity. rgs below their min capacity
+// will be recovered prior to others in AutoRecoverAll
+func (rm *ResourceManager) SetResourceGroupMinCapacity(ctx context.Context, rgName string, min int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return err
+	}
+
+	if rgName == rm.defaultRGName {
+		return fmt.Errorf("%w: set min capacity for default rg is not permitted", ErrRGMinCapacityInvalid)
+	}
+
+	if rm.groups[rgName] == nil {
+		return &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	maxCapacity := rm.groups[rgName].GetMaxCapacity()
+	if min < 0 || (maxCapacity > 0 && min > maxCapacity) {
+		return fmt.Errorf("%w(minCapacity=%d)", ErrRGMinCapacityInvalid, min)
+	}
+
+	var err error
+	if min == 0 {
+		err = rm.store.RemoveResourceGroupMinCapacity(ctx, rgName)
+	} else {
+		err = rm.store.SaveResourceGroupMinCapacity(ctx, rgName, int32(min))
+	}
+	if err != nil {
+		log.Info("failed to set resource group min capacity",
+			zap.String("rgName", rgName),
+			zap.Int("minCapacity", min),
+			zap.Error(err),
+		)
+		return err
+	}
+	rm.groups[rgName].minCapacity = min
+	log.Info("set resource group min capacity",
+		zap.String("rgName", rgName),
+		zap.Int("minCapacity", min),
+	)
+	return nil
+}
+
+// auto recover all rgs, rgs below their min capacity are satisfied first, and
+// the one with larger deficit goes first. return recover used node num
+func (rm *ResourceManager) AutoRecoverAll(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return 0, err
+	}
+
+	if rm.groups[rm.defaultRGName].sealed {
+		log.Info("default resource group is sealed, skip auto recover")
+		return 0, nil
+	}
+
+	if err := rm.refreshProportionalCapacity(ctx); err != nil {
+		return 0, err
+	}
+
+	rgNames := make([]string, 0, len(rm.groups))
+	for rgName, rg := range rm.groups {
+		if rgName == rm.defaultRGName || rg.sealed || rg.overlap {
+			continue
+		}
+		rm.checkRGNodeStatus(ctx, rgName)
+		rgNames = append(rgNames, rgName)
+	}
+	sort.Strings(rgNames)
+
+	deficient := lo.Filter(rgNames, func(rgName string, _ int) bool {
+		return rm.groups[rgName].minCapacityDeficit() > 0
+	})
+	sort.SliceStable(deficient, func(i, j int) bool {
+		return rm.groups[deficient[i]].minCapacityDeficit() > rm.groups[deficient[j]].minCapacityDeficit()
+	})
+
+	recoveredNum := 0
+	for _, rgName := range deficient {
+		num, _, err := rm.autoRecoverResourceGroup(ctx, rgName, rm.groups[rgName].minCapacityDeficit())
+		recoveredNum += num
+		if err != nil {
+			return recoveredNum, err
+		}
+	}
+
+	for _, rgName := range rgNames {
+		num, _, err := rm.autoRecoverResourceGroup(ctx, rgName, rm.groups[rgName].LackOfNodes())
+		recoveredNum += num
+		if err != nil {
+			return recoveredNum, err
+		}
+	}
+
+	return recoveredNum, nil
+}
+
+// spread spare nodes in default rg to lacking rgs, each rg gets a share proportional to its lack of nodes,
+// and the remaining nodes go to the rgs with the largest remainder. the reserve of default rg is kept.
+// return the added node num of each rg
+func (rm *ResourceManager) RebalanceFromDefault(ctx context.Context) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	if err := rm.checkRecovering(); err != nil {
+		return nil, err
+	}
+
+	added := make(map[string]int)
+	if rm.groups[rm.defaultRGName].sealed {
+		log.Info("default resource group is sealed, skip rebalance")
+		return added, nil
+	}
+
+	lacks := make(map[string]int)
+	rgNames := make([]string, 0, len(rm.groups))
+	totalLack := 0
+	for rgName, rg := range rm.groups {
+		if rgName == rm.defaultRGName || rg.sealed || rg.overlap {
+			continue
+		}
+		rm.checkRGNodeStatus(ctx, rgName)
+		if lack := rg.LackOfNodes(); lack > 0 {
+			lacks[rgName] = lack
+			rgNames = append(rgNames, rgName)
+			totalLack += lack
+		}
+	}
+	if totalLack == 0 {
+		return added, nil
+	}
+
+	rm.checkRGNodeStatus(ctx, rm.defaultRGName)
+	available := len(rm.groups[rm.defaultRGName].GetNodes()) - params.Params.QueryCoordCfg.DefaultRGReservedNodeNum.GetAsInt()
+	if available <= 0 {
+		return added, nil
+	}
+
+	shares := make(map[string]int)
+	if available >= totalLack {
+		shares = lacks
+	} else {
+		remainders := make(map[string]int)
+		left := available
+		for _, rgName := range rgNames {
+			shares[rgName] = available * lacks[rgName] / totalLack
+			remainders[rgName] = available * lacks[rgName] % totalLack
+			left -= shares[rgName]
+		}
+		sort.Slice(rgNames, func(i, j int) bool {
+			if remainders[rgNames[i]] != remainders[rgNames[j]] {
+				return remainders[rgNames[i]] > remainders[rgNames[j]]
+			}
+			if lacks[rgNames[i]] != lacks[rgNames[j]] {
+				return lacks[rgNames[i]] > lacks[rgNames[j]]
+			}
+			return rgNames[i] < rgNames[j]
+		})
+		for i := 0; i < left; i++ {
+			shares[rgNames[i]]++
+		}
+	}
+
+	sort.Strings(rgNames)
+	for _, rgName := range rgNames {
+		if shares[rgName] == 0 {
+			continue
+		}
+		num, _, err := rm.autoRecoverResourceGroup(ctx, rgName, shares[rgName])
+		if num > 0 {
+			added[rgName] = num
+		}
+		if err != nil {
+			return added, err
+		}
+	}
+
+	log.Info("rebalance nodes from default resource group",
+		zap.Any("added", added),
+	)
+	return added, nil
+}
+
+// put node back to rg with the given capacity, which undo a previous unassignNode
+func (rm *ResourceManager) restoreNode(ctx context.Context, rgName string, node int64, capacity int) error {
+	newNodes := rm.groups[rgName].GetNodes()
+	newNodes = append(newNodes, node)
+	err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+		Name:     rgName,
+		Capacity: int32(capacity),
+		Nodes:    newNodes,
+	})
+	if err != nil {
+		return err
+	}
+
+	rm.groups[rgName].nodes.Insert(node)
+	rm.groups[rgName].recordNodeCount()
+	rm.groups[rgName].capacity = capacity
+	rm.nodeToRG[node] = rgName
+	rm.saveNodeHomeRG(ctx, node, rgName)
+	rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeAdded, Node: node})
+	rm.updateResourceGroupMetrics(rgName)
+	return nil
+}
+
+func (rm *ResourceManager) Recover(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// mutating methods are rejected until recover completes, so they won't race with
+	// the rgs being reconstructed while loading meta from store without lock
+	rm.rwmutex.Lock()
+	rm.recovering = true
+	rm.rwmutex.Unlock()
+	defer func() {
+		rm.rwmutex.Lock()
+		rm.recovering = false
+		rm.rwmutex.Unlock()
+	}()
+
+	rgs, err := rm.store.GetResourceGroups(ctx)
+	if err != nil {
+		return ErrRecoverResourceGroupToStore
+	}
+
+	nodeHomeRG, err := rm.store.GetNodeResourceGroups(ctx)
+	if err != nil {
+		return ErrRecoverResourceGroupToStore
+	}
+
+	limits, err := rm.store.GetResourceGroupLimits(ctx)
+	if err != nil {
+		return ErrRecoverResourceGroupToStore
+	}
+
+	labels, err := rm.store.GetResourceGroupLabels(ctx)
+	if err != nil {
+		return ErrRecoverResourceGroupToStore
+	}
+
+	sealed, err := rm.store.GetSealedResourceGroups(ctx)
+	if err != nil {
+		return ErrRecoverResourceGroupToStore
+	}
+	sealedSet := typeutil.NewSet(sealed...)
+
+	overlap, err := rm.store.GetOverlapResourceGroups(ctx)
+	if err != nil {
+		return ErrRecoverResourceGroupToStore
+	}
+	overlapSet := typeutil.NewSet(overlap...)
+
+	proportions, err := rm.store.GetResourceGroupProportions(ctx)
+	if err != nil {
+		return ErrRecoverResourceGroupToStore
+	}
+
+	minCapacities, err := rm.store.GetResourceGroupMinCapacities(ctx)
+	if err != nil {
+		return ErrRecoverResourceGroupToStore
+	}
+
+	rm.rwmutex.Lock()
+	defer rm.rwmutex.Unlock()
+	rm.nodeHomeRG = nodeHomeRG
+	rm.recoverDroppedNodes = make(map[string][]int64)
+
+	// process rgs in name order, so the conflict resolution is deterministic
+	sort.Slice(rgs, func(i, j int) bool {
+		return rgs[i].GetName() < rgs[j].GetName()
+	})
+
+	// errors of nodes or rgs which failed to be recovered, the others are still loaded
+	var errs error
+	rgs, errs = rm.checkRecoverRGLimit(rgs)
+
+	var defaultRG *querypb.ResourceGroup
+	// node -> the rg which recovered it first
+	recovered := make(map[int64]string)
+	for _, rg := range rgs {
+		if rg.GetName() == rm.defaultRGName {
+			defaultRG = rg
+			continue
+		}
+
+		// node belongs to the first processed rg which holds it, duplicated entries are dropped.
+		// nodes of overlap rg are shared, so they never conflict
+		isOverlap := overlapSet.Contain(rg.GetName())
+		nodes := typeutil.NewUniqueSet()
+		for _, node := range rg.GetNodes() {
+			if isOverlap {
+				nodes.Insert(node)
+				continue
+			}
+			if owner, ok := recovered[node]; ok && owner != rg.GetName() {
+				log.Warn("found node in multiple resource groups, skip it",
+					zap.String("rgName", rg.GetName()),
+					zap.String("currentRG", owner),
+					zap.Int64("node", node),
+				)
+				errs = multierr.Append(errs, fmt.Errorf("failed to recover node into rg %s: %w",
+					rg.GetName(), &NodeAlreadyAssignedError{Node: node, CurrentRG: owner}))
+				continue
+			}
+			nodes.Insert(node)
+			recovered[node] = rg.GetName()
+		}
+
+		capacity := int(rg.GetCapacity())
+		if nodes.Len() > capacity {
+			// capacity should never be less than assigned nodes num, repair it
+			log.Warn("found resource group capacity drift, repair it",
+				zap.String("rgName", rg.GetName()),
+				zap.Int32("storedCapacity", rg.GetCapacity()),
+				zap.Int("nodeNum", nodes.Len()),
+			)
+			capacity = nodes.Len()
+		}
+
+		if capacity != int(rg.GetCapacity()) || nodes.Len() != len(rg.GetNodes()) {
+			err := rm.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+				Name:     rg.GetName(),
+				Capacity: int32(capacity),
+				Nodes:    nodes.Collect(),
+			})
+			if err != nil {
+				log.Warn("failed to save repaired resource group",
+					zap.String("rgName", rg.GetName()),
+					zap.Error(err),
+				)
+				errs = multierr.Append(errs, fmt.Errorf("failed to save repaired rg %s: %w", rg.GetName(), err))
+			}
+		}
+
+		rm.groups[rg.GetName()] = NewResourceGroup(capacity)
+		rm.groups[rg.GetName()].maxCapacity = int(limits[rg.GetName()])
+		rm.groups[rg.GetName()].overlap = isOverlap
+		rm.groups[rg.GetName()].nodes.Insert(nodes.Collect()...)
+		rm.groups[rg.GetName()].recordNodeCount()
+		rm.recordRecoverDroppedNodes(rg.GetName())
+		rm.checkRGNodeStatus(ctx, rg.GetName())
+		log.Info("Recover resource group",
+			zap.String("rgName", rg.GetName()),
+			zap.Int64s("nodes", rg.GetNodes()),
+			zap.Int32("capacity", rg.GetCapacity()),
+		)
+	}
+	if defaultRG != nil {
+		if err := rm.recoverDefaultResourceGroup(ctx, defaultRG); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to save repaired rg %s: %w", rm.defaultRGName, err))
+		}
+	}
+	// labels, sealed, proportion and min capacity records of removed rgs are ignored.
+	// nodes dropped while rebuilding rgs are not churn, so churn starts over
+	for rgName, rg := range rm.groups {
+		rg.labels = labels[rgName]
+		rg.sealed = sealedSet.Contain(rgName)
+		rg.proportion = proportions[rgName]
+		rg.minCapacity = int(minCapacities[rgName])
+		rg.churn = nil
+	}
+	rm.rebuildNodeIndex()
+	for rgName := range rm.groups {
+		rm.updateResourceGroupMetrics(rgName)
+	}
+
+	if len(rm.recoverDroppedNodes) > 0 {
+		log.Warn("drop stored nodes which don't exist in node manager, store may be stale",
+			zap.Any("droppedNodes", rm.recoverDroppedNodes),
+		)
+	}
+
+	if errs != nil {
+		return multierr.Append(ErrRGPartialRecovered, errs)
+	}
+	return nil
+}
+
+// stored rgs may exceed the limit which AddResourceGroup enforces, e.g. limit lowered before restart.
+// warn about it, and if configured, only keep the first rgs in name order within the limit,
+// the refused ones stay in store so they come back once the limit is raised
+func (rm *ResourceManager) checkRecoverRGLimit(rgs []*querypb.ResourceGroup) ([]*querypb.ResourceGroup, error) {
+	// default rg always exists, and takes one slot of the limit
+	num := lo.CountBy(rgs, func(rg *querypb.ResourceGroup) bool {
+		return rg.GetName() != rm.defaultRGName
+	})
+	if num+1 <= rm.maxResourceGroupNum {
+		return rgs, nil
+	}
+
+	if !params.Params.QueryCoordCfg.RGRecoverRefuseOverLimit.GetAsBool() {
+		log.Warn("stored resource groups exceed the limit, load them all",
+			zap.Int("rgNum", num+1),
+			zap.Int("limit", rm.maxResourceGroupNum),
+		)
+		return rgs, nil
+	}
+
+	var errs error
+	kept := make([]*querypb.ResourceGroup, 0, rm.maxResourceGroupNum)
+	refused := make([]string, 0)
+	loaded := 0
+	for _, rg := range rgs {
+		if rg.GetName() == rm.defaultRGName {
+			kept = append(kept, rg)
+			continue
+		}
+		if loaded < rm.maxResourceGroupNum-1 {
+			kept = append(kept, rg)
+			loaded++
+			continue
+		}
+		refused = append(refused, rg.GetName())
+		errs = multierr.Append(errs, fmt.Errorf("failed to recover rg %s: %w %d", rg.GetName(), ErrRGLimit, rm.maxResourceGroupNum))
+	}
+	log.Warn("stored resource groups exceed the limit, refuse to load the rest",
+		zap.Int("rgNum", num+1),
+		zap.Int("limit", rm.maxResourceGroupNum),
+		zap.Strings("refusedRGs", refused),
+	)
+	return kept, errs
+}
+
+// record nodes of recovered rg which don't exist in node manager, they will be dropped by checkRGNodeStatus
+func (rm *ResourceManager) recordRecoverDroppedNodes(rgName string) {
+	dropped := lo.Filter(rm.groups[rgName].GetNodes(), func(node int64, _ int) bool {
+		return rm.nodeMgr.Get(node) == nil
+	})
+	if len(dropped) == 0 {
+		return
+	}
+
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i] < dropped[j] })
+	rm.recoverDroppedNodes[rgName] = dropped
+	metrics.QueryCoordResourceGroupRecoverDroppedNodeNum.WithLabelValues(rgName).Add(float64(len(dropped)))
+}
+
+// return stored nodes of each rg dropped in last recovery since they don't exist in node manager
+func (rm *ResourceManager) GetRecoverDroppedNodes() map[string][]int64 {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make(map[string][]int64, len(rm.recoverDroppedNodes))
+	for rgName, nodes := range rm.recoverDroppedNodes {
+		ret[rgName] = append([]int64(nil), nodes...)
+	}
+	return ret
+}
+
+// default rg always has the fixed capacity, and only holds its stored nodes which
+// don't belong to other rgs, so recover it after all other rgs.
+// return error if the repaired default rg failed to be saved, it's still recovered in memory
+func (rm *ResourceManager) recoverDefaultResourceGroup(ctx context.Context, stored *querypb.ResourceGroup) error {
+	assigned := typeutil.NewUniqueSet()
+	for rgName, rg := range rm.groups {
+		if rgName != rm.defaultRGName && !rg.overlap {
+			assigned.Insert(rg.GetNodes()...)
+		}
+	}
+
+	nodes := lo.Filter(lo.Uniq(stored.GetNodes()), func(node int64, _ int) bool {
+		return !assigned.Contain(node)
+	})
+	rm.groups[rm.defaultRGName] = NewResourceGroup(defaultResourceGroupCapacity)
+	rm.groups[rm.defaultRGName].nodes.Insert(nodes...)
+	rm.groups[rm.defaultRGName].recordNodeCount()
+
+	var err error
+	if int(stored.GetCapacity()) != defaultResourceGroupCapacity || len(nodes) != len(stored.GetNodes()) {
+		log.Info("reset default resource group to fixed capacity and unassigned nodes",
+			zap.Int32("storedCapacity", stored.GetCapacity()),
+			zap.Int64s("storedNodes", stored.GetNodes()),
+			zap.Int64s("nodes", nodes),
+		)
+		err = rm.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+			Name:     rm.defaultRGName,
+			Capacity: defaultResourceGroupCapacity,
+			Nodes:    nodes,
+		})
+		if err != nil {
+			log.Warn("failed to save repaired resource group",
+				zap.String("rgName", rm.defaultRGName),
+				zap.Error(err),
+			)
+		}
+	}
+
+	rm.recordRecoverDroppedNodes(rm.defaultRGName)
+	rm.checkRGNodeStatus(ctx, rm.defaultRGName)
+	log.Info("Recover resource group",
+		zap.String("rgName", rm.defaultRGName),
+		zap.Int64s("nodes", nodes),
+		zap.Int("capacity", defaultResourceGroupCapacity),
+	)
+	return err
+}
+
+// record the rg which node has been placed into, so it can go back after restart.
+// failure only logs, since the node's membership has been persisted already
+func (rm *ResourceManager) saveNodeHomeRG(ctx context.Context, node int64, rgName string) {
+	if rgName == rm.defaultRGName {
+		rm.removeNodeHomeRG(ctx, node)
+		return
+	}
+
+	if rm.nodeHomeRG[node] == rgName {
+		return
+	}
+
+	if err := rm.store.SaveNodeResourceGroup(ctx, node, rgName); err != nil {
+		log.Warn("failed to save node's resource group",
+			zap.String("rgName", rgName),
+			zap.Int64("node", node),
+			zap.Error(err),
+		)
+		return
+	}
+	rm.nodeHomeRG[node] = rgName
+}
+
+func (rm *ResourceManager) removeNodeHomeRG(ctx context.Context, node int64) {
+	if _, ok := rm.nodeHomeRG[node]; !ok {
+		return
+	}
+
+	if err := rm.store.RemoveNodeResourceGroup(ctx, node); err != nil {
+		log.Warn("failed to remove node's resource group",
+			zap.Int64("node", node),
+			zap.Error(err),
+		)
+		return
+	}
+	delete(rm.nodeHomeRG, node)
+}
+
+// CheckConsistency verifies the invariants of resource groups, and returns all violations found
+func (rm *ResourceManager) CheckConsistency() []error {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	errs := make([]error, 0)
+	if rm.groups[rm.defaultRGName] == nil {
+		errs = append(errs, fmt.Errorf("%w: default resource group doesn't exist", ErrRGInconsistent))
+	}
+
+	rgNames := lo.Keys(rm.groups)
+	sort.Strings(rgNames)
+	nodeOwner := make(map[int64]string)
+	for _, rgName := range rgNames {
+		rg := rm.groups[rgName]
+		if len(rg.nodes) > rg.GetCapacity() {
+			errs = append(errs, fmt.Errorf("%w: rg %s has %d nodes, more than capacity %d",
+				ErrRGInconsistent, rgName, len(rg.nodes), rg.GetCapacity()))
+		}
+		if rg.GetMaxCapacity() > 0 && rg.GetCapacity() > rg.GetMaxCapacity() {
+			errs = append(errs, fmt.Errorf("%w: rg %s has capacity %d, more than max capacity %d",
+				ErrRGInconsistent, rgName, rg.GetCapacity(), rg.GetMaxCapacity()))
+		}
+
+		// nodes of overlap rg are shared and not indexed
+		if rg.overlap {
+			continue
+		}
+
+		nodes := rg.GetNodes()
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+		for _, node := range nodes {
+			if owner, ok := nodeOwner[node]; ok {
+				errs = append(errs, fmt.Errorf("%w: node %d belongs to both rg %s and rg %s",
+					ErrRGInconsistent, node, owner, rgName))
+			} else {
+				nodeOwner[node] = rgName
+			}
+
+			// a duplicated node could be indexed to either of its rgs
+			if indexed, ok := rm.nodeToRG[node]; !ok || (indexed != rgName && indexed != nodeOwner[node]) {
+				errs = append(errs, fmt.Errorf("%w: node %d in rg %s, but indexed to rg %q",
+					ErrRGInconsistent, node, rgName, indexed))
+			}
+		}
+	}
+
+	indexedNodes := lo.Keys(rm.nodeToRG)
+	sort.Slice(indexedNodes, func(i, j int) bool { return indexedNodes[i] < indexedNodes[j] })
+	for _, node := range indexedNodes {
+		if _, ok := nodeOwner[node]; !ok {
+			errs = append(errs, fmt.Errorf("%w: node %d indexed to rg %s, but not in any rg",
+				ErrRGInconsistent, node, rm.nodeToRG[node]))
+		}
+	}
+
+	return errs
+}
+
+// compare rgs in memory with the stored ones, return rgs which differ in existence, capacity or nodes,
+// in name order. the read lock is held while reading store, so rgs won't change during the comparison
+func (rm *ResourceManager) DiffWithStore(ctx context.Context) ([]ResourceGroupDiff, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	rgs, err := rm.store.GetResourceGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored := lo.SliceToMap(rgs, func(rg *querypb.ResourceGroup) (string, *querypb.ResourceGroup) {
+		return rg.GetName(), rg
+	})
+
+	rgNames := lo.Union(lo.Keys(rm.groups), lo.Keys(stored))
+	sort.Strings(rgNames)
+	diffs := make([]ResourceGroupDiff, 0)
+	for _, rgName := range rgNames {
+		diff := ResourceGroupDiff{Name: rgName}
+		memoryNodes := typeutil.NewUniqueSet()
+		if rg, ok := rm.groups[rgName]; ok {
+			diff.InMemory = true
+			diff.MemoryCapacity = rg.GetCapacity()
+			memoryNodes.Insert(rg.GetNodes()...)
+		}
+		storedNodes := typeutil.NewUniqueSet()
+		if rg, ok := stored[rgName]; ok {
+			diff.InStore = true
+			diff.StoredCapacity = int(rg.GetCapacity())
+			storedNodes.Insert(rg.GetNodes()...)
+		}
+
+		for node := range memoryNodes {
+			if !storedNodes.Contain(node) {
+				diff.NodesMissingInStore = append(diff.NodesMissingInStore, node)
+			}
+		}
+		for node := range storedNodes {
+			if !memoryNodes.Contain(node) {
+				diff.NodesMissingInMemory = append(diff.NodesMissingInMemory, node)
+			}
+		}
+		if diff.InMemory && diff.InStore && diff.MemoryCapacity == diff.StoredCapacity &&
+			len(diff.NodesMissingInStore) == 0 && len(diff.NodesMissingInMemory) == 0 {
+			continue
+		}
+
+		sort.Slice(diff.NodesMissingInStore, func(i, j int) bool {
+			return diff.NodesMissingInStore[i] < diff.NodesMissingInStore[j]
+		})
+		sort.Slice(diff.NodesMissingInMemory, func(i, j int) bool {
+			return diff.NodesMissingInMemory[i] < diff.NodesMissingInMemory[j]
+		})
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// rebuild the node to resource group index from current group membership
+func (rm *ResourceManager) rebuildNodeIndex() {
+	rm.nodeToRG = make(map[int64]string)
+	for name, group := range rm.groups {
+		if group.overlap {
+			continue
+		}
+		for node := range group.nodes {
+			rm.nodeToRG[node] = name
+		}
+	}
+}
+
+// every mutation which involves nodes access, should check nodes status first.
+// it prunes down nodes and persists the result, so the write lock must be held;
+// read paths filter down nodes by getLiveNodes instead
+func (rm *ResourceManager) checkRGNodeStatus(ctx context.Context, rgName string) {
+	lackBefore := rm.groups[rgName].LackOfNodes()
+	removed := false
+	for _, node := range rm.groups[rgName].GetNodes() {
+		if rm.isNodeDown(node) {
+			log.Info("found node down, remove it",
+				zap.String("rgName", rgName),
+				zap.Int64("nodeID", node),
+			)
+
+			rm.groups[rgName].handleNodeDown(node)
+			rm.unindexNode(node, rgName)
+			rm.notify(ResourceGroupEvent{RGName: rgName, Type: NodeRemoved, Node: node})
+			rm.updateResourceGroupMetrics(rgName)
+			removed = true
+		}
+	}
+
+	// persist the pruned membership, otherwise down nodes come back after restart
+	if removed {
+		rm.checkLackTransition(rgName, lackBefore)
+		rm.savePrunedResourceGroup(ctx, rgName)
+	}
+}
+
+// whether node is gone from node manager, such node should be pruned from its rgs.
+// node whose removal is deferred by grace period is kept until the timer fires
+func (rm *ResourceManager) isNodeDown(node int64) bool {
+	if _, ok := rm.pendingNodeDown[node]; ok {
+		return false
+	}
+	return rm.nodeMgr.Get(node) == nil
+}
+
+// return nodes of rg excluding down ones without pruning them, which is safe under read lock.
+// node in grace period is excluded as well, it stays in rg but can't take new placement
+func (rm *ResourceManager) getLiveNodes(rgName string) []int64 {
+	return lo.Filter(rm.groups[rgName].GetNodes(), func(node int64, _ int) bool {
+		return rm.nodeMgr.Get(node) != nil
+	})
+}
+
+// return lack of nodes num of rg as if its down nodes have been pruned.
+// node in grace period still counts, so its slot isn't refilled before it's really removed
+func (rm *ResourceManager) getLackOfNodes(rgName string) int {
+	nodes := lo.Filter(rm.groups[rgName].GetNodes(), func(node int64, _ int) bool {
+		return !rm.isNodeDown(node)
+	})
+	return rm.groups[rgName].GetCapacity() - len(nodes)
+}
+
+// save rg after removing down nodes from it in memory.
+// failure only logs, since Recover prunes down nodes again
+func (rm *ResourceManager) savePrunedResourceGroup(ctx context.Context, rgName string) {
+	err := rm.saveResourceGroupsToStore(ctx, &querypb.ResourceGroup{
+		Name:     rgName,
+		Capacity: int32(rm.groups[rgName].GetCapacity()),
+		Nodes:    rm.groups[rgName].GetNodes(),
+	})
+	if err != nil {
+		log.Warn("failed to save resource group after removing down nodes",
+			zap.String("rgName", rgName),
+			zap.Error(err),
+		)
+	}
+}
+
+func (rm *ResourceManager) updateResourceGroupMetrics(rgNames ...string) {
+	for _, rgName := range rgNames {
+		rg := rm.groups[rgName]
+		if rg == nil {
+			continue
+		}
+		metrics.QueryCoordResourceGroupCapacity.WithLabelValues(rgName).Set(float64(rg.GetCapacity()))
+		metrics.QueryCoordResourceGroupNodeNum.WithLabelValues(rgName).Set(float64(len(rg.nodes)))
+		metrics.QueryCoordResourceGroupLackNodeNum.WithLabelValues(rgName).Set(float64(rg.LackOfNodes()))
+		metrics.QueryCoordResourceGroupChurnRate.WithLabelValues(rgName).Set(rg.churnRate())
+	}
+}
+
+func removeResourceGroupMetrics(rgName string) {
+	metrics.QueryCoordResourceGroupCapacity.DeleteLabelValues(rgName)
+	metrics.QueryCoordResourceGroupNodeNum.DeleteLabelValues(rgName)
+	metrics.QueryCoordResourceGroupLackNodeNum.DeleteLabelValues(rgName)
+	metrics.QueryCoordResourceGroupChurnRate.DeleteLabelValues(rgName)
+}
+
+// GetWeightedCapacity returns the total weight of the rg's alive nodes
+func (rm *ResourceManager) GetWeightedCapacity(rgName string) float64 {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	return rm.getWeightedCapacity(rgName)
+}
+
+func (rm *ResourceManager) getWeightedCapacity(rgName string) float64 {
+	rg, ok := rm.groups[rgName]
+	if !ok {
+		return 0
+	}
+
+	weight := 0.0
+	for _, node := range rg.GetNodes() {
+		if info := rm.nodeMgr.Get(node); info != nil {
+			weight += info.Weight()
+		}
+	}
+	return weight
+}
+
+// CheckWeightedLackOfNode returns the part of rg's capacity which isn't covered by
+// the weighted capacity of its nodes, it equals to CheckLackOfNode if all nodes have weight 1.0
+func (rm *ResourceManager) CheckWeightedLackOfNode(rgName string) float64 {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	if rm.groups[rgName] == nil {
+		return 0
+	}
+
+	lack := float64(rm.groups[rgName].GetCapacity()) - rm.getWeightedCapacity(rgName)
+	if lack < 0 {
+		return 0
+	}
+	return lack
+}
+
+// return lack of nodes num
+func (rm *ResourceManager) CheckLackOfNode(rgName string) int {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	if rm.groups[rgName] == nil {
+		return 0
+	}
+
+	rm.recordAccess(rgName)
+	return rm.getLackOfNodes(rgName)
+}
+
+// return lack of nodes num of all rgs except default rg, which takes the lock only once
+func (rm *ResourceManager) CheckLackOfNodeAll() map[string]int {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	ret := make(map[string]int, len(rm.groups))
+	for rgName := range rm.groups {
+		if rgName == rm.defaultRGName {
+			continue
+		}
+		ret[rgName] = rm.getLackOfNodes(rgName)
+	}
+	return ret
+}
+
+// GetDefaultSpareNodes returns how many nodes could be pulled from default rg,
+// which is its live node num excluding the reserved nodes
+func (rm *ResourceManager) GetDefaultSpareNodes() int {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+
+	spare := len(rm.getLiveNodes(rm.defaultRGName)) - params.Params.QueryCoordCfg.DefaultRGReservedNodeNum.GetAsInt()
+	if spare < 0 {
+		return 0
+	}
+	return spare
+}
+
+// count a lookup of existing rg
+func (rm *ResourceManager) recordAccess(rgName string) {
+	counter, ok := rm.accessStats.Load(rgName)
+	if !ok {
+		counter, _ = rm.accessStats.LoadOrStore(rgName, atomic.NewUint64(0))
+	}
+	counter.(*atomic.Uint64).Inc()
+}
+
+// GetAccessStats returns lookup num of each rg by GetNodes, GetResourceGroup, ContainsNode and CheckLackOfNode
+func (rm *ResourceManager) GetAccessStats() map[string]uint64 {
+	stats := make(map[string]uint64)
+	rm.accessStats.Range(func(key, value any) bool {
+		stats[key.(string)] = value.(*atomic.Uint64).Load()
+		return true
+	})
+	return stats
+}
+
+// GetNodeCountHistory returns recent node num samples of rg from the oldest to the latest,
+// the history starts over after Recover
+func (rm *ResourceManager) GetNodeCountHistory(rgName string) []NodeCountSample {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	if rm.groups[rgName] == nil {
+		return nil
+	}
+
+	return rm.groups[rgName].history.collect()
+}
+
+// GetCapacityUtilization returns the ratio of rg's alive node num to its capacity,
+// a rg with zero capacity is treated as 0 utilization
+func (rm *ResourceManager) GetCapacityUtilization(rgName string) (float64, error) {
+	rm.rwmutex.RLock()
+	defer rm.rwmutex.RUnlock()
+	if rm.groups[rgName] == nil {
+		return 0, &ResourceGroupNotFoundError{Name: rgName}
+	}
+
+	rg := rm.groups[rgName]
+	if rg.GetCapacity() <= 0 {
+		return 0, nil
+	}
+	return float64(len(rm.getLiveNodes(rgName))) / float64(rg.GetCapacity()), nil
 }
@@ -16,15 +16,33 @@
 package meta
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	etcdkv "github.com/milvus-io/milvus/internal/kv/etcd"
+	"github.com/milvus-io/milvus/internal/metrics"
 	"github.com/milvus-io/milvus/internal/proto/querypb"
 	. "github.com/milvus-io/milvus/internal/querycoordv2/params"
 	"github.com/milvus-io/milvus/internal/querycoordv2/session"
 	"github.com/milvus-io/milvus/internal/util/etcd"
 	"github.com/milvus-io/milvus/internal/util/typeutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.uber.org/atomic"
+	"go.uber.org/goleak"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type ResourceManagerSuite struct {
@@ -56,96 +74,920 @@ func (suite *ResourceManagerSuite) SetupTest() {
 }
 
 func (suite *ResourceManagerSuite) TestManipulateResourceGroup() {
+	ctx := context.Background()
 	// test add rg
-	err := suite.manager.AddResourceGroup("rg1")
+	err := suite.manager.AddResourceGroup(ctx, "rg1")
 	suite.NoError(err)
 	suite.True(suite.manager.ContainResourceGroup("rg1"))
 	suite.Len(suite.manager.ListResourceGroups(), 2)
 
 	// test add duplicate rg
-	err = suite.manager.AddResourceGroup("rg1")
+	err = suite.manager.AddResourceGroup(ctx, "rg1")
 	suite.ErrorIs(err, ErrRGAlreadyExist)
 	// test delete rg
-	err = suite.manager.RemoveResourceGroup("rg1")
+	err = suite.manager.RemoveResourceGroup(ctx, "rg1")
 	suite.NoError(err)
 
 	// test delete rg which doesn't exist
-	err = suite.manager.RemoveResourceGroup("rg1")
+	err = suite.manager.RemoveResourceGroup(ctx, "rg1")
 	suite.NoError(err)
 	// test delete default rg
-	err = suite.manager.RemoveResourceGroup(DefaultResourceGroupName)
+	err = suite.manager.RemoveResourceGroup(ctx, DefaultResourceGroupName)
 	suite.ErrorIs(ErrDeleteDefaultRG, err)
 }
 
+func (suite *ResourceManagerSuite) TestResourceGroupName() {
+	ctx := context.Background()
+	cases := []struct {
+		name  string
+		rg    string
+		valid bool
+	}{
+		{"letters", "rg", true},
+		{"alphanumerics", "Rg01", true},
+		{"underscore and hyphen", "rg_1-a", true},
+		{"max length", strings.Repeat("a", maxResourceGroupNameLength), true},
+		{"too long", strings.Repeat("a", maxResourceGroupNameLength+1), false},
+		{"slash", "rg/1", false},
+		{"space", "rg 1", false},
+		{"control character", "rg\n", false},
+		{"dot", "rg.1", false},
+		{"non ascii", "资源组", false},
+		{"default rg name", DefaultResourceGroupName, false},
+	}
+
+	for _, c := range cases {
+		suite.Run(c.name, func() {
+			err := suite.manager.AddResourceGroup(ctx, c.rg)
+			if c.valid {
+				suite.NoError(err)
+				suite.True(suite.manager.ContainResourceGroup(c.rg))
+			} else {
+				suite.ErrorIs(err, ErrRGNameInvalid)
+			}
+		})
+	}
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupLimit() {
+	ctx := context.Background()
+	suite.manager.maxResourceGroupNum = 3
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+
+	err := suite.manager.AddResourceGroup(ctx, "rg3")
+	suite.ErrorIs(err, ErrRGLimit)
+	suite.Contains(err.Error(), "3")
+	suite.False(suite.manager.ContainResourceGroup("rg3"))
+}
+
+func (suite *ResourceManagerSuite) TestRecoverResourceGroupLimit() {
+	ctx := context.Background()
+	for _, rgName := range []string{"rg3", "rg1", "rg2"} {
+		suite.NoError(suite.manager.AddResourceGroup(ctx, rgName))
+	}
+
+	// limit is lowered before restart, stored rgs are loaded anyway by default
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	manager.maxResourceGroupNum = 3
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]string{DefaultResourceGroupName, "rg1", "rg2", "rg3"}, manager.ListResourceGroups())
+
+	key := Params.QueryCoordCfg.RGRecoverRefuseOverLimit.Key
+	Params.BaseTable.Save(key, "true")
+	defer Params.BaseTable.Reset(key)
+	manager = NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	manager.maxResourceGroupNum = 3
+	err := manager.Recover(ctx)
+	suite.ErrorIs(err, ErrRGPartialRecovered)
+	suite.ErrorIs(err, ErrRGLimit)
+	suite.Contains(err.Error(), "rg3")
+	suite.ElementsMatch([]string{DefaultResourceGroupName, "rg1", "rg2"}, manager.ListResourceGroups())
+
+	// refused rg is kept in store
+	manager = NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.True(manager.ContainResourceGroup("rg3"))
+}
+
 func (suite *ResourceManagerSuite) TestManipulateNode() {
+	ctx := context.Background()
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
-	err := suite.manager.AddResourceGroup("rg1")
+	err := suite.manager.AddResourceGroup(ctx, "rg1")
 	suite.NoError(err)
 	// test add node to rg
-	err = suite.manager.AssignNode("rg1", 1)
+	err = suite.manager.AssignNode(ctx, "rg1", 1)
 	suite.NoError(err)
 
 	// test add non-exist node to rg
-	err = suite.manager.AssignNode("rg1", 2)
+	err = suite.manager.AssignNode(ctx, "rg1", 2)
 	suite.ErrorIs(err, ErrNodeNotExist)
 
 	// test add node to non-exist rg
-	err = suite.manager.AssignNode("rg2", 1)
+	err = suite.manager.AssignNode(ctx, "rg2", 1)
 	suite.ErrorIs(err, ErrRGNotExist)
 
 	// test remove node from rg
-	err = suite.manager.UnassignNode("rg1", 1)
+	err = suite.manager.UnassignNode(ctx, "rg1", 1)
 	suite.NoError(err)
 
 	// test remove non-exist node from rg
-	err = suite.manager.UnassignNode("rg1", 2)
+	err = suite.manager.UnassignNode(ctx, "rg1", 2)
 	suite.NoError(err)
 
 	// test remove node from non-exist rg
-	err = suite.manager.UnassignNode("rg2", 1)
+	err = suite.manager.UnassignNode(ctx, "rg2", 1)
 	suite.ErrorIs(err, ErrRGNotExist)
 
 	// add node which already assign to rg  to another rg
-	err = suite.manager.AddResourceGroup("rg2")
+	err = suite.manager.AddResourceGroup(ctx, "rg2")
 	suite.NoError(err)
-	err = suite.manager.AssignNode("rg1", 1)
+	err = suite.manager.AssignNode(ctx, "rg1", 1)
 	suite.NoError(err)
-	err = suite.manager.AssignNode("rg2", 1)
+	err = suite.manager.AssignNode(ctx, "rg2", 1)
 	println(err.Error())
 	suite.ErrorIs(err, ErrNodeAlreadyAssign)
+	var assignedErr *NodeAlreadyAssignedError
+	suite.ErrorAs(err, &assignedErr)
+	suite.Equal(int64(1), assignedErr.Node)
+	suite.Equal("rg1", assignedErr.CurrentRG)
+
+	// owner rg is retrievable when assign nodes in batch
+	err = suite.manager.AssignNodes(ctx, "rg2", []int64{1})
+	suite.ErrorIs(err, ErrNodeAlreadyAssign)
+	suite.ErrorAs(err, &assignedErr)
+	suite.Equal("rg1", assignedErr.CurrentRG)
+
+	// add node which already assign to rg to the same rg
+	err = suite.manager.AssignNode(ctx, "rg1", 1)
+	suite.NoError(err)
+	suite.Equal(1, suite.manager.groups["rg1"].GetCapacity())
+	suite.True(suite.manager.ContainsNode("rg1", 1))
 
-	// transfer node between rgs
-	err = suite.manager.TransferNode("rg1", "rg2")
+	// transfer node between rgs, moved node is returned
+	node, err := suite.manager.TransferNode(ctx, "rg1", "rg2")
 	suite.NoError(err)
+	suite.Equal(int64(1), node)
+	suite.True(suite.manager.ContainsNode("rg2", node))
+	suite.False(suite.manager.ContainsNode("rg1", node))
 
 	// transfer meet non exist rg
-	err = suite.manager.TransferNode("rgggg", "rg2")
+	_, err = suite.manager.TransferNode(ctx, "rgggg", "rg2")
+	suite.ErrorIs(err, ErrRGNotExist)
+}
+
+func (suite *ResourceManagerSuite) TestTransferNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 3))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 2))
+
+	// transfer more nodes than source rg has
+	err := suite.manager.TransferNodes(ctx, "rg1", "rg2", 4)
+	suite.ErrorIs(err, ErrNodeNotEnough)
+
+	err = suite.manager.TransferNodes(ctx, "rg1", "rg2", 2)
+	suite.NoError(err)
+	suite.ElementsMatch([]int64{3}, suite.manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{1, 2}, suite.manager.groups["rg2"].GetNodes())
+	suite.Equal(1, suite.manager.groups["rg1"].GetCapacity())
+	suite.Equal(2, suite.manager.groups["rg2"].GetCapacity())
+
+	// transfer from empty rg
+	err = suite.manager.TransferNodes(ctx, "rg1", "rg2", 1)
+	suite.NoError(err)
+	err = suite.manager.TransferNodes(ctx, "rg1", "rg2", 1)
+	suite.ErrorIs(err, ErrRGIsEmpty)
+
+	// both rgs should be saved in a single store write
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Times(3)
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg1").Return(&querypb.ResourceGroup{Name: "rg1"}, nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg2").Return(&querypb.ResourceGroup{Name: "rg2"}, nil)
+	manager.AddResourceGroup(ctx, "rg1")
+	manager.AddResourceGroup(ctx, "rg2")
+	store.EXPECT().SaveNodeResourceGroup(mock.Anything, int64(4), mock.Anything).Return(nil).Times(2)
+	manager.AssignNode(ctx, "rg1", 4)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	err = manager.TransferNodes(ctx, "rg1", "rg2", 1)
+	suite.NoError(err)
+	suite.True(manager.groups["rg2"].containsNode(4))
+}
+
+func (suite *ResourceManagerSuite) TestTransferAllNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 3))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 4))
+	// rg1 lacks one node
+	suite.manager.nodeMgr.Remove(3)
+
+	_, err := suite.manager.TransferAllNodes(ctx, "rg1", "rg3")
+	suite.ErrorIs(err, ErrRGNotExist)
+	_, err = suite.manager.TransferAllNodes(ctx, DefaultResourceGroupName, "rg1")
+	suite.ErrorIs(err, ErrRGNameInvalid)
+
+	num, err := suite.manager.TransferAllNodes(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.Equal(2, num)
+	suite.Empty(suite.manager.groups["rg1"].GetNodes())
+	suite.Equal(0, suite.manager.groups["rg1"].GetCapacity())
+	suite.ElementsMatch([]int64{1, 2, 4}, suite.manager.groups["rg2"].GetNodes())
+	suite.Equal(3, suite.manager.groups["rg2"].GetCapacity())
+	suite.Equal("rg2", suite.manager.nodeToRG[1])
+
+	// drain an empty rg
+	num, err = suite.manager.TransferAllNodes(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.Equal(0, num)
+
+	rgs, err := suite.manager.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	for _, rg := range rgs {
+		switch rg.GetName() {
+		case "rg1":
+			suite.EqualValues(0, rg.GetCapacity())
+			suite.Empty(rg.GetNodes())
+		case "rg2":
+			suite.EqualValues(3, rg.GetCapacity())
+			suite.ElementsMatch([]int64{1, 2, 4}, rg.GetNodes())
+		}
+	}
+
+	// both rgs should be saved in a single store write
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Times(3)
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg1").Return(&querypb.ResourceGroup{Name: "rg1"}, nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg2").Return(&querypb.ResourceGroup{Name: "rg2"}, nil)
+	store.EXPECT().SaveNodeResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(2)
+	suite.NoError(manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(manager.AssignNode(ctx, "rg1", 1))
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	num, err = manager.TransferAllNodes(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.Equal(1, num)
+}
+
+func (suite *ResourceManagerSuite) TestTransferNodesStoreFailure() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Times(4)
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg1").Return(&querypb.ResourceGroup{Name: "rg1"}, nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg2").Return(&querypb.ResourceGroup{Name: "rg2"}, nil)
+	store.EXPECT().SaveNodeResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(2)
+	suite.NoError(manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(manager.AssignNode(ctx, "rg1", 2))
+
+	storeErr := errors.New("failed to save")
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(storeErr)
+	checkUnchanged := func() {
+		suite.ElementsMatch([]int64{1, 2}, manager.groups["rg1"].GetNodes())
+		suite.Equal(2, manager.groups["rg1"].GetCapacity())
+		suite.Empty(manager.groups["rg2"].GetNodes())
+		suite.Equal(0, manager.groups["rg2"].GetCapacity())
+		suite.Equal("rg1", manager.nodeToRG[1])
+		suite.Equal("rg1", manager.nodeToRG[2])
+	}
+
+	suite.ErrorIs(manager.TransferNodes(ctx, "rg1", "rg2", 2), storeErr)
+	checkUnchanged()
+	suite.ErrorIs(manager.TransferSpecificNode(ctx, "rg1", "rg2", 1), storeErr)
+	checkUnchanged()
+	suite.ErrorIs(manager.RemoveAllNodes(ctx, "rg1"), storeErr)
+	checkUnchanged()
+}
+
+func (suite *ResourceManagerSuite) TestTransferNodeToSameResourceGroup() {
+	ctx := context.Background()
+	// no store write is expected
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	_, err := manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	_, err = manager.HandleNodeUp(ctx, 2)
+	suite.NoError(err)
+
+	node, err := manager.TransferNode(ctx, DefaultResourceGroupName, DefaultResourceGroupName)
+	suite.ErrorIs(err, ErrSameResourceGroup)
+	suite.Equal(int64(0), node)
+	err = manager.TransferNodes(ctx, DefaultResourceGroupName, DefaultResourceGroupName, 2)
+	suite.ErrorIs(err, ErrSameResourceGroup)
+
+	suite.ElementsMatch([]int64{1, 2}, manager.groups[DefaultResourceGroupName].GetNodes())
+	suite.Equal(defaultResourceGroupCapacity, manager.groups[DefaultResourceGroupName].GetCapacity())
+	store.AssertNotCalled(suite.T(), "SaveResourceGroup", mock.Anything, mock.Anything)
+}
+
+func (suite *ResourceManagerSuite) TestGetNodesByState() {
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{5, 4, 3, 2, 1}))
+
+	suite.manager.nodeMgr.Stopping(2)
+	suite.manager.nodeMgr.Stopping(4)
+	suite.manager.nodeMgr.Remove(5)
+
+	live, stopping, err := suite.manager.GetNodesByState("rg")
+	suite.NoError(err)
+	suite.Equal([]int64{1, 3}, live)
+	suite.Equal([]int64{2, 4}, stopping)
+	suite.False(suite.manager.ContainsNode("rg", 5))
+
+	live, stopping, err = suite.manager.GetNodesByState(DefaultResourceGroupName)
+	suite.NoError(err)
+	suite.Empty(live)
+	suite.Empty(stopping)
+
+	_, _, err = suite.manager.GetNodesByState("rg1")
+	suite.ErrorIs(err, ErrRGNotExist)
+}
+
+func (suite *ResourceManagerSuite) TestSwapNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg2", []int64{3}))
+	suite.NoError(suite.manager.SetResourceGroupCapacity(ctx, "rg2", 2))
+
+	suite.ErrorIs(suite.manager.SwapNodes(ctx, "rg1", 1, "rg3", 3), ErrRGNotExist)
+	suite.ErrorIs(suite.manager.SwapNodes(ctx, "rg1", 1, "rg1", 2), ErrSameResourceGroup)
+	suite.ErrorIs(suite.manager.SwapNodes(ctx, "rg1", 3, "rg2", 1), ErrNodeNotAssignToRG)
+	suite.ErrorIs(suite.manager.SwapNodes(ctx, "rg1", 1, "rg2", 4), ErrNodeNotAssignToRG)
+
+	suite.NoError(suite.manager.SwapNodes(ctx, "rg1", 1, "rg2", 3))
+	suite.ElementsMatch([]int64{2, 3}, suite.manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{1}, suite.manager.groups["rg2"].GetNodes())
+	suite.Equal(2, suite.manager.groups["rg1"].GetCapacity())
+	suite.Equal(2, suite.manager.groups["rg2"].GetCapacity())
+	rgName, err := suite.manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg2", rgName)
+	rgName, err = suite.manager.FindResourceGroupByNode(3)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
+	suite.Empty(suite.manager.CheckConsistency())
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]int64{2, 3}, manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{1}, manager.groups["rg2"].GetNodes())
+	suite.Equal(2, manager.groups["rg1"].GetCapacity())
+	suite.Equal(2, manager.groups["rg2"].GetCapacity())
+
+	// rg holding more nodes than its capacity, memory should still match the stored state
+	suite.NoError(suite.manager.SetResourceGroupLimit(ctx, "rg1", 1))
+	suite.NoError(suite.manager.SwapNodes(ctx, "rg1", 3, "rg2", 1))
+	suite.ElementsMatch([]int64{1, 2}, suite.manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{3}, suite.manager.groups["rg2"].GetNodes())
+	manager = NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]int64{1, 2}, manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{3}, manager.groups["rg2"].GetNodes())
+}
+
+func (suite *ResourceManagerSuite) TestTransferSpecificNode() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2, 3}))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 4))
+
+	err := suite.manager.TransferSpecificNode(ctx, "rg1", "rg3", 1)
+	suite.ErrorIs(err, ErrRGNotExist)
+
+	// node not in from rg
+	err = suite.manager.TransferSpecificNode(ctx, "rg1", "rg2", 4)
+	suite.ErrorIs(err, ErrNodeNotAssignToRG)
+	err = suite.manager.TransferSpecificNode(ctx, "rg1", "rg2", 5)
+	suite.ErrorIs(err, ErrNodeNotExist)
+
+	// node stopped
+	suite.manager.nodeMgr.Stopping(3)
+	err = suite.manager.TransferSpecificNode(ctx, "rg1", "rg2", 3)
+	suite.ErrorIs(err, ErrNodeStopped)
+	suite.True(suite.manager.ContainsNode("rg1", 3))
+
+	suite.NoError(suite.manager.TransferSpecificNode(ctx, "rg1", "rg2", 2))
+	suite.True(suite.manager.ContainsNode("rg1", 1))
+	suite.True(suite.manager.ContainsNode("rg2", 2))
+	suite.Equal(2, suite.manager.groups["rg1"].GetCapacity())
+	suite.Equal(2, suite.manager.groups["rg2"].GetCapacity())
+	rgName, err := suite.manager.FindResourceGroupByNode(2)
+	suite.NoError(err)
+	suite.Equal("rg2", rgName)
+
+	// both rgs should be saved in a single store write
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Times(3)
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg1").Return(&querypb.ResourceGroup{Name: "rg1"}, nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg2").Return(&querypb.ResourceGroup{Name: "rg2"}, nil)
+	store.EXPECT().SaveNodeResourceGroup(mock.Anything, int64(1), mock.Anything).Return(nil).Times(2)
+	manager.AddResourceGroup(ctx, "rg1")
+	manager.AddResourceGroup(ctx, "rg2")
+	manager.AssignNode(ctx, "rg1", 1)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	suite.NoError(manager.TransferSpecificNode(ctx, "rg1", "rg2", 1))
+	suite.True(manager.ContainsNode("rg2", 1))
+}
+
+func (suite *ResourceManagerSuite) TestMoveNode() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 2))
+
+	suite.ErrorIs(suite.manager.MoveNode(ctx, "rg3", 1), ErrRGNotExist)
+	suite.ErrorIs(suite.manager.MoveNode(ctx, "rg2", 4), ErrNodeNotExist)
+
+	// move from default rg
+	suite.NoError(suite.manager.MoveNode(ctx, "rg2", 1))
+	suite.True(suite.manager.ContainsNode("rg2", 1))
+	suite.False(suite.manager.ContainsNode(DefaultResourceGroupName, 1))
+	suite.Equal(1, suite.manager.groups["rg2"].GetCapacity())
+
+	// move from custom rg
+	suite.NoError(suite.manager.MoveNode(ctx, "rg2", 2))
+	suite.True(suite.manager.ContainsNode("rg2", 2))
+	suite.False(suite.manager.ContainsNode("rg1", 2))
+	suite.Equal(0, suite.manager.groups["rg1"].GetCapacity())
+	suite.Equal(2, suite.manager.groups["rg2"].GetCapacity())
+
+	// move unassigned node
+	suite.NoError(suite.manager.MoveNode(ctx, "rg1", 3))
+	suite.True(suite.manager.ContainsNode("rg1", 3))
+	suite.Equal(1, suite.manager.groups["rg1"].GetCapacity())
+
+	// move to current rg
+	suite.NoError(suite.manager.MoveNode(ctx, "rg1", 3))
+	suite.Equal(1, suite.manager.groups["rg1"].GetCapacity())
+
+	// both rgs should be saved in a single store write
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Times(3)
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg1").Return(&querypb.ResourceGroup{Name: "rg1"}, nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg2").Return(&querypb.ResourceGroup{Name: "rg2"}, nil)
+	store.EXPECT().SaveNodeResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(2)
+	suite.NoError(manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(manager.AssignNode(ctx, "rg1", 1))
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	suite.NoError(manager.MoveNode(ctx, "rg2", 1))
+	suite.True(manager.ContainsNode("rg2", 1))
+}
+
+func (suite *ResourceManagerSuite) TestAssignNodes() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 3))
+
+	// node 3 belongs to rg2 and node 4 doesn't exist, nothing should be assigned
+	err := suite.manager.AssignNodes(ctx, "rg1", []int64{1, 3, 4})
+	suite.Error(err)
+	suite.Contains(err.Error(), "node=3")
+	suite.Contains(err.Error(), "node=4")
+	suite.NotContains(err.Error(), "node=1")
+	suite.Equal(0, suite.manager.groups["rg1"].GetCapacity())
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 0)
+	suite.False(suite.manager.checkNodeAssigned(1))
+
+	// duplicate nodes in one request
+	err = suite.manager.AssignNodes(ctx, "rg1", []int64{1, 1})
+	suite.Error(err)
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 0)
+
+	err = suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2})
+	suite.NoError(err)
+	suite.Equal(2, suite.manager.groups["rg1"].GetCapacity())
+	suite.ElementsMatch([]int64{1, 2}, suite.manager.groups["rg1"].GetNodes())
+
+	err = suite.manager.AssignNodes(ctx, "rg3", []int64{1})
+	suite.ErrorIs(err, ErrRGNotExist)
+}
+
+func (suite *ResourceManagerSuite) TestRemoveAllNodes() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 3))
+	suite.manager.HandleNodeDown(ctx, 3)
+	suite.ErrorIs(suite.manager.RemoveResourceGroup(ctx, "rg"), ErrDeleteNonEmptyRG)
+
+	err := suite.manager.RemoveAllNodes(ctx, "rg")
+	suite.NoError(err)
+	suite.True(suite.manager.ContainsNode(DefaultResourceGroupName, 1))
+	suite.True(suite.manager.ContainsNode(DefaultResourceGroupName, 2))
+	rgName, err := suite.manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal(DefaultResourceGroupName, rgName)
+	suite.NoError(suite.manager.RemoveResourceGroup(ctx, "rg"))
+	suite.False(suite.manager.ContainResourceGroup("rg"))
+
+	suite.ErrorIs(suite.manager.RemoveAllNodes(ctx, "rg"), ErrRGNotExist)
+	suite.ErrorIs(suite.manager.RemoveAllNodes(ctx, DefaultResourceGroupName), ErrDeleteDefaultRG)
+}
+
+func (suite *ResourceManagerSuite) TestUnassignNonMemberNode() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Times(3)
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg1").Return(&querypb.ResourceGroup{Name: "rg1"}, nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg2").Return(&querypb.ResourceGroup{Name: "rg2"}, nil)
+	store.EXPECT().SaveNodeResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	suite.NoError(manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(manager.AssignNode(ctx, "rg1", 1))
+
+	// neither store write nor capacity shrinkage happens
+	suite.NoError(manager.UnassignNode(ctx, "rg2", 1))
+	suite.NoError(manager.UnassignNode(ctx, "rg1", 2))
+	suite.Equal(1, manager.groups["rg1"].GetCapacity())
+	suite.Equal(0, manager.groups["rg2"].GetCapacity())
+	suite.True(manager.ContainsNode("rg1", 1))
+	store.AssertNumberOfCalls(suite.T(), "SaveResourceGroup", 3)
+}
+
+func (suite *ResourceManagerSuite) TestCapacityUnderflow() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 2))
+
+	// break capacity in hack way
+	suite.manager.groups["rg1"].capacity = 0
+	suite.NoError(suite.manager.UnassignNode(ctx, "rg1", 1))
+	suite.Equal(0, suite.manager.groups["rg1"].GetCapacity())
+
+	_, err := suite.manager.TransferNode(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.Equal(0, suite.manager.groups["rg1"].GetCapacity())
+	suite.Equal(1, suite.manager.groups["rg2"].GetCapacity())
+
+	rgs, err := suite.manager.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	for _, rg := range rgs {
+		suite.GreaterOrEqual(rg.GetCapacity(), int32(0))
+	}
+}
+
+type reverseNodeSelector struct{}
+
+func (s *reverseNodeSelector) Select(candidates []int64, count int) []int64 {
+	ret := make([]int64, 0, count)
+	for i := len(candidates) - 1; i >= 0 && len(ret) < count; i-- {
+		ret = append(ret, candidates[i])
+	}
+	return ret
+}
+
+func (suite *ResourceManagerSuite) TestNodeSelector() {
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.manager.SetNodeSelector(&reverseNodeSelector{})
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2, 3}))
+
+	_, err := suite.manager.TransferNode(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.ElementsMatch([]int64{3}, suite.manager.groups["rg2"].GetNodes())
+
+	// auto recover should honor the selector too
+	suite.manager.HandleNodeDown(ctx, 3)
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{4, 5}))
+	_, _, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg2")
+	suite.NoError(err)
+	suite.ElementsMatch([]int64{5}, suite.manager.groups["rg2"].GetNodes())
+}
+
+type mockLoadProvider struct {
+	loads map[int64]float64
+}
+
+func (p *mockLoadProvider) NodeLoad(node int64) float64 {
+	return p.loads[node]
+}
+
+func (suite *ResourceManagerSuite) TestLoadProvider() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2, 3, 4}))
+
+	provider := &mockLoadProvider{loads: map[int64]float64{1: 10, 2: 3, 3: 0.5, 4: 3}}
+	suite.manager.SetLoadProvider(provider)
+	node, err := suite.manager.TransferNode(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.Equal(int64(3), node)
+
+	// the same load keeps the node order
+	node, err = suite.manager.TransferNode(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.Equal(int64(2), node)
+
+	// fall back to the first node without provider
+	suite.manager.SetLoadProvider(nil)
+	node, err = suite.manager.TransferNode(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.Equal(int64(1), node)
+}
+
+type mockTopologyProvider struct {
+	racks map[int64]string
+}
+
+func (p *mockTopologyProvider) Rack(node int64) string {
+	return p.racks[node]
+}
+
+func (suite *ResourceManagerSuite) TestTopologyProvider() {
+	ctx := context.Background()
+	for i := 1; i <= 6; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{1, 2, 3, 4, 5, 6}))
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg1", 3))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+
+	provider := &mockTopologyProvider{racks: map[int64]string{1: "a", 2: "a", 3: "a", 4: "b", 5: "b", 6: "c"}}
+	suite.manager.SetTopologyProvider(provider)
+	num, _, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg1")
+	suite.NoError(err)
+	suite.Equal(3, num)
+	suite.ElementsMatch([]int64{1, 4, 6}, suite.manager.groups["rg1"].GetNodes())
+
+	suite.NoError(suite.manager.TransferNodes(ctx, DefaultResourceGroupName, "rg2", 2))
+	suite.ElementsMatch([]int64{2, 5}, suite.manager.groups["rg2"].GetNodes())
+
+	// fall back to the selector order without provider
+	suite.manager.SetTopologyProvider(nil)
+	suite.NoError(suite.manager.TransferNodes(ctx, "rg1", "rg2", 2))
+	suite.ElementsMatch([]int64{1, 2, 4, 5}, suite.manager.groups["rg2"].GetNodes())
+}
+
+type mockSegmentProvider struct {
+	segments map[int64]int
+}
+
+func (p *mockSegmentProvider) NodeSegmentNum(node int64) int {
+	return p.segments[node]
+}
+
+func (suite *ResourceManagerSuite) TestTransferNodeFromAllDownRG() {
+	ctx := context.Background()
+	for i := 1; i <= 2; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+
+	// nodes are down but not handled yet, so the source rg only turns empty after status check
+	suite.manager.nodeMgr.Remove(1)
+	suite.manager.nodeMgr.Remove(2)
+	suite.NotPanics(func() {
+		node, err := suite.manager.TransferNode(ctx, "rg1", "rg2")
+		suite.ErrorIs(err, ErrRGIsEmpty)
+		suite.Equal(int64(0), node)
+	})
+	suite.Empty(suite.manager.groups["rg1"].GetNodes())
+	suite.Empty(suite.manager.groups["rg2"].GetNodes())
+
+	err := suite.manager.TransferNodes(ctx, "rg1", "rg2", 2)
+	suite.ErrorIs(err, ErrRGIsEmpty)
+}
+
+func (suite *ResourceManagerSuite) TestTransferPlan() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AddResourceGroupWithLimit(ctx, "rg3", 1))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 3))
+
+	// valid plan, validation doesn't apply it
+	plan := []NodeMove{
+		{Node: 1, From: "rg1", To: "rg2"},
+		{Node: 3, From: "rg2", To: "rg3"},
+	}
+	snapshot := suite.manager.Snapshot()
+	suite.NoError(suite.manager.ValidateTransferPlan(plan))
+	suite.Equal(snapshot, suite.manager.Snapshot())
+
+	// invalid plans, nothing is applied or written
+	cases := []struct {
+		name  string
+		moves []NodeMove
+		err   error
+	}{
+		{"move node twice", []NodeMove{{Node: 1, From: "rg1", To: "rg2"}, {Node: 1, From: "rg2", To: "rg3"}}, ErrTransferPlanInvalid},
+		{"exceed max capacity", []NodeMove{{Node: 1, From: "rg1", To: "rg3"}, {Node: 2, From: "rg1", To: "rg3"}}, ErrRGCapacityExceeded},
+		{"source doesn't own node", []NodeMove{{Node: 4, From: "rg1", To: "rg2"}}, ErrNodeNotAssignToRG},
+		{"same rg", []NodeMove{{Node: 1, From: "rg1", To: "rg1"}}, ErrSameResourceGroup},
+		{"rg not exist", []NodeMove{{Node: 1, From: "rg1", To: "rg4"}}, ErrRGNotExist},
+	}
+	store := suite.manager.store
+	suite.manager.store = NewMockStore(suite.T())
+	for _, c := range cases {
+		suite.ErrorIs(suite.manager.ValidateTransferPlan(c.moves), c.err, c.name)
+		suite.ErrorIs(suite.manager.ApplyTransferPlan(ctx, c.moves), c.err, c.name)
+	}
+	suite.Equal(snapshot, suite.manager.Snapshot())
+
+	// all involved rgs are saved in one store write
+	mockStore := NewMockStore(suite.T())
+	mockStore.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, rgs ...*querypb.ResourceGroup) {
+			suite.NoError(store.SaveResourceGroup(ctx, rgs...))
+		}).Return(nil).Once()
+	mockStore.EXPECT().SaveNodeResourceGroup(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, node int64, rgName string) {
+			suite.NoError(store.SaveNodeResourceGroup(ctx, node, rgName))
+		}).Return(nil)
+	suite.manager.store = mockStore
+	suite.NoError(suite.manager.ApplyTransferPlan(ctx, plan))
+	suite.manager.store = store
+	suite.ElementsMatch([]int64{2}, suite.manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{1}, suite.manager.groups["rg2"].GetNodes())
+	suite.ElementsMatch([]int64{3}, suite.manager.groups["rg3"].GetNodes())
+	suite.Empty(suite.manager.CheckConsistency())
+
+	// moves are checked against the state after whole plan, so swapping into a full rg is allowed
+	suite.NoError(suite.manager.ApplyTransferPlan(ctx, []NodeMove{
+		{Node: 3, From: "rg3", To: "rg2"},
+		{Node: 2, From: "rg1", To: "rg3"},
+	}))
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Empty(manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{1, 3}, manager.groups["rg2"].GetNodes())
+	suite.ElementsMatch([]int64{2}, manager.groups["rg3"].GetNodes())
+	suite.Equal(0, manager.groups["rg1"].GetCapacity())
+	suite.Equal(2, manager.groups["rg2"].GetCapacity())
+	suite.Equal(1, manager.groups["rg3"].GetCapacity())
+}
+
+func (suite *ResourceManagerSuite) TestEstimateTransferCost() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2, 3, 4}))
+
+	_, err := suite.manager.EstimateTransferCost("rg1", "rg2", 1)
+	suite.ErrorIs(err, ErrSegmentProviderNotSet)
+
+	suite.manager.SetSegmentProvider(&mockSegmentProvider{segments: map[int64]int{1: 10, 2: 3, 3: 5, 4: 7}})
+	_, err = suite.manager.EstimateTransferCost("rg1", "rg3", 1)
 	suite.ErrorIs(err, ErrRGNotExist)
+	_, err = suite.manager.EstimateTransferCost("rg1", "rg1", 1)
+	suite.ErrorIs(err, ErrSameResourceGroup)
+	_, err = suite.manager.EstimateTransferCost("rg1", "rg2", 5)
+	suite.ErrorIs(err, ErrNodeNotEnough)
+
+	snapshot := suite.manager.Snapshot()
+	cost, err := suite.manager.EstimateTransferCost("rg1", "rg2", 2)
+	suite.NoError(err)
+	suite.Equal([]int64{1, 2}, cost.Nodes)
+	suite.Equal(13, cost.SegmentNum)
+	suite.Equal(map[int64]int{1: 10, 2: 3}, cost.NodeSegmentNum)
+	suite.Equal(snapshot, suite.manager.Snapshot())
+
+	// estimate follows the node selector, and matches the real transfer
+	suite.manager.SetLoadProvider(&mockLoadProvider{loads: map[int64]float64{1: 4, 2: 3, 3: 1, 4: 2}})
+	cost, err = suite.manager.EstimateTransferCost("rg1", "rg2", 2)
+	suite.NoError(err)
+	suite.Equal([]int64{3, 4}, cost.Nodes)
+	suite.Equal(12, cost.SegmentNum)
+	suite.NoError(suite.manager.TransferNodes(ctx, "rg1", "rg2", 2))
+	suite.ElementsMatch(cost.Nodes, suite.manager.groups["rg2"].GetNodes())
+}
+
+func (suite *ResourceManagerSuite) TestAssignNodeAlreadyInMemory() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Once()
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg1").Return(&querypb.ResourceGroup{Name: "rg1"}, nil)
+	store.EXPECT().SaveNodeResourceGroup(mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.NoError(manager.AddResourceGroup(ctx, "rg1"))
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Once()
+	suite.NoError(manager.AssignNode(ctx, "rg1", 1))
+
+	// the node shows up in memory right after store write, so the in-memory assign reports already assigned
+	var stored *querypb.ResourceGroup
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Run(func(ctx context.Context, rgs ...*querypb.ResourceGroup) {
+		stored = rgs[0]
+		manager.groups["rg1"].nodes.Insert(2)
+	}).Return(nil).Once()
+	suite.NoError(manager.AssignNode(ctx, "rg1", 2))
+
+	suite.ElementsMatch(stored.GetNodes(), manager.groups["rg1"].GetNodes())
+	suite.Equal(int(stored.GetCapacity()), manager.groups["rg1"].GetCapacity())
+	suite.Equal(2, manager.groups["rg1"].GetCapacity())
+	suite.Equal(0, manager.groups["rg1"].LackOfNodes())
+	rgName, err := manager.FindResourceGroupByNode(2)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
 }
 
 func (suite *ResourceManagerSuite) TestHandleNodeUp() {
+	ctx := context.Background()
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(100, "localhost"))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(101, "localhost"))
-	err := suite.manager.AddResourceGroup("rg1")
+	err := suite.manager.AddResourceGroup(ctx, "rg1")
 	suite.NoError(err)
 
-	suite.manager.AssignNode("rg1", 1)
-	suite.manager.AssignNode("rg1", 2)
-	suite.manager.AssignNode("rg1", 3)
+	suite.manager.AssignNode(ctx, "rg1", 1)
+	suite.manager.AssignNode(ctx, "rg1", 2)
+	suite.manager.AssignNode(ctx, "rg1", 3)
 
 	// test query node id not change, expect assign back to origin rg
 	rg, err := suite.manager.GetResourceGroup("rg1")
 	suite.NoError(err)
 	suite.Equal(rg.GetCapacity(), 3)
 	suite.Equal(len(rg.GetNodes()), 3)
-	suite.manager.HandleNodeUp(1)
+	suite.manager.HandleNodeUp(ctx, 1)
 	suite.Equal(rg.GetCapacity(), 3)
 	suite.Equal(len(rg.GetNodes()), 3)
 
-	suite.manager.HandleNodeDown(2)
+	suite.manager.HandleNodeDown(ctx, 2)
 	rg, err = suite.manager.GetResourceGroup("rg1")
 	suite.NoError(err)
 	suite.Equal(rg.GetCapacity(), 3)
@@ -154,7 +996,7 @@ func (suite *ResourceManagerSuite) TestHandleNodeUp() {
 	defaultRG, err := suite.manager.GetResourceGroup(DefaultResourceGroupName)
 	suite.NoError(err)
 	oldNodesNum := len(defaultRG.GetNodes())
-	suite.manager.HandleNodeUp(101)
+	suite.manager.HandleNodeUp(ctx, 101)
 	rg, err = suite.manager.GetResourceGroup("rg1")
 	suite.NoError(err)
 	suite.Equal(rg.GetCapacity(), 3)
@@ -165,124 +1007,3204 @@ func (suite *ResourceManagerSuite) TestHandleNodeUp() {
 	suite.Equal(len(nodes), oldNodesNum+1)
 }
 
-func (suite *ResourceManagerSuite) TestRecover() {
-	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
-	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
-	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
-	err := suite.manager.AddResourceGroup("rg")
-	suite.NoError(err)
-
-	suite.manager.AssignNode("rg", 1)
-	suite.manager.AssignNode("rg", 2)
-	suite.manager.AssignNode("rg", 3)
+func (suite *ResourceManagerSuite) TestHandleNodeUpAutoPlacement() {
+	ctx := context.Background()
+	for i := 1; i <= 6; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.SetResourceGroupCapacity(ctx, "rg1", 1))
+	suite.NoError(suite.manager.SetResourceGroupCapacity(ctx, "rg2", 3))
 
-	suite.manager.UnassignNode("rg", 3)
+	// disabled by default
+	rgName, err := suite.manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	suite.Equal(DefaultResourceGroupName, rgName)
 
-	// clear resource manager in hack way
-	delete(suite.manager.groups, "rg")
-	delete(suite.manager.groups, DefaultResourceGroupName)
-	suite.manager.Recover()
+	key := Params.QueryCoordCfg.RGNodeUpAutoPlacement.Key
+	Params.BaseTable.Save(key, "true")
+	defer Params.BaseTable.Reset(key)
+	// most lacking rg first, tie broken by rg name, default rg when all rgs are satisfied
+	for _, c := range []struct {
+		node     int64
+		expected string
+	}{
+		{2, "rg2"},
+		{3, "rg2"},
+		{4, "rg1"},
+		{5, "rg2"},
+		{6, DefaultResourceGroupName},
+	} {
+		rgName, err := suite.manager.HandleNodeUp(ctx, c.node)
+		suite.NoError(err)
+		suite.Equal(c.expected, rgName)
+		suite.True(suite.manager.ContainsNode(c.expected, c.node))
+	}
+	suite.Equal(0, suite.manager.groups["rg1"].LackOfNodes())
+	suite.Equal(0, suite.manager.groups["rg2"].LackOfNodes())
 
-	rg, err := suite.manager.GetResourceGroup("rg")
+	// placed node goes back to the rg after restart
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	rgName, err = manager.HandleNodeUp(ctx, 4)
 	suite.NoError(err)
-	suite.Equal(2, rg.GetCapacity())
-	suite.True(suite.manager.ContainsNode("rg", 1))
-	suite.True(suite.manager.ContainsNode("rg", 2))
-	suite.False(suite.manager.ContainsNode("rg", 3))
+	suite.Equal("rg1", rgName)
 }
 
-func (suite *ResourceManagerSuite) TestCheckOutboundNodes() {
+func (suite *ResourceManagerSuite) TestHandleNodeUpToPreviousRG() {
+	ctx := context.Background()
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
-	err := suite.manager.AddResourceGroup("rg")
-	suite.NoError(err)
-	suite.manager.AssignNode("rg", 1)
-	suite.manager.AssignNode("rg", 2)
-	suite.manager.AssignNode("rg", 3)
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 3))
 
-	replica := NewReplica(
-		&querypb.Replica{
-			ID:            1,
-			CollectionID:  1,
-			Nodes:         []int64{1, 2, 3, 4},
-			ResourceGroup: "rg",
-		},
-		typeutil.NewUniqueSet(1, 2, 3, 4),
-	)
+	// node restart, expect assign back to previous rg
+	suite.manager.HandleNodeDown(ctx, 1)
+	rgName, err := suite.manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
 
-	outboundNodes := suite.manager.CheckOutboundNodes(replica)
-	suite.Len(outboundNodes, 1)
-	suite.True(outboundNodes.Contain(4))
-}
+	// previous rg is full, expect assign to default rg
+	suite.manager.HandleNodeDown(ctx, 3)
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(4, "localhost"))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 4))
+	recovered, _, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg2")
+	suite.NoError(err)
+	suite.Equal(1, recovered)
+	rgName, err = suite.manager.HandleNodeUp(ctx, 3)
+	suite.NoError(err)
+	suite.Equal(DefaultResourceGroupName, rgName)
 
-func (suite *ResourceManagerSuite) TestCheckResourceGroup() {
-	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	// node restart during query coord restart, expect assign back to previous rg
+	suite.manager.nodeMgr.Remove(2)
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.False(manager.ContainsNode("rg1", 2))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
-	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
-	err := suite.manager.AddResourceGroup("rg")
+	rgName, err = manager.HandleNodeUp(ctx, 2)
 	suite.NoError(err)
-	suite.manager.AssignNode("rg", 1)
-	suite.manager.AssignNode("rg", 2)
-	suite.manager.AssignNode("rg", 3)
+	suite.Equal("rg1", rgName)
 
-	suite.manager.HandleNodeDown(1)
-	lackNodes := suite.manager.CheckLackOfNode("rg")
-	suite.Equal(lackNodes, 1)
+	// node moved back to default rg, expect it stays in default rg after restart
+	_, err = manager.TransferNode(ctx, "rg1", DefaultResourceGroupName)
+	suite.NoError(err)
+	for _, node := range []int64{1, 2} {
+		if manager.ContainsNode(DefaultResourceGroupName, node) {
+			manager.HandleNodeDown(ctx, node)
+			rgName, err = manager.HandleNodeUp(ctx, node)
+			suite.NoError(err)
+			suite.Equal(DefaultResourceGroupName, rgName)
+		}
+	}
+}
 
-	suite.manager.nodeMgr.Remove(2)
-	suite.manager.checkRGNodeStatus("rg")
-	lackNodes = suite.manager.CheckLackOfNode("rg")
-	suite.Equal(lackNodes, 2)
+func (suite *ResourceManagerSuite) TestHandleNodeUpToPreviousRGPersisted() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2, 3}))
 
-	rg, err := suite.manager.FindResourceGroupByNode(3)
+	// node put back to its previous rg survives restart
+	_, err := suite.manager.HandleNodeDown(ctx, 1)
 	suite.NoError(err)
-	suite.Equal(rg, "rg")
+	rgName, err := suite.manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]int64{1, 2, 3}, manager.groups["rg"].GetNodes())
+
+	// nothing changes if store write fails
+	_, err = suite.manager.HandleNodeDown(ctx, 2)
+	suite.NoError(err)
+	store := suite.manager.store
+	mockStore := NewMockStore(suite.T())
+	mockStore.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(errors.New("mock error"))
+	suite.manager.store = mockStore
+	_, err = suite.manager.HandleNodeUp(ctx, 2)
+	suite.Error(err)
+	suite.manager.store = store
+	suite.ElementsMatch([]int64{1, 3}, suite.manager.groups["rg"].GetNodes())
+	_, err = suite.manager.FindResourceGroupByNode(2)
+	suite.ErrorIs(err, ErrNodeNotAssignToRG)
+
+	// sealed rg doesn't take node back
+	suite.NoError(suite.manager.SealResourceGroup(ctx, "rg", true))
+	rgName, err = suite.manager.HandleNodeUp(ctx, 2)
+	suite.NoError(err)
+	suite.Equal(DefaultResourceGroupName, rgName)
+	suite.ElementsMatch([]int64{1, 3}, suite.manager.groups["rg"].GetNodes())
 }
 
-func (suite *ResourceManagerSuite) TestGetOutboundNode() {
-	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
-	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
-	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
-	suite.manager.AddResourceGroup("rg")
-	suite.manager.AddResourceGroup("rg1")
-	suite.manager.AssignNode("rg", 1)
-	suite.manager.AssignNode("rg", 2)
-	suite.manager.AssignNode("rg1", 3)
+func (suite *ResourceManagerSuite) TestHandleNodeDownPersisted() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 3))
 
-	replica := NewReplica(
-		&querypb.Replica{
-			ID:            1,
-			CollectionID:  100,
-			ResourceGroup: "rg",
-			Nodes:         []int64{1, 2, 3},
-		},
-		typeutil.NewUniqueSet(1, 2, 3),
-	)
+	rgName, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	rgName, err = suite.manager.HandleNodeDown(ctx, 3)
+	suite.NoError(err)
+	suite.Equal(DefaultResourceGroupName, rgName)
 
-	outgoingNodes := suite.manager.GetOutgoingNodeNumByReplica(replica)
-	suite.NotNil(outgoingNodes)
-	suite.Len(outgoingNodes, 1)
-	suite.NotNil(outgoingNodes["rg1"])
-	suite.Equal(outgoingNodes["rg1"], int32(1))
+	// nodes are still alive in node manager, only the stored state keeps them out
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.False(manager.ContainsNode("rg", 1))
+	suite.True(manager.ContainsNode("rg", 2))
+	suite.Equal(2, manager.groups["rg"].GetCapacity())
+	suite.False(manager.ContainsNode(DefaultResourceGroupName, 3))
+}
+
+func (suite *ResourceManagerSuite) TestHandleNodeDownPersistedAfterNodeRemoved() {
+	ctx := context.Background()
+	for i := 1; i <= 2; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2}))
+
+	// node is removed from node manager first, as the session delete event does
+	suite.manager.nodeMgr.Remove(1)
+	rgName, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	suite.False(suite.manager.ContainsNode("rg", 1))
+
+	// node restarts before coordinator, it shouldn't be resurrected in rg
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.False(manager.ContainsNode("rg", 1))
+	suite.True(manager.ContainsNode("rg", 2))
+	suite.Equal(2, manager.groups["rg"].GetCapacity())
+}
+
+func (suite *ResourceManagerSuite) TestHandleNodeDownGracePeriod() {
+	ctx := context.Background()
+	key := Params.QueryCoordCfg.RGNodeDownGracePeriod.Key
+	Params.BaseTable.Save(key, "100")
+	defer Params.BaseTable.Reset(key)
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2, 3}))
+	events, cancel := suite.manager.Subscribe()
+	defer cancel()
+
+	// node flaps within grace period
+	rgName, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	suite.True(suite.manager.ContainsNode("rg", 1))
+	rgName, err = suite.manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	time.Sleep(200 * time.Millisecond)
+	suite.True(suite.manager.ContainsNode("rg", 1))
+	suite.Empty(events)
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]int64{1, 2, 3}, manager.groups["rg"].GetNodes())
+
+	// node keeps down after grace period
+	_, err = suite.manager.HandleNodeDown(ctx, 2)
+	suite.NoError(err)
+	suite.Eventually(func() bool {
+		return !suite.manager.ContainsNode("rg", 2)
+	}, time.Second, 10*time.Millisecond)
+	manager = NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]int64{1, 3}, manager.groups["rg"].GetNodes())
+}
+
+func (suite *ResourceManagerSuite) TestHandleNodeDownGracePeriodAfterNodeRemoved() {
+	ctx := context.Background()
+	key := Params.QueryCoordCfg.RGNodeDownGracePeriod.Key
+	Params.BaseTable.Save(key, "100")
+	defer Params.BaseTable.Reset(key)
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2, 3}))
+
+	// node is removed from node manager first, as the session delete event does
+	suite.manager.nodeMgr.Remove(1)
+	rgName, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	// node stays in rg within grace period, but isn't offered to placement
+	rgName, err = suite.manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	suite.False(suite.manager.ContainsNode("rg", 1))
+	nodes, err := suite.manager.GetNodes("rg")
+	suite.NoError(err)
+	suite.ElementsMatch([]int64{2, 3}, nodes)
+	suite.ElementsMatch([]int64{2, 3}, suite.manager.Snapshot()["rg"].Nodes)
+	suite.ElementsMatch([]int64{2, 3}, suite.manager.ListResourceGroupsDetailed()["rg"])
+	suite.Equal(0, suite.manager.CheckLackOfNode("rg"))
+	// mutations don't prune the node within grace period
+	_, _, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	rgName, err = suite.manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	rgName, err = suite.manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	time.Sleep(200 * time.Millisecond)
+	suite.True(suite.manager.ContainsNode("rg", 1))
+
+	// node keeps down after grace period
+	suite.manager.nodeMgr.Remove(2)
+	_, err = suite.manager.HandleNodeDown(ctx, 2)
+	suite.NoError(err)
+	suite.False(suite.manager.ContainsNode("rg", 2))
+	suite.Eventually(func() bool {
+		_, err := suite.manager.FindResourceGroupByNode(2)
+		return errors.Is(err, ErrNodeNotAssignToRG)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *ResourceManagerSuite) TestClose() {
+	defer goleak.VerifyNone(suite.T(), goleak.IgnoreCurrent())
+	ctx := context.Background()
+	key := Params.QueryCoordCfg.RGNodeDownGracePeriod.Key
+	Params.BaseTable.Save(key, "60000")
+	defer Params.BaseTable.Reset(key)
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2, 3}))
+	events, cancel := suite.manager.Subscribe()
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- suite.manager.WaitForCapacity(ctx, "rg", 4)
+	}()
+
+	_, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+	_, err = suite.manager.HandleNodeDown(ctx, 2)
+	suite.NoError(err)
+	suite.True(suite.manager.ContainsNode("rg", 1))
+	suite.Len(suite.manager.pendingNodeDown, 2)
+	listened := atomic.NewBool(false)
+	suite.manager.OnLack(func(rgName string, lack int) {
+		time.Sleep(100 * time.Millisecond)
+		listened.Store(true)
+	})
+
+	// pending removals are flushed instead of waiting for grace period, and close waits for lack listeners
+	suite.NoError(suite.manager.Close(ctx))
+	suite.True(listened.Load())
+	suite.Empty(suite.manager.pendingNodeDown)
+	suite.ElementsMatch([]int64{3}, suite.manager.groups["rg"].GetNodes())
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]int64{3}, manager.groups["rg"].GetNodes())
+
+	// subscription channels are closed after the buffered events are drained
+	received := 0
+	for range events {
+		received++
+	}
+	suite.Equal(2, received)
+	cancel()
+	suite.ErrorIs(<-waitErr, ErrManagerClosed)
+	newEvents, newCancel := suite.manager.Subscribe()
+	_, ok := <-newEvents
+	suite.False(ok)
+	newCancel()
+
+	// removal isn't deferred after close
+	_, err = suite.manager.HandleNodeDown(ctx, 3)
+	suite.NoError(err)
+	suite.Empty(suite.manager.groups["rg"].GetNodes())
+	suite.NoError(suite.manager.Close(ctx))
+}
+
+func (suite *ResourceManagerSuite) TestCloseWithCancelledContext() {
+	ctx := context.Background()
+	key := Params.QueryCoordCfg.RGNodeDownGracePeriod.Key
+	Params.BaseTable.Save(key, "60000")
+	defer Params.BaseTable.Reset(key)
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	_, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	suite.ErrorIs(suite.manager.Close(cancelledCtx), context.Canceled)
+	suite.Empty(suite.manager.pendingNodeDown)
+	suite.True(suite.manager.ContainsNode("rg", 1))
+}
+
+func (suite *ResourceManagerSuite) TestGetResourceGroupCapacity() {
+	ctx := context.Background()
+	for i := 1; i <= 10; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	_, err := suite.manager.GetResourceGroupCapacity("rg")
+	suite.ErrorIs(err, ErrRGNotExist)
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg", 2))
+	capacity, err := suite.manager.GetResourceGroupCapacity("rg")
+	suite.NoError(err)
+	suite.Equal(2, capacity)
+
+	// read capacity while nodes are being assigned
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 10; i++ {
+			suite.NoError(suite.manager.AssignNode(ctx, "rg", int64(i)))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		last := 2
+		for i := 0; i < 100; i++ {
+			capacity, err := suite.manager.GetResourceGroupCapacity("rg")
+			suite.NoError(err)
+			suite.GreaterOrEqual(capacity, last)
+			last = capacity
+		}
+	}()
+	wg.Wait()
+
+	capacity, err = suite.manager.GetResourceGroupCapacity("rg")
+	suite.NoError(err)
+	suite.Equal(12, capacity)
+}
+
+func (suite *ResourceManagerSuite) TestGetResourceGroupSnapshot() {
+	ctx := context.Background()
+	for i := 1; i <= 100; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	_, err := suite.manager.GetResourceGroup("rg")
+	suite.ErrorIs(err, ErrRGNotExist)
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	rg, err := suite.manager.GetResourceGroup("rg")
+	suite.NoError(err)
+
+	// the returned snapshot is read while nodes are being assigned
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 2; i <= 100; i++ {
+			suite.NoError(suite.manager.AssignNode(ctx, "rg", int64(i)))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		suite.Equal(1, rg.GetCapacity())
+		suite.Equal([]int64{1}, rg.GetNodes())
+		suite.Equal(0, rg.LackOfNodes())
+	}
+	wg.Wait()
+
+	// mutating the snapshot doesn't affect rg
+	rg.Nodes[0] = 1000
+	rg, err = suite.manager.GetResourceGroup("rg")
+	suite.NoError(err)
+	suite.Equal(100, rg.GetCapacity())
+	suite.Len(rg.GetNodes(), 100)
+	suite.Equal(int64(1), rg.GetNodes()[0])
+	suite.True(suite.manager.ContainsNode("rg", 1))
+}
+
+func (suite *ResourceManagerSuite) TestDescribeResourceGroup() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	_, err := suite.manager.DescribeResourceGroup("rg")
+	suite.ErrorIs(err, ErrRGNotExist)
+
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{3, 1, 2}))
+	suite.NoError(suite.manager.SetResourceGroupLabels(ctx, "rg", map[string]string{"zone": "a"}))
+	suite.manager.HandleNodeDown(ctx, 2)
+
+	info, err := suite.manager.DescribeResourceGroup("rg")
+	suite.NoError(err)
+	suite.Equal(ResourceGroupInfo{
+		Name:     "rg",
+		Capacity: 3,
+		Nodes:    []int64{1, 3},
+		Lacking:  1,
+		Labels:   map[string]string{"zone": "a"},
+	}, info)
+
+	// the returned nodes are a copy
+	info.Nodes[0] = 1000
+	suite.True(suite.manager.ContainsNode("rg", 1))
+
+	info.Nodes[0] = 1
+	data, err := json.Marshal(info)
+	suite.NoError(err)
+	suite.JSONEq(`{"name":"rg","capacity":3,"nodes":[1,3],"lacking":1,"labels":{"zone":"a"}}`, string(data))
+	var decoded ResourceGroupInfo
+	suite.NoError(json.Unmarshal(data, &decoded))
+	suite.Equal(info, decoded)
+
+	// labels are omitted if not set
+	info, err = suite.manager.DescribeResourceGroup(DefaultResourceGroupName)
+	suite.NoError(err)
+	data, err = json.Marshal(info)
+	suite.NoError(err)
+	suite.NotContains(string(data), "labels")
+}
+
+func (suite *ResourceManagerSuite) TestAuditLog() {
+	ctx := WithAuditActor(context.Background(), "admin")
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	core, logs := observer.New(zap.InfoLevel)
+	suite.manager.SetAuditLogger(zap.New(core))
+
+	// audit is disabled by default
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.Zero(logs.Len())
+
+	key := Params.QueryCoordCfg.RGAuditLogEnabled.Key
+	Params.BaseTable.Save(key, "true")
+	defer Params.BaseTable.Reset(key)
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	_, err := suite.manager.TransferNode(ctx, "rg1", "rg2")
+	suite.NoError(err)
+	suite.NoError(suite.manager.UnassignNode(context.Background(), "rg2", 1))
+	suite.NoError(suite.manager.RemoveResourceGroup(ctx, "rg1"))
+	// failed operation isn't audited
+	suite.Error(suite.manager.RemoveResourceGroup(ctx, DefaultResourceGroupName))
+
+	entries := logs.FilterMessage("resource group audit").AllUntimed()
+	operations := lo.Map(entries, func(entry observer.LoggedEntry, _ int) string {
+		return entry.ContextMap()["operation"].(string)
+	})
+	suite.Equal([]string{"AddResourceGroup", "AssignNode", "TransferNodes", "UnassignNode", "RemoveResourceGroup"}, operations)
+	for _, entry := range entries {
+		if entry.ContextMap()["operation"] == "UnassignNode" {
+			suite.Equal("unknown", entry.ContextMap()["actor"])
+			continue
+		}
+		suite.Equal("admin", entry.ContextMap()["actor"])
+	}
+	suite.Equal("rg2", entries[0].ContextMap()["rgName"])
+
+	// nil falls back to the global logger
+	suite.manager.SetAuditLogger(nil)
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg3"))
+	suite.Len(logs.FilterMessage("resource group audit").AllUntimed(), len(entries))
+}
+
+func (suite *ResourceManagerSuite) TestAccessStats() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	suite.Empty(suite.manager.GetAccessStats())
+
+	const workers, rounds = 8, 100
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < rounds; j++ {
+				suite.manager.GetNodes("rg")
+				suite.manager.GetResourceGroup("rg")
+				suite.manager.ContainsNode("rg", 1)
+				suite.manager.CheckLackOfNode("rg")
+				// lookup of non-exist rg isn't counted
+				suite.manager.GetNodes("rg1")
+			}
+		}()
+	}
+	wg.Wait()
+	suite.Equal(map[string]uint64{"rg": workers * rounds * 4}, suite.manager.GetAccessStats())
+
+	suite.manager.ContainsNode(DefaultResourceGroupName, 1)
+	suite.EqualValues(1, suite.manager.GetAccessStats()[DefaultResourceGroupName])
+}
+
+func (suite *ResourceManagerSuite) TestOverlapResourceGroup() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	// overlap is forbidden by default
+	suite.ErrorIs(suite.manager.AddOverlapResourceGroup(ctx, "shared"), ErrRGOverlapNotAllowed)
+	suite.False(suite.manager.ContainResourceGroup("shared"))
+
+	manager := NewResourceManagerWithOverlap(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(manager.AddOverlapResourceGroup(ctx, "shared"))
+	suite.ErrorIs(manager.AddOverlapResourceGroup(ctx, "shared"), ErrRGAlreadyExist)
+
+	// non-overlap rgs still can't share nodes
+	suite.NoError(manager.AssignNode(ctx, "rg1", 1))
+	suite.ErrorIs(manager.AssignNode(ctx, "rg2", 1), ErrNodeAlreadyAssign)
+
+	suite.NoError(manager.AssignNode(ctx, "shared", 1))
+	suite.NoError(manager.AssignNodes(ctx, "shared", []int64{2}))
+	suite.NoError(manager.AssignNode(ctx, "rg2", 2))
+	suite.Equal([]string{"rg1", "shared"}, manager.FindResourceGroupsByNode(1))
+	suite.Equal([]string{"rg2", "shared"}, manager.FindResourceGroupsByNode(2))
+	suite.Empty(manager.FindResourceGroupsByNode(3))
+	rgName, err := manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
+	suite.Empty(manager.CheckConsistency())
+
+	_, err = manager.TransferNode(ctx, "shared", "rg1")
+	suite.ErrorIs(err, ErrRGOverlapNotAllowed)
+	_, _, err = manager.AutoRecoverResourceGroup(ctx, "shared")
+	suite.ErrorIs(err, ErrRGOverlapNotAllowed)
+
+	// unassign from overlap rg keeps node in its own rg
+	suite.NoError(manager.UnassignNode(ctx, "shared", 1))
+	rgName, err = manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
+	suite.True(manager.ContainsNode("rg1", 1))
+
+	manager = NewResourceManagerWithOverlap(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.True(manager.groups["shared"].IsOverlap())
+	suite.ElementsMatch([]int64{2}, manager.groups["shared"].GetNodes())
+	suite.ElementsMatch([]int64{2}, manager.groups["rg2"].GetNodes())
+	suite.Empty(manager.CheckConsistency())
+
+	suite.NoError(manager.UnassignNode(ctx, "shared", 2))
+	suite.True(manager.ContainsNode("rg2", 2))
+	suite.NoError(manager.RemoveResourceGroup(ctx, "shared"))
+	overlap, err := manager.store.GetOverlapResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Empty(overlap)
+
+	// rejected overlap rg leaves no record
+	maxResourceGroupNum := manager.maxResourceGroupNum
+	manager.maxResourceGroupNum = len(manager.groups)
+	suite.ErrorIs(manager.AddOverlapResourceGroup(ctx, "shared"), ErrRGLimit)
+	overlap, err = manager.store.GetOverlapResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Empty(overlap)
+
+	// stale overlap record never turns a plain rg into overlap one
+	manager.maxResourceGroupNum = maxResourceGroupNum
+	suite.NoError(manager.store.SaveOverlapResourceGroup(ctx, "shared"))
+	suite.NoError(manager.AddResourceGroup(ctx, "shared"))
+	manager = NewResourceManagerWithOverlap(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.False(manager.groups["shared"].IsOverlap())
+}
+
+func (suite *ResourceManagerSuite) TestCloneResourceGroup() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroupWithLimit(ctx, "rg1", 5))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+
+	suite.ErrorIs(suite.manager.CloneResourceGroup(ctx, "rg3", "rg2"), ErrRGNotExist)
+	suite.ErrorIs(suite.manager.CloneResourceGroup(ctx, "rg1", ""), ErrRGNameIsEmpty)
+	suite.ErrorIs(suite.manager.CloneResourceGroup(ctx, "rg1", DefaultResourceGroupName), ErrRGAlreadyExist)
+
+	// nodes can't be shared without overlap
+	suite.NoError(suite.manager.CloneResourceGroup(ctx, "rg1", "rg2"))
+	suite.ErrorIs(suite.manager.CloneResourceGroup(ctx, "rg1", "rg2"), ErrRGAlreadyExist)
+	rg2, err := suite.manager.GetResourceGroup("rg2")
+	suite.NoError(err)
+	suite.Equal(2, rg2.GetCapacity())
+	suite.Equal(5, rg2.GetMaxCapacity())
+	suite.Empty(rg2.GetNodes())
+	suite.False(rg2.IsOverlap())
+	suite.Equal(2, suite.manager.CheckLackOfNode("rg2"))
+	suite.ElementsMatch([]int64{1, 2}, suite.manager.groups["rg1"].GetNodes())
+
+	manager := NewResourceManagerWithOverlap(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(2, manager.groups["rg2"].GetCapacity())
+	suite.Equal(5, manager.groups["rg2"].GetMaxCapacity())
+	suite.Empty(manager.groups["rg2"].GetNodes())
+
+	// cloned rg shares nodes of src with overlap
+	suite.NoError(manager.CloneResourceGroup(ctx, "rg1", "rg3"))
+	rg3, err := manager.GetResourceGroup("rg3")
+	suite.NoError(err)
+	suite.True(rg3.IsOverlap())
+	suite.Equal(2, rg3.GetCapacity())
+	suite.ElementsMatch([]int64{1, 2}, rg3.GetNodes())
+	suite.Equal([]string{"rg1", "rg3"}, manager.FindResourceGroupsByNode(1))
+	rgName, err := manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
+	suite.Empty(manager.CheckConsistency())
+
+	manager = NewResourceManagerWithOverlap(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.True(manager.groups["rg3"].IsOverlap())
+	suite.ElementsMatch([]int64{1, 2}, manager.groups["rg3"].GetNodes())
+	suite.ElementsMatch([]int64{1, 2}, manager.groups["rg1"].GetNodes())
+	suite.Empty(manager.CheckConsistency())
+}
+
+func (suite *ResourceManagerSuite) TestAutoRecoverResourceGroupWithHint() {
+	ctx := context.Background()
+	for i := 1; i <= 7; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{1, 2, 3, 4, 5, 6}))
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg1", 3))
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg2", 1))
+
+	_, err := suite.manager.AutoRecoverResourceGroupWithHint(ctx, "rg3", nil)
+	suite.ErrorIs(err, ErrRGNotExist)
+
+	// preferred nodes are consumed first, nodes not in default rg are skipped
+	num, err := suite.manager.AutoRecoverResourceGroupWithHint(ctx, "rg1", []int64{7, 5, 100, 4, 5})
+	suite.NoError(err)
+	suite.Equal(3, num)
+	suite.ElementsMatch([]int64{1, 4, 5}, suite.manager.groups["rg1"].GetNodes())
+
+	// no more than lacking nodes are taken even if more are preferred
+	num, err = suite.manager.AutoRecoverResourceGroupWithHint(ctx, "rg2", []int64{6, 2})
+	suite.NoError(err)
+	suite.Equal(1, num)
+	suite.ElementsMatch([]int64{6}, suite.manager.groups["rg2"].GetNodes())
+	suite.ElementsMatch([]int64{2, 3}, suite.manager.groups[DefaultResourceGroupName].GetNodes())
+
+	num, err = suite.manager.AutoRecoverResourceGroupWithHint(ctx, "rg2", []int64{2})
+	suite.NoError(err)
+	suite.Equal(0, num)
+}
+
+func (suite *ResourceManagerSuite) TestAutoRecoverResourceGroupFrom() {
+	ctx := context.Background()
+	for i := 1; i <= 6; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg1", 3))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg3"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg2", []int64{1, 2, 3}))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg3", []int64{4, 5, 6}))
+	// donors are over provisioned
+	suite.manager.groups["rg2"].capacity = 1
+	suite.manager.groups["rg3"].capacity = 2
+
+	_, err := suite.manager.AutoRecoverResourceGroupFrom(ctx, "rg1", []string{"rg4"})
+	suite.ErrorIs(err, ErrRGNotExist)
+	_, err = suite.manager.AutoRecoverResourceGroupFrom(ctx, "rg1", []string{"rg2", "rg1"})
+	suite.ErrorIs(err, ErrSameResourceGroup)
+
+	num, err := suite.manager.AutoRecoverResourceGroupFrom(ctx, "rg1", []string{"rg2", "rg3"})
+	suite.NoError(err)
+	suite.Equal(3, num)
+	suite.Equal(0, suite.manager.CheckLackOfNode("rg1"))
+	suite.Equal(3, suite.manager.groups["rg1"].GetCapacity())
+	suite.ElementsMatch([]int64{1, 2, 4}, suite.manager.groups["rg1"].GetNodes())
+	// donors keep their capacity floor
+	suite.Equal(1, suite.manager.groups["rg2"].GetCapacity())
+	suite.ElementsMatch([]int64{3}, suite.manager.groups["rg2"].GetNodes())
+	suite.Equal(2, suite.manager.groups["rg3"].GetCapacity())
+	suite.ElementsMatch([]int64{5, 6}, suite.manager.groups["rg3"].GetNodes())
+	rgName, err := suite.manager.FindResourceGroupByNode(4)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
+	suite.Empty(suite.manager.CheckConsistency())
+
+	// nothing to recover
+	num, err = suite.manager.AutoRecoverResourceGroupFrom(ctx, "rg1", []string{"rg3"})
+	suite.NoError(err)
+	suite.Equal(0, num)
+
+	// nodes exceeding the lack are rejected before store write
+	store := suite.manager.store
+	suite.manager.store = NewMockStore(suite.T())
+	suite.ErrorIs(suite.manager.recoverNodesFromDonor(ctx, "rg3", "rg1", []int64{5}), ErrRGIsFull)
+	suite.ElementsMatch([]int64{5, 6}, suite.manager.groups["rg3"].GetNodes())
+	suite.manager.store = store
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]int64{1, 2, 4}, manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{3}, manager.groups["rg2"].GetNodes())
+	suite.ElementsMatch([]int64{5, 6}, manager.groups["rg3"].GetNodes())
+	suite.Equal(2, manager.groups["rg3"].GetCapacity())
+}
+
+func (suite *ResourceManagerSuite) TestAutoShedResourceGroup() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2, 3, 4}))
+
+	num, err := suite.manager.AutoShedResourceGroup(ctx, "rg1")
+	suite.NoError(err)
+	suite.Equal(0, num)
+
+	// lowering limit below node num shrinks capacity and leaves the surplus to shed
+	suite.NoError(suite.manager.SetResourceGroupLimit(ctx, "rg1", 1))
+	suite.Equal(1, suite.manager.groups["rg1"].GetCapacity())
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 4)
+	num, err = suite.manager.AutoShedResourceGroup(ctx, "rg1")
+	suite.NoError(err)
+	suite.Equal(3, num)
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 1)
+	suite.Equal(1, suite.manager.groups["rg1"].GetCapacity())
+	suite.Equal(0, suite.manager.groups["rg1"].LackOfNodes())
+	suite.Len(suite.manager.groups[DefaultResourceGroupName].GetNodes(), 3)
+	suite.Equal(0, suite.manager.groups[DefaultResourceGroupName].LackOfNodes())
+
+	_, err = suite.manager.AutoShedResourceGroup(ctx, "rg2")
+	suite.ErrorIs(err, ErrRGNotExist)
+	_, err = suite.manager.AutoShedResourceGroup(ctx, DefaultResourceGroupName)
+	suite.ErrorIs(err, ErrSameResourceGroup)
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(suite.manager.groups["rg1"].GetNodes(), manager.groups["rg1"].GetNodes())
+	suite.Equal(1, manager.groups["rg1"].GetCapacity())
+	suite.ElementsMatch(suite.manager.groups[DefaultResourceGroupName].GetNodes(), manager.groups[DefaultResourceGroupName].GetNodes())
+}
+
+func (suite *ResourceManagerSuite) TestRecover() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	err := suite.manager.AddResourceGroup(ctx, "rg")
+	suite.NoError(err)
+
+	suite.manager.AssignNode(ctx, "rg", 1)
+	suite.manager.AssignNode(ctx, "rg", 2)
+	suite.manager.AssignNode(ctx, "rg", 3)
+
+	suite.manager.UnassignNode(ctx, "rg", 3)
+
+	// clear resource manager in hack way
+	delete(suite.manager.groups, "rg")
+	delete(suite.manager.groups, DefaultResourceGroupName)
+	suite.manager.Recover(ctx)
+
+	rg, err := suite.manager.GetResourceGroup("rg")
+	suite.NoError(err)
+	suite.Equal(2, rg.GetCapacity())
+	suite.True(suite.manager.ContainsNode("rg", 1))
+	suite.True(suite.manager.ContainsNode("rg", 2))
+	suite.False(suite.manager.ContainsNode("rg", 3))
+}
+
+func (suite *ResourceManagerSuite) TestRecoverCapacity() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(4, "localhost"))
+
+	// over-provisioned rg
+	err := suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     "rg1",
+		Capacity: 5,
+		Nodes:    []int64{1, 2, 3},
+	})
+	suite.NoError(err)
+	// rg with capacity drift
+	err = suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     "rg2",
+		Capacity: 0,
+		Nodes:    []int64{4},
+	})
+	suite.NoError(err)
+
+	suite.NoError(suite.manager.Recover(ctx))
+	rg, err := suite.manager.GetResourceGroup("rg1")
+	suite.NoError(err)
+	suite.Equal(5, rg.GetCapacity())
+	suite.Equal(2, rg.LackOfNodes())
+	suite.ElementsMatch([]int64{1, 2, 3}, rg.GetNodes())
+
+	rg, err = suite.manager.GetResourceGroup("rg2")
+	suite.NoError(err)
+	suite.Equal(1, rg.GetCapacity())
+	suite.Equal(0, rg.LackOfNodes())
+	rgs, err := suite.manager.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	for _, rg := range rgs {
+		if rg.GetName() == "rg2" {
+			suite.EqualValues(1, rg.GetCapacity())
+		}
+	}
+}
+
+func (suite *ResourceManagerSuite) TestRecoverDefaultResourceGroup() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+
+	err := suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     "rg",
+		Capacity: 1,
+		Nodes:    []int64{3},
+	})
+	suite.NoError(err)
+	// default rg persisted with wrong capacity, duplicated node and node owned by other rg
+	err = suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     DefaultResourceGroupName,
+		Capacity: 5,
+		Nodes:    []int64{1, 1, 2, 3},
+	})
+	suite.NoError(err)
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	rg, err := manager.GetResourceGroup(DefaultResourceGroupName)
+	suite.NoError(err)
+	suite.Equal(defaultResourceGroupCapacity, rg.GetCapacity())
+	suite.ElementsMatch([]int64{1, 2}, rg.GetNodes())
+	suite.True(manager.ContainsNode("rg", 3))
+
+	rgs, err := manager.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	for _, rg := range rgs {
+		if rg.GetName() == DefaultResourceGroupName {
+			suite.EqualValues(defaultResourceGroupCapacity, rg.GetCapacity())
+			suite.ElementsMatch([]int64{1, 2}, rg.GetNodes())
+		}
+	}
+}
+
+func (suite *ResourceManagerSuite) TestChurnRate() {
+	ctx := context.Background()
+	key := Params.QueryCoordCfg.RGChurnRateWindow.Key
+	Params.BaseTable.Save(key, "120")
+	defer Params.BaseTable.Reset(key)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics.QueryCoordResourceGroupChurnRate)
+	defer registry.Unregister(metrics.QueryCoordResourceGroupChurnRate)
+
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2, 3}))
+	suite.Equal(0.0, suite.manager.GetChurnRate("rg"))
+	suite.Equal(0.0, suite.manager.GetChurnRate("rg1"))
+
+	now := time.Unix(0, 0)
+	suite.manager.groups["rg"].clock = func() time.Time { return now }
+
+	// node 1 flaps, node 2 goes down
+	_, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+	now = now.Add(30 * time.Second)
+	_, err = suite.manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	now = now.Add(30 * time.Second)
+	_, err = suite.manager.HandleNodeDown(ctx, 2)
+	suite.NoError(err)
+	suite.Equal(1.5, suite.manager.GetChurnRate("rg"))
+
+	families, err := registry.Gather()
+	suite.NoError(err)
+	rates := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			rates[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+		}
+	}
+	suite.Equal(1.5, rates["rg"])
+
+	// moving node between rgs isn't churn
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.TransferSpecificNode(ctx, "rg", "rg1", 3))
+	suite.Equal(1.5, suite.manager.GetChurnRate("rg"))
+	suite.Equal(0.0, suite.manager.GetChurnRate("rg1"))
+
+	// events slide out of the window
+	now = now.Add(70 * time.Second)
+	suite.Equal(1.0, suite.manager.GetChurnRate("rg"))
+	now = now.Add(60 * time.Second)
+	suite.Equal(0.0, suite.manager.GetChurnRate("rg"))
+
+	// recovery starts over
+	now = time.Unix(0, 0)
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.manager.nodeMgr.Remove(3)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(0.0, manager.GetChurnRate("rg"))
+}
+
+func (suite *ResourceManagerSuite) TestIsNodeAssigned() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	manager := NewResourceManagerWithOverlap(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(manager.AssignNode(ctx, "rg", 1))
+	_, err := manager.HandleNodeUp(ctx, 2)
+	suite.NoError(err)
+	suite.NoError(manager.AddOverlapResourceGroup(ctx, "overlap"))
+	suite.NoError(manager.AssignNode(ctx, "overlap", 3))
+
+	suite.True(manager.IsNodeAssigned(1))
+	suite.True(manager.IsNodeAssigned(2))
+	suite.False(manager.IsNodeAssigned(3))
+	suite.False(manager.IsNodeAssigned(4))
+	suite.False(manager.IsNodeAssigned(5))
+}
+
+func (suite *ResourceManagerSuite) TestReplicaQuota() {
+	ctx := context.Background()
+	holder := &mockReplicaHolder{replicas: make(map[string][]*Replica)}
+	suite.manager.SetReplicaHolder(holder)
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	newReplica := func(id int64, rgName string) *Replica {
+		return NewReplica(&querypb.Replica{ID: id, CollectionID: 100, ResourceGroup: rgName}, typeutil.NewUniqueSet())
+	}
+
+	// unlimited by default
+	holder.replicas["rg1"] = []*Replica{newReplica(1, "rg1"), newReplica(2, "rg1"), newReplica(3, "rg1")}
+	suite.True(suite.manager.CanAcceptReplica("rg1", 1))
+
+	key := Params.QueryCoordCfg.RGMaxReplicaNum.Key
+	Params.BaseTable.Save(key, "2")
+	defer Params.BaseTable.Reset(key)
+	suite.False(suite.manager.CanAcceptReplica("rg1", 1))
+
+	// quota is per rg
+	suite.True(suite.manager.CanAcceptReplica("rg2", 2))
+	suite.False(suite.manager.CanAcceptReplica("rg2", 3))
+	holder.replicas["rg2"] = []*Replica{newReplica(4, "rg2")}
+	suite.True(suite.manager.CanAcceptReplica("rg2", 1))
+	suite.False(suite.manager.CanAcceptReplica("rg2", 2))
+
+	// release frees the quota
+	holder.replicas["rg1"] = []*Replica{newReplica(3, "rg1")}
+	suite.True(suite.manager.CanAcceptReplica("rg1", 1))
+
+	suite.False(suite.manager.CanAcceptReplica("rg3", 1))
+}
+
+func (suite *ResourceManagerSuite) TestGetDefaultSpareNodes() {
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+		_, err := suite.manager.HandleNodeUp(context.Background(), int64(i))
+		suite.NoError(err)
+	}
+	suite.Equal(4, suite.manager.GetDefaultSpareNodes())
+
+	key := Params.QueryCoordCfg.DefaultRGReservedNodeNum.Key
+	Params.BaseTable.Save(key, "1")
+	defer Params.BaseTable.Reset(key)
+	suite.Equal(3, suite.manager.GetDefaultSpareNodes())
+
+	// down nodes are pruned before counting
+	suite.manager.nodeMgr.Remove(1)
+	suite.Equal(2, suite.manager.GetDefaultSpareNodes())
+	suite.False(suite.manager.ContainsNode(DefaultResourceGroupName, 1))
+
+	Params.BaseTable.Save(key, "5")
+	suite.Equal(0, suite.manager.GetDefaultSpareNodes())
+}
+
+func (suite *ResourceManagerSuite) TestIdempotencyToken() {
+	ctx := context.Background()
+	for i := 1; i <= 2; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+
+	// retry of succeeded assign returns success without touching store
+	tokenCtx := WithIdempotencyToken(ctx, "token1")
+	suite.NoError(suite.manager.AssignNode(tokenCtx, "rg", 1))
+	store := suite.manager.store
+	suite.manager.store = NewMockStore(suite.T())
+	suite.NoError(suite.manager.AssignNode(tokenCtx, "rg", 1))
+	suite.manager.store = store
+	suite.Equal(1, suite.manager.groups["rg"].GetCapacity())
+
+	// retry without token still fails
+	suite.ErrorIs(suite.manager.AssignNode(ctx, "rg", 1), ErrNodeAlreadyAssign)
+	// token reused by another operation doesn't match, so it's applied
+	suite.NoError(suite.manager.UnassignNode(tokenCtx, "rg", 1))
+	suite.False(suite.manager.ContainsNode("rg", 1))
+	suite.NoError(suite.manager.UnassignNode(tokenCtx, "rg", 1))
+	suite.NoError(suite.manager.AssignNode(tokenCtx, "rg", 1))
+	suite.True(suite.manager.ContainsNode("rg", 1))
+	suite.Equal(1, suite.manager.groups["rg"].GetCapacity())
+
+	// failed operation isn't recorded
+	tokenCtx = WithIdempotencyToken(ctx, "token2")
+	suite.ErrorIs(suite.manager.AssignNode(tokenCtx, "rg1", 2), ErrRGNotExist)
+	suite.NoError(suite.manager.AddResourceGroup(tokenCtx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(tokenCtx, "rg1"))
+	suite.ErrorIs(suite.manager.AddResourceGroup(ctx, "rg1"), ErrRGAlreadyExist)
+}
+
+func (suite *ResourceManagerSuite) TestRecentOperations() {
+	ops := newRecentOperations(2)
+	ops.add("token1", "op1")
+	ops.add("token2", "op2")
+	suite.True(ops.contains("token1", "op1"))
+	suite.False(ops.contains("token1", "op2"))
+
+	// token2 is the least recently used one
+	ops.add("token3", "op3")
+	suite.False(ops.contains("token2", "op2"))
+	suite.True(ops.contains("token1", "op1"))
+	suite.True(ops.contains("token3", "op3"))
+}
+
+func (suite *ResourceManagerSuite) TestRecoverDroppedNodes() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+
+	// node 3 and 4 no longer exist in node manager
+	err := suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     "rg",
+		Capacity: 3,
+		Nodes:    []int64{1, 4, 3},
+	})
+	suite.NoError(err)
+	err = suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     "rg2",
+		Capacity: 1,
+		Nodes:    []int64{2},
+	})
+	suite.NoError(err)
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.Empty(manager.GetRecoverDroppedNodes())
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(map[string][]int64{"rg": {3, 4}}, manager.GetRecoverDroppedNodes())
+	suite.ElementsMatch([]int64{1}, manager.groups["rg"].GetNodes())
+	suite.Equal(3, manager.groups["rg"].GetCapacity())
+	suite.ElementsMatch([]int64{2}, manager.groups["rg2"].GetNodes())
+
+	// dropped nodes are persisted, so they are not reported again
+	manager = NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Empty(manager.GetRecoverDroppedNodes())
+}
+
+func (suite *ResourceManagerSuite) TestRecoverOverlappingNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+
+	// node 2 persisted in both rgs, node 1 duplicated in rg1
+	err := suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     "rg1",
+		Capacity: 3,
+		Nodes:    []int64{1, 1, 2},
+	})
+	suite.NoError(err)
+	err = suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     "rg2",
+		Capacity: 3,
+		Nodes:    []int64{2, 3},
+	})
+	suite.NoError(err)
+
+	// the conflicting node is reported, the others are still loaded
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	err = manager.Recover(ctx)
+	suite.ErrorIs(err, ErrRGPartialRecovered)
+	suite.ErrorIs(err, ErrNodeAlreadyAssign)
+	var assignedErr *NodeAlreadyAssignedError
+	suite.ErrorAs(err, &assignedErr)
+	suite.Equal(int64(2), assignedErr.Node)
+	suite.Equal("rg1", assignedErr.CurrentRG)
+	suite.Contains(err.Error(), "rg2")
+	suite.Empty(manager.CheckConsistency())
+
+	for i := 1; i <= 4; i++ {
+		owners := 0
+		for _, rgName := range manager.ListResourceGroups() {
+			if manager.ContainsNode(rgName, int64(i)) {
+				owners++
+			}
+		}
+		suite.Equal(1, owners, "node %d", i)
+	}
+	suite.ElementsMatch([]int64{1, 2}, manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{3}, manager.groups["rg2"].GetNodes())
+	suite.Equal(3, manager.groups["rg2"].GetCapacity())
+
+	// repaired membership is persisted
+	rgs, err := manager.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	for _, rg := range rgs {
+		switch rg.GetName() {
+		case "rg1":
+			suite.ElementsMatch([]int64{1, 2}, rg.GetNodes())
+		case "rg2":
+			suite.ElementsMatch([]int64{3}, rg.GetNodes())
+		}
+	}
+
+	manager = NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+}
+
+func (suite *ResourceManagerSuite) TestMutateDuringRecover() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	loading := make(chan struct{})
+	release := make(chan struct{})
+	store.EXPECT().GetResourceGroups(mock.Anything).Run(func(ctx context.Context) {
+		close(loading)
+		<-release
+	}).Return([]*querypb.ResourceGroup{{Name: "rg1", Capacity: 1}}, nil)
+	store.EXPECT().GetNodeResourceGroups(mock.Anything).Return(map[int64]string{}, nil)
+	store.EXPECT().GetResourceGroupLimits(mock.Anything).Return(map[string]int32{}, nil)
+	store.EXPECT().GetResourceGroupLabels(mock.Anything).Return(map[string]map[string]string{}, nil)
+	store.EXPECT().GetSealedResourceGroups(mock.Anything).Return(nil, nil)
+	store.EXPECT().GetOverlapResourceGroups(mock.Anything).Return(nil, nil)
+	store.EXPECT().GetResourceGroupProportions(mock.Anything).Return(map[string]float64{}, nil)
+	store.EXPECT().GetResourceGroupMinCapacities(mock.Anything).Return(map[string]int32{}, nil)
+
+	done := make(chan error)
+	go func() {
+		done <- manager.Recover(ctx)
+	}()
+	<-loading
+
+	// mutating methods are rejected before touching the store
+	err := manager.AddResourceGroup(ctx, "rg1")
+	suite.ErrorIs(err, ErrManagerRecovering)
+	err = manager.AssignNode(ctx, DefaultResourceGroupName, 1)
+	suite.ErrorIs(err, ErrManagerRecovering)
+	_, err = manager.TransferNode(ctx, DefaultResourceGroupName, "rg1")
+	suite.ErrorIs(err, ErrManagerRecovering)
+
+	close(release)
+	suite.NoError(<-done)
+	suite.False(manager.recovering)
+	suite.ElementsMatch([]string{DefaultResourceGroupName, "rg1"}, manager.ListResourceGroups())
+	suite.Equal(1, manager.groups["rg1"].GetCapacity())
+	suite.Empty(manager.groups["rg1"].GetNodes())
+}
+
+func (suite *ResourceManagerSuite) TestHealthy() {
+	ctx := context.Background()
+	healthy, reason := suite.manager.Healthy(ctx)
+	suite.True(healthy)
+	suite.Empty(reason)
+
+	suite.manager.recovering = true
+	healthy, reason = suite.manager.Healthy(ctx)
+	suite.False(healthy)
+	suite.Contains(reason, "recovering")
+	suite.manager.recovering = false
+
+	defaultRG := suite.manager.groups[DefaultResourceGroupName]
+	delete(suite.manager.groups, DefaultResourceGroupName)
+	healthy, reason = suite.manager.Healthy(ctx)
+	suite.False(healthy)
+	suite.Contains(reason, DefaultResourceGroupName)
+	suite.manager.groups[DefaultResourceGroupName] = defaultRG
+
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().GetResourceGroup(mock.Anything, DefaultResourceGroupName).Return(nil, errors.New("mock error")).Once()
+	healthy, reason = manager.Healthy(ctx)
+	suite.False(healthy)
+	suite.Contains(reason, "mock error")
+}
+
+func (suite *ResourceManagerSuite) TestCheckOutboundNodes() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	err := suite.manager.AddResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.manager.AssignNode(ctx, "rg", 1)
+	suite.manager.AssignNode(ctx, "rg", 2)
+	suite.manager.AssignNode(ctx, "rg", 3)
+
+	replica := NewReplica(
+		&querypb.Replica{
+			ID:            1,
+			CollectionID:  1,
+			Nodes:         []int64{1, 2, 3, 4},
+			ResourceGroup: "rg",
+		},
+		typeutil.NewUniqueSet(1, 2, 3, 4),
+	)
+
+	outboundNodes := suite.manager.CheckOutboundNodes(replica)
+	suite.Len(outboundNodes, 1)
+	suite.True(outboundNodes.Contain(4))
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupAdmission() {
+	ctx := context.Background()
+	for i := 1; i <= 6; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.ErrorIs(suite.manager.SetResourceGroupAdmission("rg2", nil), ErrRGNotExist)
+	suite.NoError(suite.manager.SetResourceGroupAdmission("rg1", func(node int64) bool {
+		return node%2 == 0
+	}))
+
+	suite.ErrorIs(suite.manager.AssignNode(ctx, "rg1", 1), ErrNodeNotAdmissible)
+	suite.ErrorIs(suite.manager.AssignNodes(ctx, "rg1", []int64{2, 3}), ErrNodeNotAdmissible)
+	suite.Empty(suite.manager.groups["rg1"].GetNodes())
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 2))
+
+	// odd nodes in default rg are skipped on recovery
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{1, 3, 4, 5}))
+	suite.NoError(suite.manager.SetResourceGroupCapacity(ctx, "rg1", 3))
+	num, _, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg1")
+	suite.NoError(err)
+	suite.Equal(1, num)
+	suite.ElementsMatch([]int64{2, 4}, suite.manager.groups["rg1"].GetNodes())
+	suite.ElementsMatch([]int64{1, 3, 5}, suite.manager.groups[DefaultResourceGroupName].GetNodes())
+
+	rg := suite.manager.groups["rg1"]
+	suite.ErrorIs(rg.handleNodeUp(5), ErrNodeNotAdmissible)
+	suite.NoError(rg.handleNodeUp(6))
+	suite.ElementsMatch([]int64{2, 4, 6}, rg.GetNodes())
+
+	// nil admits all nodes
+	suite.NoError(suite.manager.SetResourceGroupAdmission("rg1", nil))
+	suite.NoError(suite.manager.SetResourceGroupCapacity(ctx, "rg1", 4))
+	num, _, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg1")
+	suite.NoError(err)
+	suite.Equal(1, num)
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 4)
+}
+
+func (suite *ResourceManagerSuite) TestGetInRGNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2, 3}))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg2", []int64{4, 5}))
+
+	replica := NewReplica(
+		&querypb.Replica{
+			ID:            1,
+			CollectionID:  1,
+			Nodes:         []int64{5, 3, 1, 4, 6},
+			ResourceGroup: "rg1",
+		},
+		typeutil.NewUniqueSet(5, 3, 1, 4, 6),
+	)
+
+	inRG := suite.manager.GetInRGNodes(replica)
+	suite.Equal([]int64{1, 3}, inRG)
+
+	// in rg nodes and outbound nodes partition replica's nodes
+	outbound := suite.manager.CheckOutboundNodes(replica)
+	suite.ElementsMatch([]int64{4, 5, 6}, outbound.Collect())
+	for _, node := range inRG {
+		suite.False(outbound.Contain(node))
+	}
+	suite.ElementsMatch(replica.GetNodes(), append(inRG, outbound.Collect()...))
+
+	replica = NewReplica(
+		&querypb.Replica{
+			ID:            2,
+			CollectionID:  1,
+			Nodes:         []int64{1},
+			ResourceGroup: "rg3",
+		},
+		typeutil.NewUniqueSet(1),
+	)
+	suite.Empty(suite.manager.GetInRGNodes(replica))
+}
+
+func (suite *ResourceManagerSuite) TestPersistDownNodeRemoval() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2, 3}))
+
+	suite.manager.nodeMgr.Remove(1)
+	suite.Equal(1, suite.manager.CheckLackOfNode("rg"))
+	// read path doesn't prune, the next mutation on rg does
+	_, _, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	groups, err := suite.manager.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	group, ok := lo.Find(groups, func(group *querypb.ResourceGroup) bool {
+		return group.GetName() == "rg"
+	})
+	suite.True(ok)
+	suite.Equal(int32(3), group.GetCapacity())
+	suite.ElementsMatch([]int64{2, 3}, group.GetNodes())
+
+	// node comes back before coordinator restart, it shouldn't be resurrected in rg
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.False(manager.ContainsNode("rg", 1))
+	suite.Equal(1, manager.CheckLackOfNode("rg"))
+}
+
+func (suite *ResourceManagerSuite) TestReadPathsDontPrune() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2, 3}))
+	suite.manager.nodeMgr.Remove(1)
+
+	// concurrent readers see the down node filtered out, without any store write or state change
+	store := suite.manager.store
+	suite.manager.store = NewMockStore(suite.T())
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodes, err := suite.manager.GetNodes("rg")
+			suite.NoError(err)
+			suite.ElementsMatch([]int64{2, 3}, nodes)
+			suite.False(suite.manager.ContainsNode("rg", 1))
+			suite.Equal(1, suite.manager.CheckLackOfNode("rg"))
+			snapshot, err := suite.manager.GetResourceGroup("rg")
+			suite.NoError(err)
+			suite.Equal([]int64{2, 3}, snapshot.Nodes)
+			suite.Equal(2, suite.manager.CountNodes()["rg"])
+			suite.Equal(1, suite.manager.GetClusterSummary().TotalLacking)
+		}()
+	}
+	wg.Wait()
+	suite.True(suite.manager.groups["rg"].containsNode(1))
+	rgName, err := suite.manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+
+	// mutation prunes it
+	suite.manager.store = store
+	_, _, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.False(suite.manager.groups["rg"].containsNode(1))
+	_, err = suite.manager.FindResourceGroupByNode(1)
+	suite.ErrorIs(err, ErrNodeNotAssignToRG)
+}
+
+func (suite *ResourceManagerSuite) TestGetReplicaNodeDistribution() {
+	ctx := context.Background()
+	for i := 1; i <= 7; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg2", []int64{3, 4}))
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{5, 6}))
+
+	// node 7 doesn't belong to any rg
+	replica := NewReplica(
+		&querypb.Replica{
+			ID:            1,
+			CollectionID:  1,
+			Nodes:         []int64{1, 2, 3, 5, 6, 7},
+			ResourceGroup: "rg1",
+		},
+		typeutil.NewUniqueSet(1, 2, 3, 5, 6, 7),
+	)
+
+	inRG, outbound := suite.manager.GetReplicaNodeDistribution(replica)
+	suite.Equal([]int64{1, 2}, inRG)
+	suite.Equal(map[string][]int64{
+		"rg2":                    {3},
+		DefaultResourceGroupName: {5, 6},
+	}, outbound)
+
+	replica.ResourceGroup = "rg3"
+	inRG, outbound = suite.manager.GetReplicaNodeDistribution(replica)
+	suite.Nil(inRG)
+	suite.Nil(outbound)
+}
+
+func (suite *ResourceManagerSuite) TestCheckResourceGroup() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	err := suite.manager.AddResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.manager.AssignNode(ctx, "rg", 1)
+	suite.manager.AssignNode(ctx, "rg", 2)
+	suite.manager.AssignNode(ctx, "rg", 3)
+
+	suite.manager.HandleNodeDown(ctx, 1)
+	lackNodes := suite.manager.CheckLackOfNode("rg")
+	suite.Equal(lackNodes, 1)
+
+	suite.manager.nodeMgr.Remove(2)
+	suite.manager.checkRGNodeStatus(ctx, "rg")
+	lackNodes = suite.manager.CheckLackOfNode("rg")
+	suite.Equal(lackNodes, 2)
+
+	rg, err := suite.manager.FindResourceGroupByNode(3)
+	suite.NoError(err)
+	suite.Equal(rg, "rg")
+}
+
+func (suite *ResourceManagerSuite) TestForEachResourceGroup() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+
+	// mutation issued during iteration waits until the iteration is done
+	names := make([]string, 0)
+	added := make(chan struct{})
+	suite.manager.ForEachResourceGroup(func(name string, stats ResourceGroupStats) bool {
+		if len(names) == 0 {
+			go func() {
+				defer close(added)
+				suite.NoError(suite.manager.AddResourceGroup(ctx, "rg0"))
+			}()
+			time.Sleep(100 * time.Millisecond)
+		}
+		if name == "rg1" {
+			suite.Equal(ResourceGroupStats{Capacity: 1, AssignedNodes: 1, AvailableNodes: 1}, stats)
+		}
+		names = append(names, name)
+		return true
+	})
+	suite.Equal([]string{DefaultResourceGroupName, "rg1", "rg2"}, names)
+	<-added
+	suite.True(suite.manager.ContainResourceGroup("rg0"))
+
+	// stop early
+	names = names[:0]
+	suite.manager.ForEachResourceGroup(func(name string, stats ResourceGroupStats) bool {
+		names = append(names, name)
+		return len(names) < 2
+	})
+	suite.Equal([]string{DefaultResourceGroupName, "rg0"}, names)
+}
+
+func (suite *ResourceManagerSuite) TestGetResourceGroupStats() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(4, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	for i := 1; i <= 4; i++ {
+		suite.NoError(suite.manager.AssignNode(ctx, "rg", int64(i)))
+	}
+
+	stats, err := suite.manager.GetResourceGroupStats("rg")
+	suite.NoError(err)
+	suite.Equal(ResourceGroupStats{
+		Capacity:       4,
+		AssignedNodes:  4,
+		AvailableNodes: 4,
+		LackingNodes:   0,
+	}, stats)
+
+	suite.manager.HandleNodeDown(ctx, 1)
+	suite.manager.nodeMgr.Remove(2)
+	suite.manager.nodeMgr.Stopping(3)
+	stats, err = suite.manager.GetResourceGroupStats("rg")
+	suite.NoError(err)
+	suite.Equal(ResourceGroupStats{
+		Capacity:       4,
+		AssignedNodes:  2,
+		AvailableNodes: 1,
+		LackingNodes:   2,
+	}, stats)
+
+	_, err = suite.manager.GetResourceGroupStats("rg1")
+	suite.ErrorIs(err, ErrRGNotExist)
+}
+
+func (suite *ResourceManagerSuite) TestListResourceGroupsDetailed() {
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{3, 1}))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 4))
+	suite.manager.nodeMgr.Remove(3)
+
+	detailed := suite.manager.ListResourceGroupsDetailed()
+	suite.ElementsMatch(suite.manager.ListResourceGroups(), lo.Keys(detailed))
+	for _, rgName := range suite.manager.ListResourceGroups() {
+		nodes, err := suite.manager.GetNodes(rgName)
+		suite.NoError(err)
+		suite.ElementsMatch(nodes, detailed[rgName])
+	}
+	suite.Equal([]int64{1}, detailed["rg1"])
+	suite.Equal([]int64{2}, detailed["rg2"])
+	suite.Equal([]int64{4}, detailed[DefaultResourceGroupName])
+}
+
+func (suite *ResourceManagerSuite) TestCountNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg3"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2, 3}))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 4))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 5))
+	suite.manager.nodeMgr.Remove(3)
+
+	counts := suite.manager.CountNodes()
+	suite.ElementsMatch(suite.manager.ListResourceGroups(), lo.Keys(counts))
+	for _, rgName := range suite.manager.ListResourceGroups() {
+		nodes, err := suite.manager.GetNodes(rgName)
+		suite.NoError(err)
+		suite.Len(nodes, counts[rgName])
+	}
+	suite.Equal(map[string]int{
+		DefaultResourceGroupName: 1,
+		"rg1":                    2,
+		"rg2":                    1,
+		"rg3":                    0,
+	}, counts)
+}
+
+func (suite *ResourceManagerSuite) TestWaitForCapacity() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.ErrorIs(suite.manager.WaitForCapacity(ctx, "rg", 1), ErrRGNotExist)
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	suite.NoError(suite.manager.WaitForCapacity(ctx, "rg", 1))
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		suite.manager.AddResourceGroup(ctx, "rg2")
+		suite.manager.AssignNode(ctx, "rg", 2)
+		suite.manager.AssignNode(ctx, "rg", 3)
+	}()
+	suite.NoError(suite.manager.WaitForCapacity(ctx, "rg", 3))
+	suite.ElementsMatch([]int64{1, 2, 3}, suite.manager.groups["rg"].GetNodes())
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	suite.ErrorIs(suite.manager.WaitForCapacity(timeoutCtx, "rg", 4), context.DeadlineExceeded)
+}
+
+func (suite *ResourceManagerSuite) TestListEmptyResourceGroups() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg3"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg3", 2))
+	// rg which lacks of nodes is not empty
+	suite.manager.HandleNodeDown(ctx, 2)
+
+	suite.ElementsMatch([]string{"rg2"}, suite.manager.ListEmptyResourceGroups())
+
+	suite.NoError(suite.manager.UnassignNode(ctx, "rg1", 1))
+	suite.ElementsMatch([]string{"rg1", "rg2"}, suite.manager.ListEmptyResourceGroups())
+}
+
+func (suite *ResourceManagerSuite) TestGetOutboundNode() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	suite.manager.AddResourceGroup(ctx, "rg")
+	suite.manager.AddResourceGroup(ctx, "rg1")
+	suite.manager.AssignNode(ctx, "rg", 1)
+	suite.manager.AssignNode(ctx, "rg", 2)
+	suite.manager.AssignNode(ctx, "rg1", 3)
+
+	replica := NewReplica(
+		&querypb.Replica{
+			ID:            1,
+			CollectionID:  100,
+			ResourceGroup: "rg",
+			Nodes:         []int64{1, 2, 3},
+		},
+		typeutil.NewUniqueSet(1, 2, 3),
+	)
+
+	outgoingNodes := suite.manager.GetOutgoingNodeNumByReplica(replica)
+	suite.NotNil(outgoingNodes)
+	suite.Len(outgoingNodes, 1)
+	suite.NotNil(outgoingNodes["rg1"])
+	suite.Equal(outgoingNodes["rg1"], int32(1))
 }
 
 func (suite *ResourceManagerSuite) TestAutoRecover() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	err := suite.manager.AddResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.manager.AssignNode(ctx, DefaultResourceGroupName, 1)
+	suite.manager.AssignNode(ctx, DefaultResourceGroupName, 2)
+	suite.manager.AssignNode(ctx, "rg", 3)
+
+	suite.manager.HandleNodeDown(ctx, 3)
+	lackNodes := suite.manager.CheckLackOfNode("rg")
+	suite.Equal(lackNodes, 1)
+	suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	lackNodes = suite.manager.CheckLackOfNode("rg")
+	suite.Equal(lackNodes, 0)
+}
+
+func (suite *ResourceManagerSuite) TestNodeIndex() {
+	ctx := context.Background()
+	nodes := []int64{1, 2, 3, 4, 5, 6, 7, 8}
+	for _, node := range nodes {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(node, "localhost"))
+	}
+	rgs := []string{DefaultResourceGroupName, "rg1", "rg2"}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+
+	checkIndex := func() {
+		assigned := typeutil.NewUniqueSet()
+		for _, group := range suite.manager.groups {
+			assigned.Insert(group.GetNodes()...)
+		}
+		suite.Len(suite.manager.nodeToRG, assigned.Len())
+		for node, rgName := range suite.manager.nodeToRG {
+			suite.True(suite.manager.groups[rgName].containsNode(node))
+		}
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 200; i++ {
+		node := nodes[r.Intn(len(nodes))]
+		rgName := rgs[r.Intn(len(rgs))]
+		switch r.Intn(5) {
+		case 0:
+			suite.manager.AssignNode(ctx, rgName, node)
+		case 1:
+			suite.manager.UnassignNode(ctx, rgName, node)
+		case 2:
+			suite.manager.HandleNodeUp(ctx, node)
+		case 3:
+			suite.manager.HandleNodeDown(ctx, node)
+		case 4:
+			suite.manager.nodeMgr.Remove(node)
+			suite.manager.checkRGNodeStatus(ctx, rgName)
+			suite.manager.nodeMgr.Add(session.NewNodeInfo(node, "localhost"))
+		}
+		checkIndex()
+	}
+
+	// clear resource manager in hack way
+	delete(suite.manager.groups, "rg1")
+	delete(suite.manager.groups, "rg2")
+	suite.manager.nodeToRG = make(map[int64]string)
+	suite.NoError(suite.manager.Recover(ctx))
+	checkIndex()
+}
+
+func (suite *ResourceManagerSuite) TestWeightedCapacity() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg", 2))
+	for i := 1; i <= 3; i++ {
+		suite.NoError(suite.manager.AssignNode(ctx, "rg", int64(i)))
+	}
+
+	// default weight keeps the same result as node count
+	suite.Equal(3.0, suite.manager.GetWeightedCapacity("rg"))
+	suite.Equal(2, suite.manager.CheckLackOfNode("rg"))
+	suite.Equal(2.0, suite.manager.CheckWeightedLackOfNode("rg"))
+
+	suite.manager.nodeMgr.Get(1).SetWeight(2.0)
+	suite.manager.nodeMgr.Get(2).SetWeight(0.5)
+	suite.Equal(3.5, suite.manager.GetWeightedCapacity("rg"))
+	suite.Equal(1.5, suite.manager.CheckWeightedLackOfNode("rg"))
+	suite.Equal(2, suite.manager.CheckLackOfNode("rg"))
+
+	suite.manager.nodeMgr.Get(3).SetWeight(3.0)
+	suite.Equal(5.5, suite.manager.GetWeightedCapacity("rg"))
+	suite.Equal(0.0, suite.manager.CheckWeightedLackOfNode("rg"))
+
+	suite.manager.nodeMgr.Remove(1)
+	suite.Equal(1.5, suite.manager.CheckWeightedLackOfNode("rg"))
+	suite.Equal(3.5, suite.manager.GetWeightedCapacity("rg"))
+
+	suite.Equal(0.0, suite.manager.GetWeightedCapacity("rg1"))
+	suite.Equal(0.0, suite.manager.CheckWeightedLackOfNode("rg1"))
+}
+
+func (suite *ResourceManagerSuite) TestCheckLackOfNodeAll() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg1", 3))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg3"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg2", []int64{2, 3}))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 4))
+	suite.manager.nodeMgr.Remove(3)
+
+	lacks := suite.manager.CheckLackOfNodeAll()
+	suite.Equal(map[string]int{"rg1": 3, "rg2": 1, "rg3": 0}, lacks)
+	for rgName, lack := range lacks {
+		suite.Equal(suite.manager.CheckLackOfNode(rgName), lack)
+	}
+}
+
+func (suite *ResourceManagerSuite) TestGetCapacityUtilization() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "full"))
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "half", 2))
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "empty", 2))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "zero"))
+	suite.NoError(suite.manager.AssignNode(ctx, "full", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "full", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, "half", 3))
+	suite.NoError(suite.manager.AssignNode(ctx, "half", 4))
+
+	utilization, err := suite.manager.GetCapacityUtilization("full")
+	suite.NoError(err)
+	suite.Equal(1.0, utilization)
+
+	utilization, err = suite.manager.GetCapacityUtilization("half")
+	suite.NoError(err)
+	suite.Equal(0.5, utilization)
+
+	utilization, err = suite.manager.GetCapacityUtilization("empty")
+	suite.NoError(err)
+	suite.Equal(0.0, utilization)
+
+	utilization, err = suite.manager.GetCapacityUtilization("zero")
+	suite.NoError(err)
+	suite.Equal(0.0, utilization)
+
+	// down node shouldn't be counted
+	suite.manager.nodeMgr.Remove(2)
+	utilization, err = suite.manager.GetCapacityUtilization("full")
+	suite.NoError(err)
+	suite.Equal(0.5, utilization)
+
+	_, err = suite.manager.GetCapacityUtilization("rg")
+	suite.ErrorIs(err, ErrRGNotExist)
+}
+
+func (suite *ResourceManagerSuite) TestListUnassignedNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 6; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 3))
+	suite.NoError(suite.manager.UnassignNode(ctx, "rg", 3))
+	suite.manager.nodeMgr.Stopping(6)
+
+	suite.Equal([]int64{3, 4, 5}, suite.manager.ListUnassignedNodes())
+
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 4))
+	suite.Equal([]int64{3, 5}, suite.manager.ListUnassignedNodes())
+}
+
+func (suite *ResourceManagerSuite) TestSetResourceGroupCapacity() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 2))
+
+	suite.ErrorIs(suite.manager.SetResourceGroupCapacity(ctx, "rg1", 1), ErrRGNotExist)
+	suite.ErrorIs(suite.manager.SetResourceGroupCapacity(ctx, DefaultResourceGroupName, 1), ErrRGCapacityInvalid)
+	suite.ErrorIs(suite.manager.SetResourceGroupCapacity(ctx, "rg", -1), ErrRGCapacityInvalid)
+
+	// increase capacity, lack of nodes grows
+	suite.NoError(suite.manager.SetResourceGroupCapacity(ctx, "rg", 5))
+	suite.Equal(5, suite.manager.groups["rg"].GetCapacity())
+	suite.Equal(3, suite.manager.CheckLackOfNode("rg"))
+	suite.ElementsMatch([]int64{1, 2}, suite.manager.groups["rg"].GetNodes())
+
+	// decrease capacity above node num
+	suite.NoError(suite.manager.SetResourceGroupCapacity(ctx, "rg", 3))
+	suite.Equal(1, suite.manager.CheckLackOfNode("rg"))
+
+	// decrease capacity below node num
+	suite.ErrorIs(suite.manager.SetResourceGroupCapacity(ctx, "rg", 1), ErrCapacityBelowNodeCount)
+	suite.Equal(3, suite.manager.groups["rg"].GetCapacity())
+
+	// capacity should be persisted
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	rg, err := manager.GetResourceGroup("rg")
+	suite.NoError(err)
+	suite.Equal(3, rg.GetCapacity())
+	suite.ElementsMatch([]int64{1, 2}, rg.GetNodes())
+
+	// capacity is bounded by max capacity
+	suite.NoError(suite.manager.SetResourceGroupLimit(ctx, "rg", 4))
+	suite.ErrorIs(suite.manager.SetResourceGroupCapacity(ctx, "rg", 5), ErrRGCapacityExceeded)
+	suite.NoError(suite.manager.SetResourceGroupCapacity(ctx, "rg", 4))
+}
+
+func (suite *ResourceManagerSuite) TestSealResourceGroup() {
+	ctx := context.Background()
+	for i := 1; i <= 6; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 3))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 4))
+
+	suite.ErrorIs(suite.manager.SealResourceGroup(ctx, "rg3", true), ErrRGNotExist)
+	suite.NoError(suite.manager.SealResourceGroup(ctx, "rg1", true))
+	suite.NoError(suite.manager.SealResourceGroup(ctx, "rg1", true))
+
+	suite.ErrorIs(suite.manager.AssignNode(ctx, "rg1", 5), ErrRGSealed)
+	suite.ErrorIs(suite.manager.AssignNodes(ctx, "rg1", []int64{5}), ErrRGSealed)
+	suite.ErrorIs(suite.manager.UnassignNode(ctx, "rg1", 1), ErrRGSealed)
+	_, err := suite.manager.TransferNode(ctx, "rg1", "rg2")
+	suite.ErrorIs(err, ErrRGSealed)
+	_, err = suite.manager.TransferNode(ctx, "rg2", "rg1")
+	suite.ErrorIs(err, ErrRGSealed)
+	suite.ErrorIs(suite.manager.TransferSpecificNode(ctx, "rg1", "rg2", 1), ErrRGSealed)
+	suite.ErrorIs(suite.manager.MoveNode(ctx, "rg2", 1), ErrRGSealed)
+	_, err = suite.manager.TransferAllNodes(ctx, "rg1", "rg2")
+	suite.ErrorIs(err, ErrRGSealed)
+	suite.ErrorIs(suite.manager.RemoveAllNodes(ctx, "rg1"), ErrRGSealed)
+	suite.ElementsMatch([]int64{1, 2}, suite.manager.groups["rg1"].GetNodes())
+
+	// node down is still handled
+	rgName, err := suite.manager.HandleNodeDown(ctx, 2)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
+	suite.Equal(1, suite.manager.CheckLackOfNode("rg1"))
+
+	_, _, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg1")
+	suite.ErrorIs(err, ErrRGSealed)
+	recovered, err := suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(0, recovered)
+	suite.Equal(1, suite.manager.CheckLackOfNode("rg1"))
+
+	// sealed flag survives recover
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	rg, err := manager.GetResourceGroup("rg1")
+	suite.NoError(err)
+	suite.True(rg.IsSealed())
+	rg, err = manager.GetResourceGroup("rg2")
+	suite.NoError(err)
+	suite.False(rg.IsSealed())
+
+	// unseal
+	suite.NoError(suite.manager.SealResourceGroup(ctx, "rg1", false))
+	recovered, _, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg1")
+	suite.NoError(err)
+	suite.Equal(1, recovered)
+	suite.NoError(suite.manager.UnassignNode(ctx, "rg1", 1))
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupLabels() {
+	ctx := context.Background()
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg3"))
+
+	suite.ErrorIs(suite.manager.SetResourceGroupLabels(ctx, "rg4", map[string]string{"tenant": "acme"}), ErrRGNotExist)
+
+	suite.NoError(suite.manager.SetResourceGroupLabels(ctx, "rg1", map[string]string{"tenant": "acme", "tier": "gold"}))
+	suite.NoError(suite.manager.SetResourceGroupLabels(ctx, "rg2", map[string]string{"tenant": "acme", "tier": "silver"}))
+	suite.NoError(suite.manager.SetResourceGroupLabels(ctx, "rg3", map[string]string{"tenant": "foo"}))
+	suite.Equal([]string{"rg1", "rg2"}, suite.manager.ListResourceGroupsByLabel("tenant", "acme"))
+	suite.Equal([]string{"rg1"}, suite.manager.ListResourceGroupsByLabel("tier", "gold"))
+	suite.Empty(suite.manager.ListResourceGroupsByLabel("tier", "bronze"))
+
+	// overwrite labels
+	suite.NoError(suite.manager.SetResourceGroupLabels(ctx, "rg2", map[string]string{"tier": "gold"}))
+	suite.Equal([]string{"rg1"}, suite.manager.ListResourceGroupsByLabel("tenant", "acme"))
+	suite.Equal([]string{"rg1", "rg2"}, suite.manager.ListResourceGroupsByLabel("tier", "gold"))
+
+	// clear labels
+	suite.NoError(suite.manager.SetResourceGroupLabels(ctx, "rg3", nil))
+	suite.Empty(suite.manager.ListResourceGroupsByLabel("tenant", "foo"))
+
+	// labels survive recover
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal([]string{"rg1", "rg2"}, manager.ListResourceGroupsByLabel("tier", "gold"))
+	rg, err := manager.GetResourceGroup("rg1")
+	suite.NoError(err)
+	suite.Equal(map[string]string{"tenant": "acme", "tier": "gold"}, rg.GetLabels())
+	rg, err = manager.GetResourceGroup("rg3")
+	suite.NoError(err)
+	suite.Empty(rg.GetLabels())
+
+	// labels are removed along with rg
+	suite.NoError(manager.RemoveResourceGroup(ctx, "rg1"))
+	labels, err := manager.store.GetResourceGroupLabels(ctx)
+	suite.NoError(err)
+	suite.NotContains(labels, "rg1")
+}
+
+func (suite *ResourceManagerSuite) TestCheckConsistency() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 3))
+	suite.Empty(suite.manager.CheckConsistency())
+
+	// over-provisioned rg is fine
+	_, err := suite.manager.HandleNodeDown(ctx, 2)
+	suite.NoError(err)
+	suite.Empty(suite.manager.CheckConsistency())
+
+	// inject inconsistency in hack way
+	suite.manager.groups["rg2"].nodes.Insert(1)
+	errs := suite.manager.CheckConsistency()
+	suite.Len(errs, 1)
+	suite.ErrorIs(errs[0], ErrRGInconsistent)
+	suite.Contains(errs[0].Error(), "node 1 belongs to both rg rg1 and rg rg2")
+	suite.manager.groups["rg2"].nodes.Remove(1)
+
+	suite.manager.groups["rg1"].nodes.Insert(4)
+	errs = suite.manager.CheckConsistency()
+	suite.Len(errs, 2)
+	suite.Contains(errs[0].Error(), "rg rg1 has 2 nodes, more than capacity 1")
+	suite.Contains(errs[1].Error(), "node 4 in rg rg1, but indexed to rg \"\"")
+	suite.manager.groups["rg1"].nodes.Remove(4)
+
+	suite.manager.nodeToRG[4] = "rg2"
+	errs = suite.manager.CheckConsistency()
+	suite.Len(errs, 1)
+	suite.Contains(errs[0].Error(), "node 4 indexed to rg rg2, but not in any rg")
+	delete(suite.manager.nodeToRG, 4)
+
+	delete(suite.manager.groups, DefaultResourceGroupName)
+	delete(suite.manager.nodeToRG, 3)
+	errs = suite.manager.CheckConsistency()
+	suite.Len(errs, 1)
+	suite.Contains(errs[0].Error(), "default resource group doesn't exist")
+}
+
+func (suite *ResourceManagerSuite) TestFindResourceGroupByNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 3))
+
+	ret := suite.manager.FindResourceGroupByNodes([]int64{1, 2, 3, 4, 5})
+	suite.Equal(map[int64]string{
+		1: DefaultResourceGroupName,
+		2: "rg",
+		3: "rg",
+	}, ret)
+
+	suite.Empty(suite.manager.FindResourceGroupByNodes(nil))
+}
+
+func (suite *ResourceManagerSuite) TestAutoRecoverWithNotEnoughNodes() {
+	ctx := context.Background()
+	for i := 1; i <= 7; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	for i := 3; i <= 7; i++ {
+		suite.NoError(suite.manager.AssignNode(ctx, "rg", int64(i)))
+		suite.manager.HandleNodeDown(ctx, int64(i))
+	}
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 1))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 2))
+	suite.Equal(5, suite.manager.CheckLackOfNode("rg"))
+
+	recovered, _, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.Equal(2, recovered)
+	suite.Equal(3, suite.manager.CheckLackOfNode("rg"))
+	suite.True(suite.manager.ContainsNode("rg", 1))
+	suite.True(suite.manager.ContainsNode("rg", 2))
+}
+
+func (suite *ResourceManagerSuite) TestAutoRecoverWithDefaultRGReserve() {
+	ctx := context.Background()
+	for i := 1; i <= 8; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	for i := 4; i <= 8; i++ {
+		suite.NoError(suite.manager.AssignNode(ctx, "rg", int64(i)))
+		suite.manager.HandleNodeDown(ctx, int64(i))
+	}
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{1, 2, 3}))
+
+	key := Params.QueryCoordCfg.DefaultRGReservedNodeNum.Key
+	Params.BaseTable.Save(key, "2")
+	defer Params.BaseTable.Reset(key)
+
+	recovered, limited, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.Equal(1, recovered)
+	suite.True(limited)
+	suite.Len(suite.manager.groups[DefaultResourceGroupName].GetNodes(), 2)
+	suite.Equal(4, suite.manager.CheckLackOfNode("rg"))
+
+	// reserve reached, no more recovery
+	recovered, limited, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.Equal(0, recovered)
+	suite.True(limited)
+	suite.Len(suite.manager.groups[DefaultResourceGroupName].GetNodes(), 2)
+
+	Params.BaseTable.Save(key, "0")
+	recovered, limited, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.Equal(2, recovered)
+	suite.False(limited)
+	suite.Empty(suite.manager.groups[DefaultResourceGroupName].GetNodes())
+}
+
+func (suite *ResourceManagerSuite) TestAutoRecoverDryRun() {
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg", 3))
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{1, 2, 4, 5}))
+	suite.manager.nodeMgr.Remove(4)
+
+	_, err := suite.manager.AutoRecoverResourceGroupDryRun("rg1")
+	suite.ErrorIs(err, ErrRGNotExist)
+
+	snapshot := suite.manager.Snapshot()
+	nodes, err := suite.manager.AutoRecoverResourceGroupDryRun("rg")
+	suite.NoError(err)
+	suite.Equal([]int64{1, 2, 5}, nodes)
+	suite.Equal(snapshot, suite.manager.Snapshot())
+
+	// dry run is rejected the same way as the real recovery
+	suite.NoError(suite.manager.SealResourceGroup(ctx, "rg", true))
+	_, err = suite.manager.AutoRecoverResourceGroupDryRun("rg")
+	suite.ErrorIs(err, ErrRGSealed)
+	suite.NoError(suite.manager.SealResourceGroup(ctx, "rg", false))
+
+	// dry run should match the real recovery
+	recovered, _, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.Equal(3, recovered)
+	suite.ElementsMatch(nodes, suite.manager.groups["rg"].GetNodes())
+}
+
+func (suite *ResourceManagerSuite) TestAutoRecoverRollback() {
+	ctx := context.Background()
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
 	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
-	err := suite.manager.AddResourceGroup("rg")
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 2))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 3))
+	suite.manager.HandleNodeDown(ctx, 2)
+	suite.manager.HandleNodeDown(ctx, 3)
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 1))
+
+	defaultRG := suite.manager.groups[DefaultResourceGroupName]
+	oldCapacity := defaultRG.GetCapacity()
+	oldNodes := defaultRG.GetNodes()
+
+	// make handleNodeUp fail in hack way
+	suite.manager.groups["rg"].nodes.Insert(1)
+	_, _, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.ErrorIs(err, ErrNodeAlreadyAssign)
+
+	suite.Equal(oldCapacity, defaultRG.GetCapacity())
+	suite.ElementsMatch(oldNodes, defaultRG.GetNodes())
+	rgName, err := suite.manager.FindResourceGroupByNode(1)
 	suite.NoError(err)
-	suite.manager.AssignNode(DefaultResourceGroupName, 1)
-	suite.manager.AssignNode(DefaultResourceGroupName, 2)
-	suite.manager.AssignNode("rg", 3)
+	suite.Equal(DefaultResourceGroupName, rgName)
+}
 
-	suite.manager.HandleNodeDown(3)
-	lackNodes := suite.manager.CheckLackOfNode("rg")
-	suite.Equal(lackNodes, 1)
-	suite.manager.AutoRecoverResourceGroup("rg")
-	lackNodes = suite.manager.CheckLackOfNode("rg")
-	suite.Equal(lackNodes, 0)
+func (suite *ResourceManagerSuite) TestSubscribe() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	ch, cancel := suite.manager.Subscribe()
+
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	suite.NoError(suite.manager.UnassignNode(ctx, "rg", 1))
+	_, err := suite.manager.HandleNodeUp(ctx, 2)
+	suite.NoError(err)
+	_, err = suite.manager.HandleNodeDown(ctx, 2)
+	suite.NoError(err)
+	suite.NoError(suite.manager.RemoveResourceGroup(ctx, "rg"))
+
+	expected := []ResourceGroupEvent{
+		{RGName: "rg", Type: RGCreated},
+		{RGName: "rg", Type: NodeAdded, Node: 1},
+		{RGName: "rg", Type: NodeRemoved, Node: 1},
+		{RGName: DefaultResourceGroupName, Type: NodeAdded, Node: 2},
+		{RGName: DefaultResourceGroupName, Type: NodeRemoved, Node: 2},
+		{RGName: "rg", Type: RGRemoved},
+	}
+	for _, event := range expected {
+		suite.Equal(event, <-ch)
+	}
+
+	cancel()
+	_, ok := <-ch
+	suite.False(ok)
+	// cancel twice should be tolerable
+	cancel()
+
+	// no event should be sent if store write failed
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	ch, cancel = manager.Subscribe()
+	defer cancel()
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, "rg").Return(nil)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(errors.New("mock error"))
+	suite.Error(manager.AddResourceGroup(ctx, "rg"))
+	suite.Len(ch, 0)
+}
+
+func (suite *ResourceManagerSuite) TestStoreWriteRetry() {
+	ctx := context.Background()
+	key := Params.QueryCoordCfg.RGStoreRetryInterval.Key
+	Params.BaseTable.Save(key, "1")
+	defer Params.BaseTable.Reset(key)
+
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	storeErr := errors.New("etcd unavailable")
+
+	// transient failure is retried
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(storeErr).Times(2)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil).Once()
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg").Return(&querypb.ResourceGroup{Name: "rg"}, nil)
+	suite.NoError(manager.AddResourceGroup(ctx, "rg"))
+	suite.True(manager.ContainResourceGroup("rg"))
+
+	store.EXPECT().RemoveResourceGroup(mock.Anything, "rg").Return(storeErr).Times(2)
+	store.EXPECT().RemoveResourceGroup(mock.Anything, "rg").Return(nil).Once()
+	suite.NoError(manager.RemoveResourceGroup(ctx, "rg"))
+	suite.False(manager.ContainResourceGroup("rg"))
+
+	// give up after all attempts failed
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(storeErr).Times(3)
+	err := manager.AddResourceGroup(ctx, "rg1")
+	suite.ErrorIs(err, ErrSaveResourceGroupToStore)
+	suite.ErrorIs(err, storeErr)
+	suite.False(manager.ContainResourceGroup("rg1"))
+
+	// write is still tried once without retry
+	retryKey := Params.QueryCoordCfg.RGStoreRetryNum.Key
+	Params.BaseTable.Save(retryKey, "0")
+	defer Params.BaseTable.Reset(retryKey)
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(storeErr).Once()
+	err = manager.AddResourceGroup(ctx, "rg1")
+	suite.ErrorIs(err, ErrSaveResourceGroupToStore)
+	suite.ErrorIs(err, storeErr)
+	suite.False(manager.ContainResourceGroup("rg1"))
+}
+
+func (suite *ResourceManagerSuite) TestAddResourceGroupStoreMismatch() {
+	ctx := context.Background()
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+
+	// store reports success, but stored a different capacity
+	store.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg1").Return(&querypb.ResourceGroup{Name: "rg1", Capacity: 3}, nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg2").Return(nil, nil)
+	store.EXPECT().RemoveResourceGroup(mock.Anything, mock.Anything).Return(nil).Times(2)
+	err := manager.AddResourceGroup(ctx, "rg1")
+	suite.ErrorIs(err, ErrRGStoreMismatch)
+	suite.False(manager.ContainResourceGroup("rg1"))
+
+	// store reports success, but stored nothing
+	err = manager.AddResourceGroup(ctx, "rg2")
+	suite.ErrorIs(err, ErrRGStoreMismatch)
+	suite.False(manager.ContainResourceGroup("rg2"))
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupNotFoundError() {
+	ctx := context.Background()
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+
+	checkNotFound := func(err error, name string) {
+		suite.ErrorIs(err, ErrRGNotExist)
+		var notFound *ResourceGroupNotFoundError
+		suite.True(errors.As(err, &notFound))
+		suite.Equal(name, notFound.Name)
+		suite.Contains(err.Error(), ErrRGNotExist.Error())
+		suite.Contains(err.Error(), name)
+	}
+
+	_, err := suite.manager.GetNodes("rg2")
+	checkNotFound(err, "rg2")
+	_, err = suite.manager.GetResourceGroup("rg3")
+	checkNotFound(err, "rg3")
+	_, err = suite.manager.TransferNode(ctx, "rg1", "rg4")
+	checkNotFound(err, "rg4")
+	_, err = suite.manager.TransferNode(ctx, "rg5", "rg1")
+	checkNotFound(err, "rg5")
+	checkNotFound(suite.manager.AssignNode(ctx, "rg6", 1), "rg6")
+	checkNotFound(suite.manager.CloneResourceGroup(ctx, "rg7", "rg8"), "rg7")
+
+	// still matches after being wrapped by callers
+	checkNotFound(fmt.Errorf("failed to transfer node: %w", &ResourceGroupNotFoundError{Name: "rg9"}), "rg9")
+}
+
+func (suite *ResourceManagerSuite) TestDiffWithStore() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 3))
+
+	diffs, err := suite.manager.DiffWithStore(ctx)
+	suite.NoError(err)
+	suite.Empty(diffs)
+
+	// mutate memory and store separately, without persisting the change to the other side
+	suite.manager.groups["rg1"].nodes.Remove(2)
+	suite.manager.groups["rg1"].nodes.Insert(4)
+	suite.manager.groups["rg1"].capacity = 3
+	suite.manager.groups["rg3"] = NewResourceGroup(1)
+	suite.NoError(suite.manager.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{Name: "rg4", Capacity: 2, Nodes: []int64{5}}))
+	suite.NoError(suite.manager.store.RemoveResourceGroup(ctx, "rg2"))
+
+	diffs, err = suite.manager.DiffWithStore(ctx)
+	suite.NoError(err)
+	suite.Equal([]ResourceGroupDiff{
+		{
+			Name:                 "rg1",
+			InMemory:             true,
+			InStore:              true,
+			MemoryCapacity:       3,
+			StoredCapacity:       2,
+			NodesMissingInStore:  []int64{4},
+			NodesMissingInMemory: []int64{2},
+		},
+		{Name: "rg2", InMemory: true},
+		{Name: "rg3", InMemory: true, MemoryCapacity: 1},
+		{Name: "rg4", InStore: true, StoredCapacity: 2, NodesMissingInMemory: []int64{5}},
+	}, diffs)
+
+	suite.manager.store = NewMockStore(suite.T())
+	storeErr := errors.New("mock error")
+	suite.manager.store.(*MockStore).EXPECT().GetResourceGroups(mock.Anything).Return(nil, storeErr)
+	_, err = suite.manager.DiffWithStore(ctx)
+	suite.ErrorIs(err, storeErr)
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupProportion() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{1, 2, 3, 4}))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+
+	suite.ErrorIs(suite.manager.SetResourceGroupProportion(ctx, "rg1", 1.5), ErrRGProportionInvalid)
+	suite.ErrorIs(suite.manager.SetResourceGroupProportion(ctx, "rg1", -0.1), ErrRGProportionInvalid)
+	suite.ErrorIs(suite.manager.SetResourceGroupProportion(ctx, DefaultResourceGroupName, 0.5), ErrRGProportionInvalid)
+	suite.ErrorIs(suite.manager.SetResourceGroupProportion(ctx, "rg2", 0.5), ErrRGNotExist)
+
+	suite.NoError(suite.manager.SetResourceGroupProportion(ctx, "rg1", 0.5))
+	num, err := suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(2, num)
+	suite.Equal(2, suite.manager.groups["rg1"].GetCapacity())
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 2)
+
+	// target scales along with the cluster
+	for i := 5; i <= 8; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{5, 6, 7, 8}))
+	num, err = suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(2, num)
+	suite.Equal(4, suite.manager.groups["rg1"].GetCapacity())
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 4)
+
+	// stopping nodes are not counted, and capacity never drops below node num
+	suite.manager.nodeMgr.Stopping(8)
+	suite.manager.nodeMgr.Stopping(7)
+	suite.manager.nodeMgr.Stopping(6)
+	_, err = suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(4, suite.manager.groups["rg1"].GetCapacity())
+
+	// proportion survives restart
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(0.5, manager.groups["rg1"].proportion)
+	suite.Equal(4, manager.groups["rg1"].GetCapacity())
+
+	// fixed capacity doesn't follow the cluster anymore
+	suite.NoError(suite.manager.SetResourceGroupProportion(ctx, "rg1", 0))
+	for i := 9; i <= 12; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	_, err = suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(4, suite.manager.groups["rg1"].GetCapacity())
+
+	// proportion record is removed along with rg
+	suite.NoError(suite.manager.SetResourceGroupProportion(ctx, "rg1", 0.3))
+	suite.NoError(suite.manager.RemoveAllNodes(ctx, "rg1"))
+	suite.NoError(suite.manager.RemoveResourceGroup(ctx, "rg1"))
+	proportions, err := suite.manager.store.GetResourceGroupProportions(ctx)
+	suite.NoError(err)
+	suite.Empty(proportions)
+}
+
+func (suite *ResourceManagerSuite) TestApplySpec() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg3"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg4"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg4", 1))
+
+	// invalid spec changes nothing
+	err := suite.manager.ApplySpec(ctx, []ResourceGroupSpec{{Name: "rg1", Capacity: 2}, {Name: "rg2", Capacity: -1}}, false)
+	suite.ErrorIs(err, ErrRGCapacityInvalid)
+	err = suite.manager.ApplySpec(ctx, []ResourceGroupSpec{{Name: "rg1", Capacity: 2}, {Name: "rg1", Capacity: 1}}, false)
+	suite.ErrorIs(err, ErrRGNameInvalid)
+	err = suite.manager.ApplySpec(ctx, []ResourceGroupSpec{{Name: DefaultResourceGroupName, Capacity: 1}}, false)
+	suite.ErrorIs(err, ErrRGNameInvalid)
+	err = suite.manager.ApplySpec(ctx, []ResourceGroupSpec{{Name: "rg1", Capacity: 2}, {Name: "rg4", Capacity: 0}}, false)
+	suite.ErrorIs(err, ErrCapacityBelowNodeCount)
+	err = suite.manager.ApplySpec(ctx, []ResourceGroupSpec{{Name: "rg1", Capacity: 2}}, true)
+	suite.ErrorIs(err, ErrDeleteNonEmptyRG)
+	suite.Equal(0, suite.manager.groups["rg1"].GetCapacity())
+	suite.NotNil(suite.manager.groups["rg3"])
+
+	spec := []ResourceGroupSpec{
+		{Name: "rg1", Capacity: 2},
+		{Name: "rg2", Capacity: 1},
+		{Name: "rg4", Capacity: 3},
+	}
+	suite.NoError(suite.manager.ApplySpec(ctx, spec, true))
+	suite.ElementsMatch([]string{DefaultResourceGroupName, "rg1", "rg2", "rg4"}, suite.manager.ListResourceGroups())
+	suite.Equal(2, suite.manager.groups["rg1"].GetCapacity())
+	suite.Equal(1, suite.manager.groups["rg2"].GetCapacity())
+	suite.Equal(3, suite.manager.groups["rg4"].GetCapacity())
+	suite.ElementsMatch([]int64{1}, suite.manager.groups["rg4"].GetNodes())
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch(suite.manager.ListResourceGroups(), manager.ListResourceGroups())
+	for _, rgSpec := range spec {
+		suite.Equal(rgSpec.Capacity, manager.groups[rgSpec.Name].GetCapacity())
+	}
+
+	// applying the same spec again performs no store writes
+	snapshot := suite.manager.Snapshot()
+	suite.manager.store = NewMockStore(suite.T())
+	suite.NoError(suite.manager.ApplySpec(ctx, spec, true))
+	suite.Equal(snapshot, suite.manager.Snapshot())
+}
+
+func (suite *ResourceManagerSuite) TestGetClusterSummary() {
+	ctx := context.Background()
+	for i := 1; i <= 6; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.Equal(ClusterSummary{ResourceGroupNum: 1, UnassignedNodes: 6}, suite.manager.GetClusterSummary())
+
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg2", 3))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 3))
+	suite.manager.nodeMgr.Remove(2)
+	suite.manager.nodeMgr.Stopping(6)
+
+	suite.Equal(ClusterSummary{
+		ResourceGroupNum: 3,
+		TotalCapacity:    5,
+		AssignedNodes:    2,
+		UnassignedNodes:  2,
+		TotalLacking:     4,
+	}, suite.manager.GetClusterSummary())
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupAssignNodes() {
+	rg := NewResourceGroup(1)
+	suite.NoError(rg.assignNodes([]int64{1, 2}))
+	suite.ElementsMatch([]int64{1, 2}, rg.GetNodes())
+	suite.Equal(3, rg.GetCapacity())
+
+	// duplicated nodes are reported, the others are still assigned
+	err := rg.assignNodes([]int64{2, 3, 3})
+	suite.ErrorIs(err, ErrNodeAlreadyAssign)
+	suite.Len(multierr.Errors(err), 2)
+	suite.Contains(err.Error(), "node=2")
+	suite.ElementsMatch([]int64{1, 2, 3}, rg.GetNodes())
+	suite.Equal(4, rg.GetCapacity())
+	suite.Equal(1, rg.LackOfNodes())
+
+	suite.NoError(rg.assignNodes(nil))
+	suite.Equal(4, rg.GetCapacity())
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupUnassignNodes() {
+	rg := NewResourceGroup(0)
+	suite.NoError(rg.assignNodes([]int64{1, 2, 3}))
+	suite.NoError(rg.unassignNodes([]int64{1}))
+	suite.ElementsMatch([]int64{2, 3}, rg.GetNodes())
+	suite.Equal(2, rg.GetCapacity())
+
+	// non members and duplicated nodes are reported, the others are still unassigned
+	err := rg.unassignNodes([]int64{4, 2, 2})
+	suite.ErrorIs(err, ErrNodeNotAssignToRG)
+	suite.Len(multierr.Errors(err), 2)
+	suite.Contains(err.Error(), "node=4")
+	suite.ElementsMatch([]int64{3}, rg.GetNodes())
+	suite.Equal(1, rg.GetCapacity())
+
+	// capacity never drops below 0
+	rg.capacity = 0
+	suite.NoError(rg.unassignNodes([]int64{3}))
+	suite.Empty(rg.GetNodes())
+	suite.Equal(0, rg.GetCapacity())
+}
+
+func (suite *ResourceManagerSuite) TestAssignNodesToNewResourceGroup() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+
+	suite.NoError(suite.manager.AssignNodesToNewResourceGroup(ctx, "rg1", []int64{1, 2}))
+	rg, err := suite.manager.GetResourceGroup("rg1")
+	suite.NoError(err)
+	suite.Equal(2, rg.GetCapacity())
+	suite.ElementsMatch([]int64{1, 2}, rg.GetNodes())
+	rgName, err := suite.manager.FindResourceGroupByNode(2)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.ElementsMatch([]int64{1, 2}, manager.groups["rg1"].GetNodes())
+	suite.Equal(2, manager.groups["rg1"].GetCapacity())
+
+	// rg already exists
+	err = suite.manager.AssignNodesToNewResourceGroup(ctx, "rg1", []int64{3})
+	suite.ErrorIs(err, ErrRGAlreadyExist)
+	suite.False(suite.manager.ContainsNode("rg1", 3))
+
+	// invalid nodes, nothing changes
+	err = suite.manager.AssignNodesToNewResourceGroup(ctx, "rg2", []int64{3, 5})
+	suite.ErrorIs(err, ErrNodeNotExist)
+	suite.False(suite.manager.ContainResourceGroup("rg2"))
+	err = suite.manager.AssignNodesToNewResourceGroup(ctx, "rg2", []int64{3, 1})
+	suite.ErrorIs(err, ErrNodeAlreadyAssign)
+	suite.False(suite.manager.ContainResourceGroup("rg2"))
+	err = suite.manager.AssignNodesToNewResourceGroup(ctx, "rg2", []int64{3, 3})
+	suite.ErrorIs(err, ErrNodeAlreadyAssign)
+	suite.False(suite.manager.ContainResourceGroup("rg2"))
+	suite.Equal([]int64{3, 4}, suite.manager.ListUnassignedNodes())
+
+	// rg is created with its nodes in a single store write
+	store := NewMockStore(suite.T())
+	manager = NewResourceManager(store, suite.manager.nodeMgr)
+	store.EXPECT().SaveResourceGroup(mock.Anything, &querypb.ResourceGroup{
+		Name:     "rg3",
+		Capacity: 2,
+		Nodes:    []int64{3, 4},
+	}).Return(nil).Once()
+	store.EXPECT().RemoveResourceGroupAttributes(mock.Anything, mock.Anything).Return(nil)
+	store.EXPECT().GetResourceGroup(mock.Anything, "rg3").Return(&querypb.ResourceGroup{Name: "rg3", Capacity: 2, Nodes: []int64{4, 3}}, nil)
+	store.EXPECT().SaveNodeResourceGroup(mock.Anything, mock.Anything, "rg3").Return(nil)
+	suite.NoError(manager.AssignNodesToNewResourceGroup(ctx, "rg3", []int64{3, 4}))
+	suite.ElementsMatch([]int64{3, 4}, manager.groups["rg3"].GetNodes())
+}
+
+func (suite *ResourceManagerSuite) TestResourceManagerView() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+
+	var view ResourceManagerView = suite.manager
+	suite.True(view.ContainsNode("rg", 1))
+	rgName, err := view.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg", rgName)
+	nodes, err := view.GetNodes("rg")
+	suite.NoError(err)
+	suite.Equal([]int64{1}, nodes)
+	suite.Equal(0, view.CheckLackOfNode("rg"))
+}
+
+func (suite *ResourceManagerSuite) TestNodeCountHistory() {
+	ctx := context.Background()
+	key := Params.QueryCoordCfg.RGNodeCountHistorySize.Key
+	Params.BaseTable.Save(key, "4")
+	defer Params.BaseTable.Reset(key)
+
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.Empty(suite.manager.GetNodeCountHistory("rg"))
+
+	nodeCounts := func() []int {
+		return lo.Map(suite.manager.GetNodeCountHistory("rg"), func(sample NodeCountSample, _ int) int {
+			return sample.NodeCount
+		})
+	}
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 2))
+	_, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+	suite.Equal([]int{1, 2, 1}, nodeCounts())
+
+	// oldest sample is dropped once the buffer is full
+	_, err = suite.manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	suite.NoError(suite.manager.UnassignNode(ctx, "rg", 2))
+	suite.Equal([]int{2, 1, 2, 1}, nodeCounts())
+
+	history := suite.manager.GetNodeCountHistory("rg")
+	for i := 1; i < len(history); i++ {
+		suite.False(history[i].Timestamp.Before(history[i-1].Timestamp))
+	}
+	suite.Nil(suite.manager.GetNodeCountHistory("rg1"))
+
+	// history starts over after recover
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Len(manager.GetNodeCountHistory("rg"), 1)
+	suite.Equal(1, manager.GetNodeCountHistory("rg")[0].NodeCount)
+}
+
+func (suite *ResourceManagerSuite) TestOnLack() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2, 3}))
+
+	type lackEvent struct {
+		rgName string
+		lack   int
+	}
+	events := make(chan lackEvent, 10)
+	suite.manager.OnLack(func(rgName string, lack int) {
+		// listener could re-enter the manager
+		suite.manager.CheckLackOfNode(rgName)
+		events <- lackEvent{rgName, lack}
+	})
+
+	_, err := suite.manager.HandleNodeDown(ctx, 1)
+	suite.NoError(err)
+	select {
+	case event := <-events:
+		suite.Equal(lackEvent{"rg", 1}, event)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("lack listener not called")
+	}
+
+	// rg is already lacking, no more callback
+	suite.manager.nodeMgr.Remove(2)
+	suite.Equal(2, suite.manager.CheckLackOfNode("rg"))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 1))
+	recovered, err := suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(1, recovered)
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 2))
+	recovered, err = suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(1, recovered)
+	suite.Len(events, 0)
+
+	// pruning down node triggers callback once rg is fully provisioned again
+	suite.Equal(0, suite.manager.CheckLackOfNode("rg"))
+	suite.manager.nodeMgr.Remove(3)
+	suite.Equal(1, suite.manager.CheckLackOfNode("rg"))
+	_, err = suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	select {
+	case event := <-events:
+		suite.Equal(lackEvent{"rg", 1}, event)
+	case <-time.After(5 * time.Second):
+		suite.FailNow("lack listener not called")
+	}
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupMetrics() {
+	ctx := context.Background()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(metrics.QueryCoordResourceGroupCapacity)
+	registry.MustRegister(metrics.QueryCoordResourceGroupNodeNum)
+	registry.MustRegister(metrics.QueryCoordResourceGroupLackNodeNum)
+	defer registry.Unregister(metrics.QueryCoordResourceGroupCapacity)
+	defer registry.Unregister(metrics.QueryCoordResourceGroupNodeNum)
+	defer registry.Unregister(metrics.QueryCoordResourceGroupLackNodeNum)
+
+	gauge := func(name string, rgName string) (float64, bool) {
+		families, err := registry.Gather()
+		suite.Require().NoError(err)
+		for _, family := range families {
+			if family.GetName() != name {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "resource_group" && label.GetValue() == rgName {
+						return metric.GetGauge().GetValue(), true
+					}
+				}
+			}
+		}
+		return 0, false
+	}
+	checkMetrics := func(rgName string) {
+		stats, err := suite.manager.GetResourceGroupStats(rgName)
+		suite.NoError(err)
+		capacity, ok := gauge("milvus_querycoord_resource_group_capacity", rgName)
+		suite.True(ok)
+		suite.Equal(float64(stats.Capacity), capacity)
+		nodeNum, ok := gauge("milvus_querycoord_resource_group_node_num", rgName)
+		suite.True(ok)
+		suite.Equal(float64(stats.AssignedNodes), nodeNum)
+		lackNum, ok := gauge("milvus_querycoord_resource_group_lack_node_num", rgName)
+		suite.True(ok)
+		suite.Equal(float64(stats.LackingNodes), lackNum)
+	}
+
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(3, "localhost"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	checkMetrics("rg")
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg", 2))
+	checkMetrics("rg")
+	suite.manager.HandleNodeDown(ctx, 1)
+	checkMetrics("rg")
+	suite.manager.HandleNodeUp(ctx, 3)
+	_, err := suite.manager.TransferNode(ctx, "rg", DefaultResourceGroupName)
+	suite.NoError(err)
+	checkMetrics("rg")
+	checkMetrics(DefaultResourceGroupName)
+	_, _, err = suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	checkMetrics("rg")
+	checkMetrics(DefaultResourceGroupName)
+	nodes, err := suite.manager.GetNodes("rg")
+	suite.NoError(err)
+	for _, node := range nodes {
+		suite.NoError(suite.manager.UnassignNode(ctx, "rg", node))
+	}
+	checkMetrics("rg")
+
+	suite.NoError(suite.manager.RemoveResourceGroup(ctx, "rg"))
+	_, ok := gauge("milvus_querycoord_resource_group_capacity", "rg")
+	suite.False(ok)
+}
+
+func (suite *ResourceManagerSuite) TestTransferNodeToFullResourceGroup() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroupWithLimit(ctx, "rg2", 1))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg2", 3))
+	// down node isn't pruned yet, pruning it would write store
+	suite.manager.nodeMgr.Remove(2)
+
+	// any store write fails the test
+	suite.manager.store = NewMockStore(suite.T())
+	_, err := suite.manager.TransferNode(ctx, "rg1", "rg2")
+	suite.ErrorIs(err, ErrRGCapacityExceeded)
+	err = suite.manager.TransferNodes(ctx, "rg1", "rg2", 1)
+	suite.ErrorIs(err, ErrRGCapacityExceeded)
+	err = suite.manager.TransferSpecificNode(ctx, "rg1", "rg2", 1)
+	suite.ErrorIs(err, ErrRGCapacityExceeded)
+
+	suite.ElementsMatch([]int64{1, 2}, suite.manager.groups["rg1"].GetNodes())
+	suite.Equal(2, suite.manager.groups["rg1"].GetCapacity())
+	suite.ElementsMatch([]int64{3}, suite.manager.groups["rg2"].GetNodes())
+	rgName, err := suite.manager.FindResourceGroupByNode(1)
+	suite.NoError(err)
+	suite.Equal("rg1", rgName)
+}
+
+func (suite *ResourceManagerSuite) TestResourceGroupMaxCapacity() {
+	ctx := context.Background()
+	for i := 1; i <= 4; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	err := suite.manager.AddResourceGroupWithLimit(ctx, "rg1", -1)
+	suite.ErrorIs(err, ErrRGMaxCapacityInvalid)
+	suite.NoError(suite.manager.AddResourceGroupWithLimit(ctx, "rg1", 2))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+
+	// fill rg1 to its max capacity
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 1))
+	suite.NoError(suite.manager.AssignNode(ctx, "rg1", 2))
+	err = suite.manager.AssignNode(ctx, "rg1", 3)
+	suite.ErrorIs(err, ErrRGCapacityExceeded)
+	err = suite.manager.AssignNodes(ctx, "rg1", []int64{3})
+	suite.ErrorIs(err, ErrRGCapacityExceeded)
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg2", []int64{3, 4}))
+	_, err = suite.manager.TransferNode(ctx, "rg2", "rg1")
+	suite.ErrorIs(err, ErrRGCapacityExceeded)
+	suite.Equal(2, suite.manager.groups["rg1"].GetCapacity())
+	suite.Equal(2, suite.manager.groups["rg2"].GetCapacity())
+
+	// update limit
+	err = suite.manager.SetResourceGroupLimit(ctx, "rg1", -1)
+	suite.ErrorIs(err, ErrRGMaxCapacityInvalid)
+	err = suite.manager.SetResourceGroupLimit(ctx, DefaultResourceGroupName, 1)
+	suite.ErrorIs(err, ErrRGMaxCapacityInvalid)
+	err = suite.manager.SetResourceGroupLimit(ctx, "rg3", 1)
+	suite.ErrorIs(err, ErrRGNotExist)
+	suite.NoError(suite.manager.SetResourceGroupLimit(ctx, "rg1", 3))
+	_, err = suite.manager.TransferNode(ctx, "rg2", "rg1")
+	suite.NoError(err)
+	_, err = suite.manager.TransferNode(ctx, "rg2", "rg1")
+	suite.ErrorIs(err, ErrRGCapacityExceeded)
+
+	// limit should be recovered
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(3, manager.groups["rg1"].GetMaxCapacity())
+	suite.Equal(0, manager.groups["rg2"].GetMaxCapacity())
+
+	// remove limit
+	suite.NoError(manager.SetResourceGroupLimit(ctx, "rg1", 0))
+	_, err = manager.TransferNode(ctx, "rg2", "rg1")
+	suite.NoError(err)
+	suite.Equal(4, manager.groups["rg1"].GetCapacity())
+
+	// limit below capacity shrinks capacity, nodes stay until shed
+	suite.NoError(manager.SetResourceGroupLimit(ctx, "rg1", 2))
+	suite.Equal(2, manager.groups["rg1"].GetCapacity())
+	suite.Len(manager.groups["rg1"].GetNodes(), 4)
+	manager = NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(2, manager.groups["rg1"].GetMaxCapacity())
+	suite.Equal(2, manager.groups["rg1"].GetCapacity())
+
+	// limit below min capacity is rejected
+	suite.NoError(manager.SetResourceGroupMinCapacity(ctx, "rg1", 2))
+	err = manager.SetResourceGroupLimit(ctx, "rg1", 1)
+	suite.ErrorIs(err, ErrRGMaxCapacityInvalid)
+	suite.Equal(2, manager.groups["rg1"].GetMaxCapacity())
+}
+
+func (suite *ResourceManagerSuite) TestStaleResourceGroupAttributes() {
+	ctx := context.Background()
+	store := suite.manager.store
+
+	// limit written by a failed add is removed
+	mockStore := NewMockStore(suite.T())
+	mockStore.EXPECT().SaveResourceGroupLimit(mock.Anything, "rg1", int32(3)).
+		Run(func(ctx context.Context, rgName string, maxCapacity int32) {
+			suite.NoError(store.SaveResourceGroupLimit(ctx, rgName, maxCapacity))
+		}).Return(nil)
+	mockStore.EXPECT().RemoveResourceGroupAttributes(mock.Anything, "rg1").
+		Run(func(ctx context.Context, rgName string) {
+			suite.NoError(store.RemoveResourceGroupAttributes(ctx, rgName))
+		}).Return(nil)
+	mockStore.EXPECT().SaveResourceGroup(mock.Anything, mock.Anything).Return(errors.New("mock error"))
+	suite.manager.store = mockStore
+	suite.Error(suite.manager.AddResourceGroupWithLimit(ctx, "rg1", 3))
+	suite.manager.store = store
+	mockStore.AssertNumberOfCalls(suite.T(), "RemoveResourceGroupAttributes", 2)
+	limits, err := store.GetResourceGroupLimits(ctx)
+	suite.NoError(err)
+	suite.NotContains(limits, "rg1")
+
+	// records left by a former rg never attach to a new rg with the same name
+	suite.NoError(store.SaveResourceGroupLimit(ctx, "rg2", 3))
+	suite.NoError(store.SaveResourceGroupLabels(ctx, "rg2", map[string]string{"zone": "a"}))
+	suite.NoError(store.SaveSealedResourceGroup(ctx, "rg2"))
+	suite.NoError(store.SaveResourceGroupProportion(ctx, "rg2", 0.5))
+	suite.NoError(store.SaveResourceGroupMinCapacity(ctx, "rg2", 1))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg2"))
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	for _, rgName := range []string{"rg1", "rg2"} {
+		suite.Equal(0, manager.groups[rgName].GetMaxCapacity())
+		suite.Empty(manager.groups[rgName].GetLabels())
+		suite.False(manager.groups[rgName].IsSealed())
+		suite.Zero(manager.groups[rgName].proportion)
+		suite.Equal(0, manager.groups[rgName].GetMinCapacity())
+	}
+}
+
+func (suite *ResourceManagerSuite) TestAutoRecoverAllWithMinCapacity() {
+	ctx := context.Background()
+	for i := 1; i <= 12; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.AddResourceGroupWithLimit(ctx, "rg2", 3))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg1", []int64{1, 2, 3}))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg2", []int64{4, 5, 6}))
+	for i := 1; i <= 6; i++ {
+		suite.manager.HandleNodeDown(ctx, int64(i))
+	}
+
+	err := suite.manager.SetResourceGroupMinCapacity(ctx, DefaultResourceGroupName, 1)
+	suite.ErrorIs(err, ErrRGMinCapacityInvalid)
+	err = suite.manager.SetResourceGroupMinCapacity(ctx, "rg1", -1)
+	suite.ErrorIs(err, ErrRGMinCapacityInvalid)
+	err = suite.manager.SetResourceGroupMinCapacity(ctx, "rg2", 4)
+	suite.ErrorIs(err, ErrRGMinCapacityInvalid)
+	err = suite.manager.SetResourceGroupMinCapacity(ctx, "rg3", 1)
+	suite.ErrorIs(err, ErrRGNotExist)
+	suite.NoError(suite.manager.SetResourceGroupMinCapacity(ctx, "rg1", 1))
+	suite.NoError(suite.manager.SetResourceGroupMinCapacity(ctx, "rg2", 3))
+
+	// min capacity should be recovered
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(1, manager.groups["rg1"].GetMinCapacity())
+	suite.Equal(3, manager.groups["rg2"].GetMinCapacity())
+	suite.NoError(manager.SetResourceGroupMinCapacity(ctx, "rg1", 0))
+	manager = NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(0, manager.groups["rg1"].GetMinCapacity())
+	suite.NoError(suite.manager.SetResourceGroupMinCapacity(ctx, "rg1", 1))
+
+	// rg2 has larger deficit, so it should be filled first
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{7, 8}))
+	recovered, err := suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(2, recovered)
+	suite.ElementsMatch([]int64{7, 8}, suite.manager.groups["rg2"].GetNodes())
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 0)
+
+	// min capacity is satisfied first, then top up the others
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{9, 10, 11, 12}))
+	recovered, err = suite.manager.AutoRecoverAll(ctx)
+	suite.NoError(err)
+	suite.Equal(4, recovered)
+	suite.Equal(0, suite.manager.CheckLackOfNode("rg1"))
+	suite.Equal(0, suite.manager.CheckLackOfNode("rg2"))
+	suite.Len(suite.manager.groups[DefaultResourceGroupName].GetNodes(), 0)
+}
+
+func (suite *ResourceManagerSuite) TestSnapshot() {
+	ctx := context.Background()
+	for i := 1; i <= 100; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroupWithLimit(ctx, "rg", 100))
+	suite.NoError(suite.manager.SetResourceGroupMinCapacity(ctx, "rg", 10))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 100; i++ {
+			suite.manager.AssignNode(ctx, "rg", int64(i))
+		}
+	}()
+
+	// snapshot should never see a half assigned node
+	for i := 0; i < 100; i++ {
+		snapshot := suite.manager.Snapshot()
+		suite.Len(snapshot, 2)
+		suite.Equal(snapshot["rg"].Capacity, len(snapshot["rg"].Nodes))
+	}
+	wg.Wait()
+
+	snapshot := suite.manager.Snapshot()
+	suite.Equal(100, snapshot["rg"].Capacity)
+	suite.Equal(100, snapshot["rg"].MaxCapacity)
+	suite.Equal(10, snapshot["rg"].MinCapacity)
+	suite.Len(snapshot["rg"].Nodes, 100)
+	suite.True(sort.SliceIsSorted(snapshot["rg"].Nodes, func(i, j int) bool {
+		return snapshot["rg"].Nodes[i] < snapshot["rg"].Nodes[j]
+	}))
+	suite.Len(snapshot[DefaultResourceGroupName].Nodes, 0)
+}
+
+type mockReplicaHolder struct {
+	replicas map[string][]*Replica
+}
+
+func (h *mockReplicaHolder) GetByResourceGroup(rgName string) []*Replica {
+	return h.replicas[rgName]
+}
+
+func (suite *ResourceManagerSuite) TestRemoveResourceGroupInUse() {
+	ctx := context.Background()
+	holder := &mockReplicaHolder{replicas: make(map[string][]*Replica)}
+	suite.manager.SetReplicaHolder(holder)
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	holder.replicas["rg"] = []*Replica{
+		NewReplica(&querypb.Replica{ID: 1, CollectionID: 100, ResourceGroup: "rg"}, typeutil.NewUniqueSet()),
+	}
+
+	err := suite.manager.RemoveResourceGroup(ctx, "rg")
+	suite.ErrorIs(err, ErrRGInUse)
+	suite.Contains(err.Error(), "100")
+	suite.True(suite.manager.ContainResourceGroup("rg"))
+
+	// replica released
+	delete(holder.replicas, "rg")
+	suite.NoError(suite.manager.RemoveResourceGroup(ctx, "rg"))
+	suite.False(suite.manager.ContainResourceGroup("rg"))
+}
+
+func (suite *ResourceManagerSuite) TestRemoveResourceGroupForce() {
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+	}
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg"))
+	suite.NoError(suite.manager.AssignNodes(ctx, "rg", []int64{1, 2}))
+	suite.NoError(suite.manager.AssignNode(ctx, DefaultResourceGroupName, 3))
+
+	// safe remove is unchanged
+	suite.ErrorIs(suite.manager.RemoveResourceGroup(ctx, "rg"), ErrDeleteNonEmptyRG)
+	suite.ErrorIs(suite.manager.RemoveResourceGroupForce(ctx, DefaultResourceGroupName), ErrDeleteDefaultRG)
+
+	suite.NoError(suite.manager.RemoveResourceGroupForce(ctx, "rg"))
+	suite.False(suite.manager.ContainResourceGroup("rg"))
+	nodes, err := suite.manager.GetNodes(DefaultResourceGroupName)
+	suite.NoError(err)
+	suite.ElementsMatch([]int64{1, 2, 3}, nodes)
+	for i := 1; i <= 3; i++ {
+		rgName, err := suite.manager.FindResourceGroupByNode(int64(i))
+		suite.NoError(err)
+		suite.Equal(DefaultResourceGroupName, rgName)
+	}
+
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.False(manager.ContainResourceGroup("rg"))
+	suite.ElementsMatch([]int64{1, 2, 3}, manager.groups[DefaultResourceGroupName].GetNodes())
+
+	// remove non-exist rg should be tolerable
+	suite.NoError(suite.manager.RemoveResourceGroupForce(ctx, "rg"))
+
+	// rg in use can't be removed even by force
+	holder := &mockReplicaHolder{replicas: make(map[string][]*Replica)}
+	suite.manager.SetReplicaHolder(holder)
+	suite.NoError(suite.manager.AddResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.manager.TransferNodes(ctx, DefaultResourceGroupName, "rg1", 1))
+	holder.replicas["rg1"] = []*Replica{
+		NewReplica(&querypb.Replica{ID: 1, CollectionID: 100, ResourceGroup: "rg1"}, typeutil.NewUniqueSet()),
+	}
+	suite.ErrorIs(suite.manager.RemoveResourceGroupForce(ctx, "rg1"), ErrRGInUse)
+	suite.Len(suite.manager.groups["rg1"].GetNodes(), 1)
+}
+
+func (suite *ResourceManagerSuite) TestCustomDefaultResourceGroupName() {
+	ctx := context.Background()
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+
+	manager := NewResourceManagerWithDefaultRG(NewMetaStore(suite.kv), suite.manager.nodeMgr, "custom_default")
+	suite.Equal("custom_default", manager.GetDefaultResourceGroupName())
+	suite.True(manager.ContainResourceGroup("custom_default"))
+	suite.False(manager.ContainResourceGroup(DefaultResourceGroupName))
+
+	rgName, err := manager.HandleNodeUp(ctx, 1)
+	suite.NoError(err)
+	suite.Equal("custom_default", rgName)
+	suite.True(manager.ContainsNode("custom_default", 1))
+	_, err = manager.HandleNodeUp(ctx, 2)
+	suite.NoError(err)
+
+	suite.NoError(manager.AddResourceGroupWithCapacity(ctx, "rg", 1))
+	recovered, _, err := manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.Equal(1, recovered)
+	suite.Len(manager.groups["custom_default"].GetNodes(), 1)
+
+	suite.ErrorIs(manager.RemoveResourceGroup(ctx, "custom_default"), ErrDeleteDefaultRG)
+	suite.ErrorIs(manager.AddResourceGroup(ctx, "custom_default"), ErrRGNameInvalid)
+
+	// empty name falls back to the built-in one
+	manager = NewResourceManagerWithDefaultRG(NewMetaStore(suite.kv), suite.manager.nodeMgr, "")
+	suite.Equal(DefaultResourceGroupName, manager.GetDefaultResourceGroupName())
+}
+
+func (suite *ResourceManagerSuite) TestRebalanceFromDefault() {
+	ctx := context.Background()
+	for i := 1; i <= 7; i++ {
+		suite.manager.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
+		_, err := suite.manager.HandleNodeUp(ctx, int64(i))
+		suite.NoError(err)
+	}
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg1", 3))
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg2", 2))
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg3", 1))
+
+	key := Params.QueryCoordCfg.DefaultRGReservedNodeNum.Key
+	Params.BaseTable.Save(key, "3")
+	defer Params.BaseTable.Reset(key)
+
+	// 4 spare nodes for 6 lacking ones
+	added, err := suite.manager.RebalanceFromDefault(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]int{"rg1": 2, "rg2": 1, "rg3": 1}, added)
+	suite.Len(suite.manager.groups[DefaultResourceGroupName].GetNodes(), 3)
+	suite.Equal(1, suite.manager.CheckLackOfNode("rg1"))
+	suite.Equal(1, suite.manager.CheckLackOfNode("rg2"))
+	suite.Equal(0, suite.manager.CheckLackOfNode("rg3"))
+
+	// reserve reached
+	added, err = suite.manager.RebalanceFromDefault(ctx)
+	suite.NoError(err)
+	suite.Empty(added)
+
+	Params.BaseTable.Save(key, "0")
+	added, err = suite.manager.RebalanceFromDefault(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]int{"rg1": 1, "rg2": 1}, added)
+	suite.Len(suite.manager.groups[DefaultResourceGroupName].GetNodes(), 1)
+	suite.Empty(suite.manager.CheckConsistency())
+}
+
+func (suite *ResourceManagerSuite) TestAddResourceGroupWithCapacity() {
+	ctx := context.Background()
+	err := suite.manager.AddResourceGroupWithCapacity(ctx, "rg", -1)
+	suite.ErrorIs(err, ErrRGCapacityInvalid)
+
+	// new create
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg", 2))
+	suite.Equal(2, suite.manager.CheckLackOfNode("rg"))
+
+	// same capacity
+	suite.NoError(suite.manager.AddResourceGroupWithCapacity(ctx, "rg", 2))
+	suite.Equal(2, suite.manager.groups["rg"].GetCapacity())
+
+	// different capacity
+	err = suite.manager.AddResourceGroupWithCapacity(ctx, "rg", 3)
+	suite.ErrorIs(err, ErrRGAlreadyExist)
+	suite.Equal(2, suite.manager.groups["rg"].GetCapacity())
+
+	// capacity should be persisted
+	manager := NewResourceManager(NewMetaStore(suite.kv), suite.manager.nodeMgr)
+	suite.NoError(manager.Recover(ctx))
+	suite.Equal(2, manager.CheckLackOfNode("rg"))
+
+	// lacking nodes should be populated by auto recover
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(2, "localhost"))
+	suite.NoError(suite.manager.AssignNodes(ctx, DefaultResourceGroupName, []int64{1, 2}))
+	recovered, _, err := suite.manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.NoError(err)
+	suite.Equal(2, recovered)
+	suite.Equal(0, suite.manager.CheckLackOfNode("rg"))
+}
+
+func (suite *ResourceManagerSuite) TestCanceledContext() {
+	// no store write is expected with a canceled context
+	store := NewMockStore(suite.T())
+	manager := NewResourceManager(store, suite.manager.nodeMgr)
+	suite.manager.nodeMgr.Add(session.NewNodeInfo(1, "localhost"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	suite.ErrorIs(manager.AddResourceGroup(ctx, "rg"), context.Canceled)
+	suite.ErrorIs(manager.RemoveResourceGroup(ctx, "rg"), context.Canceled)
+	suite.ErrorIs(manager.AssignNode(ctx, DefaultResourceGroupName, 1), context.Canceled)
+	suite.ErrorIs(manager.UnassignNode(ctx, DefaultResourceGroupName, 1), context.Canceled)
+	_, err := manager.TransferNode(ctx, DefaultResourceGroupName, "rg")
+	suite.ErrorIs(err, context.Canceled)
+	_, _, err = manager.AutoRecoverResourceGroup(ctx, "rg")
+	suite.ErrorIs(err, context.Canceled)
+	suite.ErrorIs(manager.Recover(ctx), context.Canceled)
+	suite.False(manager.ContainResourceGroup("rg"))
 }
 
 func (suite *ResourceManagerSuite) TearDownSuite() {
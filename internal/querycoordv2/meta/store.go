@@ -17,14 +17,19 @@
 package meta
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"strconv"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/samber/lo"
 	clientv3 "go.etcd.io/etcd/client/v3"
 
 	"github.com/milvus-io/milvus-proto/go-api/milvuspb"
+	"github.com/milvus-io/milvus/internal/common"
 	"github.com/milvus-io/milvus/internal/kv"
 	"github.com/milvus-io/milvus/internal/metastore"
 	"github.com/milvus-io/milvus/internal/proto/querypb"
@@ -35,12 +40,19 @@ var (
 )
 
 const (
-	CollectionLoadInfoPrefix = "querycoord-collection-loadinfo"
-	PartitionLoadInfoPrefix  = "querycoord-partition-loadinfo"
-	ReplicaPrefix            = "querycoord-replica"
-	CollectionMetaPrefixV1   = "queryCoord-collectionMeta"
-	ReplicaMetaPrefixV1      = "queryCoord-ReplicaMeta"
-	ResourceGroupPrefix      = "queryCoord-ResourceGroup"
+	CollectionLoadInfoPrefix       = "querycoord-collection-loadinfo"
+	PartitionLoadInfoPrefix        = "querycoord-partition-loadinfo"
+	ReplicaPrefix                  = "querycoord-replica"
+	CollectionMetaPrefixV1         = "queryCoord-collectionMeta"
+	ReplicaMetaPrefixV1            = "queryCoord-ReplicaMeta"
+	ResourceGroupPrefix            = "queryCoord-ResourceGroup"
+	NodeResourceGroupPrefix        = "queryCoord-NodeResourceGroup"
+	ResourceGroupLimitPrefix       = "queryCoord-RGLimit"
+	ResourceGroupLabelPrefix       = "queryCoord-RGLabel"
+	SealedResourceGroupPrefix      = "queryCoord-RGSealed"
+	OverlapResourceGroupPrefix     = "queryCoord-RGOverlap"
+	ResourceGroupProportionPrefix  = "queryCoord-RGProportion"
+	ResourceGroupMinCapacityPrefix = "queryCoord-RGMinCapacity"
 )
 
 type WatchStoreChan = clientv3.WatchChan
@@ -91,7 +103,13 @@ func (s metaStore) SaveReplica(replica *querypb.Replica) error {
 	return s.cli.Save(key, string(value))
 }
 
-func (s metaStore) SaveResourceGroup(rgs ...*querypb.ResourceGroup) error {
+// kv doesn't support context yet, so resource group methods only check
+// cancellation before touching kv
+func (s metaStore) SaveResourceGroup(ctx context.Context, rgs ...*querypb.ResourceGroup) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	ret := make(map[string]string)
 	for _, rg := range rgs {
 		key := encodeResourceGroupKey(rg.GetName())
@@ -106,11 +124,171 @@ func (s metaStore) SaveResourceGroup(rgs ...*querypb.ResourceGroup) error {
 	return s.cli.MultiSave(ret)
 }
 
-func (s metaStore) RemoveResourceGroup(rgName string) error {
+func (s metaStore) RemoveResourceGroup(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	key := encodeResourceGroupKey(rgName)
 	return s.cli.Remove(key)
 }
 
+// SaveNodeResourceGroup records the rg which node has been assigned to,
+// so node could be assigned back after restart
+func (s metaStore) SaveNodeResourceGroup(ctx context.Context, node int64, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeNodeResourceGroupKey(node)
+	return s.cli.Save(key, rgName)
+}
+
+func (s metaStore) RemoveNodeResourceGroup(ctx context.Context, node int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeNodeResourceGroupKey(node)
+	return s.cli.Remove(key)
+}
+
+// SaveResourceGroupLimit records the max capacity of rg, which isn't a field of querypb.ResourceGroup
+func (s metaStore) SaveResourceGroupLimit(ctx context.Context, rgName string, maxCapacity int32) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeResourceGroupLimitKey(rgName)
+	return s.cli.Save(key, strconv.FormatInt(int64(maxCapacity), 10))
+}
+
+func (s metaStore) RemoveResourceGroupLimit(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeResourceGroupLimitKey(rgName)
+	return s.cli.Remove(key)
+}
+
+// SaveResourceGroupLabels records the labels of rg as json, which isn't a field of querypb.ResourceGroup
+func (s metaStore) SaveResourceGroupLabels(ctx context.Context, rgName string, labels map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	v, err := json.Marshal(labels)
+	if err != nil {
+		return err
+	}
+
+	key := encodeResourceGroupLabelKey(rgName)
+	return s.cli.Save(key, string(v))
+}
+
+func (s metaStore) RemoveResourceGroupLabels(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeResourceGroupLabelKey(rgName)
+	return s.cli.Remove(key)
+}
+
+// SaveSealedResourceGroup marks rg as sealed, whose nodes shouldn't be changed
+func (s metaStore) SaveSealedResourceGroup(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeSealedResourceGroupKey(rgName)
+	return s.cli.Save(key, rgName)
+}
+
+func (s metaStore) RemoveSealedResourceGroup(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeSealedResourceGroupKey(rgName)
+	return s.cli.Remove(key)
+}
+
+// SaveOverlapResourceGroup marks rg as overlap, whose nodes could be shared with other rgs
+func (s metaStore) SaveOverlapResourceGroup(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeOverlapResourceGroupKey(rgName)
+	return s.cli.Save(key, rgName)
+}
+
+func (s metaStore) RemoveOverlapResourceGroup(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeOverlapResourceGroupKey(rgName)
+	return s.cli.Remove(key)
+}
+
+// SaveResourceGroupProportion records the fraction of cluster size which rg's capacity follows
+func (s metaStore) SaveResourceGroupProportion(ctx context.Context, rgName string, fraction float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeResourceGroupProportionKey(rgName)
+	return s.cli.Save(key, strconv.FormatFloat(fraction, 'f', -1, 64))
+}
+
+func (s metaStore) RemoveResourceGroupProportion(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeResourceGroupProportionKey(rgName)
+	return s.cli.Remove(key)
+}
+
+// SaveResourceGroupMinCapacity records the min capacity of rg, which isn't a field of querypb.ResourceGroup
+func (s metaStore) SaveResourceGroupMinCapacity(ctx context.Context, rgName string, minCapacity int32) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeResourceGroupMinCapacityKey(rgName)
+	return s.cli.Save(key, strconv.FormatInt(int64(minCapacity), 10))
+}
+
+func (s metaStore) RemoveResourceGroupMinCapacity(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key := encodeResourceGroupMinCapacityKey(rgName)
+	return s.cli.Remove(key)
+}
+
+// RemoveResourceGroupAttributes removes all records of rg besides querypb.ResourceGroup in one write,
+// so records left by a former rg never attach to a new rg with the same name
+func (s metaStore) RemoveResourceGroupAttributes(ctx context.Context, rgName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.cli.MultiRemove([]string{
+		encodeResourceGroupLimitKey(rgName),
+		encodeResourceGroupLabelKey(rgName),
+		encodeSealedResourceGroupKey(rgName),
+		encodeOverlapResourceGroupKey(rgName),
+		encodeResourceGroupProportionKey(rgName),
+		encodeResourceGroupMinCapacityKey(rgName),
+	})
+}
+
 func (s metaStore) GetCollections() ([]*querypb.CollectionLoadInfo, error) {
 	_, values, err := s.cli.LoadWithPrefix(CollectionLoadInfoPrefix)
 	if err != nil {
@@ -191,7 +369,11 @@ func (s metaStore) getReplicasFromV1() ([]*querypb.Replica, error) {
 	return ret, nil
 }
 
-func (s metaStore) GetResourceGroups() ([]*querypb.ResourceGroup, error) {
+func (s metaStore) GetResourceGroups(ctx context.Context) ([]*querypb.ResourceGroup, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	_, rgs, err := s.cli.LoadWithPrefix(ResourceGroupPrefix)
 	if err != nil {
 		return nil, err
@@ -210,6 +392,162 @@ func (s metaStore) GetResourceGroups() ([]*querypb.ResourceGroup, error) {
 	return ret, nil
 }
 
+// GetResourceGroup loads a single rg, returns nil if it doesn't exist
+func (s metaStore) GetResourceGroup(ctx context.Context, rgName string) (*querypb.ResourceGroup, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	value, err := s.cli.Load(encodeResourceGroupKey(rgName))
+	if common.IsKeyNotExistError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rg := &querypb.ResourceGroup{}
+	if err := proto.Unmarshal([]byte(value), rg); err != nil {
+		return nil, err
+	}
+	return rg, nil
+}
+
+func (s metaStore) GetNodeResourceGroups(ctx context.Context) (map[int64]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keys, values, err := s.cli.LoadWithPrefix(NodeResourceGroupPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int64]string, len(keys))
+	for i, key := range keys {
+		node, err := strconv.ParseInt(path.Base(key), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ret[node] = values[i]
+	}
+	return ret, nil
+}
+
+func (s metaStore) GetResourceGroupLimits(ctx context.Context) (map[string]int32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keys, values, err := s.cli.LoadWithPrefix(ResourceGroupLimitPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]int32, len(keys))
+	for i, key := range keys {
+		maxCapacity, err := strconv.ParseInt(values[i], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		ret[path.Base(key)] = int32(maxCapacity)
+	}
+	return ret, nil
+}
+
+func (s metaStore) GetResourceGroupMinCapacities(ctx context.Context) (map[string]int32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keys, values, err := s.cli.LoadWithPrefix(ResourceGroupMinCapacityPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]int32, len(keys))
+	for i, key := range keys {
+		minCapacity, err := strconv.ParseInt(values[i], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		ret[path.Base(key)] = int32(minCapacity)
+	}
+	return ret, nil
+}
+
+func (s metaStore) GetResourceGroupLabels(ctx context.Context) (map[string]map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keys, values, err := s.cli.LoadWithPrefix(ResourceGroupLabelPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]map[string]string, len(keys))
+	for i, key := range keys {
+		labels := make(map[string]string)
+		if err := json.Unmarshal([]byte(values[i]), &labels); err != nil {
+			return nil, err
+		}
+		ret[path.Base(key)] = labels
+	}
+	return ret, nil
+}
+
+func (s metaStore) GetResourceGroupProportions(ctx context.Context) (map[string]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keys, values, err := s.cli.LoadWithPrefix(ResourceGroupProportionPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]float64, len(keys))
+	for i, key := range keys {
+		fraction, err := strconv.ParseFloat(values[i], 64)
+		if err != nil {
+			return nil, err
+		}
+		ret[path.Base(key)] = fraction
+	}
+	return ret, nil
+}
+
+func (s metaStore) GetSealedResourceGroups(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keys, _, err := s.cli.LoadWithPrefix(SealedResourceGroupPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(keys, func(key string, _ int) string {
+		return path.Base(key)
+	}), nil
+}
+
+func (s metaStore) GetOverlapResourceGroups(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	keys, _, err := s.cli.LoadWithPrefix(OverlapResourceGroupPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(keys, func(key string, _ int) string {
+		return path.Base(key)
+	}), nil
+}
+
 func (s metaStore) ReleaseCollection(id int64) error {
 	k := encodeCollectionLoadInfoKey(id)
 	return s.cli.Remove(k)
@@ -251,3 +589,31 @@ func encodeCollectionReplicaKey(collection int64) string {
 func encodeResourceGroupKey(rgName string) string {
 	return fmt.Sprintf("%s/%s", ResourceGroupPrefix, rgName)
 }
+
+func encodeNodeResourceGroupKey(node int64) string {
+	return fmt.Sprintf("%s/%d", NodeResourceGroupPrefix, node)
+}
+
+func encodeResourceGroupLimitKey(rgName string) string {
+	return fmt.Sprintf("%s/%s", ResourceGroupLimitPrefix, rgName)
+}
+
+func encodeResourceGroupMinCapacityKey(rgName string) string {
+	return fmt.Sprintf("%s/%s", ResourceGroupMinCapacityPrefix, rgName)
+}
+
+func encodeResourceGroupLabelKey(rgName string) string {
+	return fmt.Sprintf("%s/%s", ResourceGroupLabelPrefix, rgName)
+}
+
+func encodeResourceGroupProportionKey(rgName string) string {
+	return fmt.Sprintf("%s/%s", ResourceGroupProportionPrefix, rgName)
+}
+
+func encodeSealedResourceGroupKey(rgName string) string {
+	return fmt.Sprintf("%s/%s", SealedResourceGroupPrefix, rgName)
+}
+
+func encodeOverlapResourceGroupKey(rgName string) string {
+	return fmt.Sprintf("%s/%s", OverlapResourceGroupPrefix, rgName)
+}
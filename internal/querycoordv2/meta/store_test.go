@@ -17,6 +17,7 @@
 package meta
 
 import (
+	"context"
 	"sort"
 	"testing"
 
@@ -127,26 +128,26 @@ func (suite *StoreTestSuite) TestReplica() {
 }
 
 func (suite *StoreTestSuite) TestResourceGroup() {
-	suite.store.SaveResourceGroup(&querypb.ResourceGroup{
+	suite.store.SaveResourceGroup(context.Background(), &querypb.ResourceGroup{
 		Name:     "rg1",
 		Capacity: 3,
 		Nodes:    []int64{1, 2, 3},
 	})
-	suite.store.SaveResourceGroup(&querypb.ResourceGroup{
+	suite.store.SaveResourceGroup(context.Background(), &querypb.ResourceGroup{
 		Name:     "rg2",
 		Capacity: 3,
 		Nodes:    []int64{4, 5},
 	})
 
-	suite.store.SaveResourceGroup(&querypb.ResourceGroup{
+	suite.store.SaveResourceGroup(context.Background(), &querypb.ResourceGroup{
 		Name:     "rg3",
 		Capacity: 0,
 		Nodes:    []int64{},
 	})
 
-	suite.store.RemoveResourceGroup("rg3")
+	suite.store.RemoveResourceGroup(context.Background(), "rg3")
 
-	groups, err := suite.store.GetResourceGroups()
+	groups, err := suite.store.GetResourceGroups(context.Background())
 	suite.NoError(err)
 	suite.Len(groups, 2)
 
@@ -161,6 +162,191 @@ func (suite *StoreTestSuite) TestResourceGroup() {
 	suite.Equal("rg2", groups[1].GetName())
 	suite.Equal(int32(3), groups[1].GetCapacity())
 	suite.Equal([]int64{4, 5}, groups[1].GetNodes())
+
+	rg, err := suite.store.GetResourceGroup(context.Background(), "rg1")
+	suite.NoError(err)
+	suite.Equal(int32(3), rg.GetCapacity())
+	suite.Equal([]int64{1, 2, 3}, rg.GetNodes())
+	rg, err = suite.store.GetResourceGroup(context.Background(), "rg3")
+	suite.NoError(err)
+	suite.Nil(rg)
+
+	// nothing should be written with a cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = suite.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{
+		Name:     "rg4",
+		Capacity: 0,
+	})
+	suite.ErrorIs(err, context.Canceled)
+	groups, err = suite.store.GetResourceGroups(context.Background())
+	suite.NoError(err)
+	suite.Len(groups, 2)
+}
+
+func (suite *StoreTestSuite) TestNodeResourceGroup() {
+	ctx := context.Background()
+	suite.NoError(suite.store.SaveNodeResourceGroup(ctx, 1, "rg1"))
+	suite.NoError(suite.store.SaveNodeResourceGroup(ctx, 2, "rg1"))
+	suite.NoError(suite.store.SaveNodeResourceGroup(ctx, 3, "rg2"))
+	suite.NoError(suite.store.SaveNodeResourceGroup(ctx, 3, "rg3"))
+	suite.NoError(suite.store.RemoveNodeResourceGroup(ctx, 2))
+
+	nodes, err := suite.store.GetNodeResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Equal(map[int64]string{1: "rg1", 3: "rg3"}, nodes)
+
+	// node records should never be treated as resource groups
+	groups, err := suite.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Len(groups, 0)
+}
+
+func (suite *StoreTestSuite) TestResourceGroupLimit() {
+	ctx := context.Background()
+	suite.NoError(suite.store.SaveResourceGroupLimit(ctx, "rg1", 3))
+	suite.NoError(suite.store.SaveResourceGroupLimit(ctx, "rg2", 5))
+	suite.NoError(suite.store.SaveResourceGroupLimit(ctx, "rg2", 4))
+	suite.NoError(suite.store.SaveResourceGroupLimit(ctx, "rg3", 1))
+	suite.NoError(suite.store.RemoveResourceGroupLimit(ctx, "rg3"))
+
+	limits, err := suite.store.GetResourceGroupLimits(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]int32{"rg1": 3, "rg2": 4}, limits)
+
+	// limit records should never be treated as resource groups
+	groups, err := suite.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Len(groups, 0)
+}
+
+func (suite *StoreTestSuite) TestResourceGroupMinCapacity() {
+	ctx := context.Background()
+	suite.NoError(suite.store.SaveResourceGroupMinCapacity(ctx, "rg1", 3))
+	suite.NoError(suite.store.SaveResourceGroupMinCapacity(ctx, "rg2", 5))
+	suite.NoError(suite.store.SaveResourceGroupMinCapacity(ctx, "rg2", 4))
+	suite.NoError(suite.store.SaveResourceGroupMinCapacity(ctx, "rg3", 1))
+	suite.NoError(suite.store.RemoveResourceGroupMinCapacity(ctx, "rg3"))
+
+	minCapacities, err := suite.store.GetResourceGroupMinCapacities(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]int32{"rg1": 3, "rg2": 4}, minCapacities)
+
+	// min capacity records should never be treated as limits
+	limits, err := suite.store.GetResourceGroupLimits(ctx)
+	suite.NoError(err)
+	suite.Empty(limits)
+}
+
+func (suite *StoreTestSuite) TestResourceGroupLabels() {
+	ctx := context.Background()
+	suite.NoError(suite.store.SaveResourceGroupLabels(ctx, "rg1", map[string]string{"tenant": "acme", "tier": "gold"}))
+	suite.NoError(suite.store.SaveResourceGroupLabels(ctx, "rg2", map[string]string{"tenant": "acme"}))
+	suite.NoError(suite.store.SaveResourceGroupLabels(ctx, "rg3", map[string]string{"tier": "silver"}))
+	suite.NoError(suite.store.RemoveResourceGroupLabels(ctx, "rg3"))
+
+	labels, err := suite.store.GetResourceGroupLabels(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]map[string]string{
+		"rg1": {"tenant": "acme", "tier": "gold"},
+		"rg2": {"tenant": "acme"},
+	}, labels)
+
+	// label records should never be treated as resource groups
+	groups, err := suite.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Len(groups, 0)
+}
+
+func (suite *StoreTestSuite) TestResourceGroupProportion() {
+	ctx := context.Background()
+	suite.NoError(suite.store.SaveResourceGroupProportion(ctx, "rg1", 0.3))
+	suite.NoError(suite.store.SaveResourceGroupProportion(ctx, "rg2", 0.5))
+	suite.NoError(suite.store.SaveResourceGroupProportion(ctx, "rg2", 0.25))
+	suite.NoError(suite.store.SaveResourceGroupProportion(ctx, "rg3", 1))
+	suite.NoError(suite.store.RemoveResourceGroupProportion(ctx, "rg3"))
+
+	proportions, err := suite.store.GetResourceGroupProportions(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]float64{"rg1": 0.3, "rg2": 0.25}, proportions)
+
+	// proportion records should never be treated as resource groups
+	groups, err := suite.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Len(groups, 0)
+}
+
+func (suite *StoreTestSuite) TestSealedResourceGroup() {
+	ctx := context.Background()
+	suite.NoError(suite.store.SaveSealedResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.store.SaveSealedResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.store.SaveSealedResourceGroup(ctx, "rg3"))
+	suite.NoError(suite.store.RemoveSealedResourceGroup(ctx, "rg3"))
+
+	sealed, err := suite.store.GetSealedResourceGroups(ctx)
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"rg1", "rg2"}, sealed)
+
+	// sealed records should never be treated as resource groups
+	groups, err := suite.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Len(groups, 0)
+}
+
+func (suite *StoreTestSuite) TestOverlapResourceGroup() {
+	ctx := context.Background()
+	suite.NoError(suite.store.SaveOverlapResourceGroup(ctx, "rg1"))
+	suite.NoError(suite.store.SaveOverlapResourceGroup(ctx, "rg2"))
+	suite.NoError(suite.store.RemoveOverlapResourceGroup(ctx, "rg2"))
+
+	overlap, err := suite.store.GetOverlapResourceGroups(ctx)
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"rg1"}, overlap)
+
+	// overlap records should never be treated as resource groups
+	groups, err := suite.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Len(groups, 0)
+}
+
+func (suite *StoreTestSuite) TestRemoveResourceGroupAttributes() {
+	ctx := context.Background()
+	suite.NoError(suite.store.SaveResourceGroup(ctx, &querypb.ResourceGroup{Name: "rg1"}))
+	for _, rg := range []string{"rg1", "rg2"} {
+		suite.NoError(suite.store.SaveResourceGroupLimit(ctx, rg, 3))
+		suite.NoError(suite.store.SaveResourceGroupLabels(ctx, rg, map[string]string{"zone": "a"}))
+		suite.NoError(suite.store.SaveSealedResourceGroup(ctx, rg))
+		suite.NoError(suite.store.SaveOverlapResourceGroup(ctx, rg))
+		suite.NoError(suite.store.SaveResourceGroupProportion(ctx, rg, 0.5))
+		suite.NoError(suite.store.SaveResourceGroupMinCapacity(ctx, rg, 1))
+	}
+	suite.NoError(suite.store.RemoveResourceGroupAttributes(ctx, "rg1"))
+	// removing records which don't exist is fine
+	suite.NoError(suite.store.RemoveResourceGroupAttributes(ctx, "rg3"))
+
+	limits, err := suite.store.GetResourceGroupLimits(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]int32{"rg2": 3}, limits)
+	labels, err := suite.store.GetResourceGroupLabels(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]map[string]string{"rg2": {"zone": "a"}}, labels)
+	sealed, err := suite.store.GetSealedResourceGroups(ctx)
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"rg2"}, sealed)
+	overlap, err := suite.store.GetOverlapResourceGroups(ctx)
+	suite.NoError(err)
+	suite.ElementsMatch([]string{"rg2"}, overlap)
+	proportions, err := suite.store.GetResourceGroupProportions(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]float64{"rg2": 0.5}, proportions)
+	minCapacities, err := suite.store.GetResourceGroupMinCapacities(ctx)
+	suite.NoError(err)
+	suite.Equal(map[string]int32{"rg2": 1}, minCapacities)
+
+	// the rg itself is kept
+	groups, err := suite.store.GetResourceGroups(ctx)
+	suite.NoError(err)
+	suite.Len(groups, 1)
 }
 
 func (suite *StoreTestSuite) TestLoadRelease() {
@@ -70,19 +70,20 @@ func (ob *ResourceObserver) schedule(ctx context.Context) {
 			return
 
 		case <-ticker.C:
-			ob.checkResourceGroup()
+			ob.checkResourceGroup(ctx)
 		}
 	}
 }
 
-func (ob *ResourceObserver) checkResourceGroup() {
+func (ob *ResourceObserver) checkResourceGroup(ctx context.Context) {
 	manager := ob.meta.ResourceManager
 	rgNames := manager.ListResourceGroups()
 
 	enableRGAutoRecover := params.Params.QueryCoordCfg.EnableRGAutoRecover.GetAsBool()
 
+	totalLackNodeNum := 0
 	for _, rgName := range rgNames {
-		if rgName == meta.DefaultResourceGroupName {
+		if rgName == manager.GetDefaultResourceGroupName() {
 			continue
 		}
 		lackNodeNum := manager.CheckLackOfNode(rgName)
@@ -91,17 +92,17 @@ func (ob *ResourceObserver) checkResourceGroup() {
 				zap.String("rgName", rgName),
 				zap.Int("lackNodeNum", lackNodeNum),
 			)
+			totalLackNodeNum += lackNodeNum
+		}
+	}
 
-			if enableRGAutoRecover {
-				usedNodeNum, err := manager.AutoRecoverResourceGroup(rgName)
-				if err != nil {
-					log.Warn("failed to recover resource group",
-						zap.String("rgName", rgName),
-						zap.Int("lackNodeNum", lackNodeNum-usedNodeNum),
-						zap.Error(err),
-					)
-				}
-			}
+	if enableRGAutoRecover && totalLackNodeNum > 0 {
+		usedNodeNum, err := manager.AutoRecoverAll(ctx)
+		if err != nil {
+			log.Warn("failed to recover resource groups",
+				zap.Int("lackNodeNum", totalLackNodeNum-usedNodeNum),
+				zap.Error(err),
+			)
 		}
 	}
 }
@@ -48,6 +48,7 @@ func (suite *ResourceObserverSuite) SetupSuite() {
 }
 
 func (suite *ResourceObserverSuite) SetupTest() {
+	ctx := context.Background()
 	var err error
 	config := GenerateEtcdConfig()
 	cli, err := etcd.GetEtcdClient(
@@ -72,20 +73,21 @@ func (suite *ResourceObserverSuite) SetupTest() {
 
 	for i := 1; i < 10; i++ {
 		suite.nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
-		suite.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, int64(i))
+		suite.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, int64(i))
 	}
 }
 
 func (suite *ResourceObserverSuite) TestCheckNodesInReplica() {
-	suite.meta.ResourceManager.AddResourceGroup("rg")
+	ctx := context.Background()
+	suite.meta.ResourceManager.AddResourceGroup(ctx, "rg")
 	suite.nodeMgr.Add(session.NewNodeInfo(int64(100), "localhost"))
 	suite.nodeMgr.Add(session.NewNodeInfo(int64(101), "localhost"))
 	suite.nodeMgr.Add(session.NewNodeInfo(int64(102), "localhost"))
-	suite.meta.ResourceManager.AssignNode("rg", 100)
-	suite.meta.ResourceManager.AssignNode("rg", 101)
-	suite.meta.ResourceManager.AssignNode("rg", 102)
-	suite.meta.ResourceManager.HandleNodeDown(100)
-	suite.meta.ResourceManager.HandleNodeDown(101)
+	suite.meta.ResourceManager.AssignNode(ctx, "rg", 100)
+	suite.meta.ResourceManager.AssignNode(ctx, "rg", 101)
+	suite.meta.ResourceManager.AssignNode(ctx, "rg", 102)
+	suite.meta.ResourceManager.HandleNodeDown(ctx, 100)
+	suite.meta.ResourceManager.HandleNodeDown(ctx, 101)
 
 	//before auto recover rg
 	suite.Eventually(func() bool {
@@ -292,8 +292,11 @@ func (s *Server) initMeta() error {
 		return err
 	}
 
-	err = s.meta.ResourceManager.Recover()
-	if err != nil {
+	err = s.meta.ResourceManager.Recover(s.ctx)
+	if errors.Is(err, meta.ErrRGPartialRecovered) {
+		// the recoverable part has been loaded, don't block the startup
+		log.Warn("resource groups are partially recovered", zap.Error(err))
+	} else if err != nil {
 		log.Error("failed to recover resource groups")
 		return err
 	}
@@ -451,6 +454,14 @@ func (s *Server) Stop() error {
 	if s.resourceObserver != nil {
 		s.resourceObserver.Stop()
 	}
+	if s.meta != nil {
+		// s.ctx has been cancelled, flushing pending removals needs its own deadline
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := s.meta.ResourceManager.Close(ctx); err != nil {
+			log.Warn("failed to close resource manager", zap.Error(err))
+		}
+		cancel()
+	}
 
 	s.wg.Wait()
 	log.Info("QueryCoord stop successfully")
@@ -629,7 +640,7 @@ func (s *Server) handleNodeUp(node int64) {
 	s.distController.StartDistInstance(s.ctx, node)
 
 	// need assign to new rg and replica
-	rgName, err := s.meta.ResourceManager.HandleNodeUp(node)
+	rgName, err := s.meta.ResourceManager.HandleNodeUp(s.ctx, node)
 	if err != nil {
 		log.Warn("HandleNodeUp: failed to assign node to resource group",
 			zap.Error(err),
@@ -698,7 +709,7 @@ func (s *Server) handleNodeDown(node int64) {
 	// Clear tasks
 	s.taskScheduler.RemoveByNode(node)
 
-	rgName, err := s.meta.ResourceManager.HandleNodeDown(node)
+	rgName, err := s.meta.ResourceManager.HandleNodeDown(s.ctx, node)
 	if err != nil {
 		log.Warn("HandleNodeDown: failed to remove node from resource group",
 			zap.String("resourceGroup", rgName),
@@ -99,6 +99,7 @@ func (suite *ServerSuite) SetupSuite() {
 }
 
 func (suite *ServerSuite) SetupTest() {
+	ctx := context.Background()
 	var err error
 
 	suite.server, err = newQueryCoord()
@@ -113,7 +114,7 @@ func (suite *ServerSuite) SetupTest() {
 		suite.Require().NoError(err)
 		ok := suite.waitNodeUp(suite.nodes[i], 5*time.Second)
 		suite.Require().True(ok)
-		suite.server.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, suite.nodes[i].ID)
+		suite.server.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, suite.nodes[i].ID)
 	}
 
 	suite.loadAll()
@@ -939,6 +939,11 @@ func (s *Server) CheckHealth(ctx context.Context, req *milvuspb.CheckHealthReque
 		return &milvuspb.CheckHealthResponse{IsHealthy: false, Reasons: []string{reason}}, nil
 	}
 
+	if ok, reason := s.meta.ResourceManager.Healthy(ctx); !ok {
+		reason := errorutil.UnHealthReason("querycoord", s.session.ServerID, reason)
+		return &milvuspb.CheckHealthResponse{IsHealthy: false, Reasons: []string{reason}}, nil
+	}
+
 	group, ctx := errgroup.WithContext(ctx)
 	errReasons := make([]string, 0, len(s.nodeMgr.GetAll()))
 
@@ -976,7 +981,7 @@ func (s *Server) CreateResourceGroup(ctx context.Context, req *milvuspb.CreateRe
 		return utils.WrapStatus(commonpb.ErrorCode_UnexpectedError, ErrCreateResourceGroupFailed.Error(), ErrNotHealthy), nil
 	}
 
-	err := s.meta.ResourceManager.AddResourceGroup(req.GetResourceGroup())
+	err := s.meta.ResourceManager.AddResourceGroup(ctx, req.GetResourceGroup())
 	if err != nil {
 		log.Warn(ErrCreateResourceGroupFailed.Error(), zap.Error(err))
 		return utils.WrapStatus(commonpb.ErrorCode_UnexpectedError, ErrCreateResourceGroupFailed.Error(), err), nil
@@ -995,7 +1000,7 @@ func (s *Server) DropResourceGroup(ctx context.Context, req *milvuspb.DropResour
 		return utils.WrapStatus(commonpb.ErrorCode_UnexpectedError, ErrDropResourceGroupFailed.Error(), ErrNotHealthy), nil
 	}
 
-	err := s.meta.ResourceManager.RemoveResourceGroup(req.GetResourceGroup())
+	err := s.meta.ResourceManager.RemoveResourceGroup(ctx, req.GetResourceGroup())
 	if err != nil {
 		log.Warn(ErrDropResourceGroupFailed.Error(), zap.Error(err))
 		return utils.WrapStatus(commonpb.ErrorCode_UnexpectedError, ErrDropResourceGroupFailed.Error(), err), nil
@@ -1025,11 +1030,12 @@ func (s *Server) TransferNode(ctx context.Context, req *milvuspb.TransferNodeReq
 			fmt.Sprintf("the target resource group[%s] doesn't exist", req.GetTargetResourceGroup()), meta.ErrRGNotExist), nil
 	}
 
-	err := s.meta.ResourceManager.TransferNode(req.GetSourceResourceGroup(), req.GetTargetResourceGroup())
+	node, err := s.meta.ResourceManager.TransferNode(ctx, req.GetSourceResourceGroup(), req.GetTargetResourceGroup())
 	if err != nil {
 		log.Warn(ErrTransferNodeFailed.Error(), zap.Error(err))
 		return utils.WrapStatus(commonpb.ErrorCode_UnexpectedError, ErrTransferNodeFailed.Error(), err), nil
 	}
+	log.Info("transfer node between resource group done", zap.Int64("node", node))
 
 	return successStatus, nil
 }
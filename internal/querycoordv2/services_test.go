@@ -114,6 +114,7 @@ func (suite *ServiceSuite) SetupSuite() {
 }
 
 func (suite *ServiceSuite) SetupTest() {
+	ctx := context.Background()
 	config := params.GenerateEtcdConfig()
 	cli, err := etcd.GetEtcdClient(
 		config.UseEmbedEtcd.GetAsBool(),
@@ -140,7 +141,7 @@ func (suite *ServiceSuite) SetupTest() {
 	)
 	for _, node := range suite.nodes {
 		suite.nodeMgr.Add(session.NewNodeInfo(node, "localhost"))
-		err := suite.meta.ResourceManager.AssignNode(meta.DefaultResourceGroupName, node)
+		err := suite.meta.ResourceManager.AssignNode(ctx, meta.DefaultResourceGroupName, node)
 		suite.NoError(err)
 	}
 	suite.cluster = session.NewMockCluster(suite.T())
@@ -364,12 +365,12 @@ func (suite *ServiceSuite) TestResourceGroup() {
 	server.nodeMgr.Add(session.NewNodeInfo(1012, "localhost"))
 	server.nodeMgr.Add(session.NewNodeInfo(1013, "localhost"))
 	server.nodeMgr.Add(session.NewNodeInfo(1014, "localhost"))
-	server.meta.ResourceManager.AddResourceGroup("rg11")
-	server.meta.ResourceManager.AssignNode("rg11", 1011)
-	server.meta.ResourceManager.AssignNode("rg11", 1012)
-	server.meta.ResourceManager.AddResourceGroup("rg12")
-	server.meta.ResourceManager.AssignNode("rg12", 1013)
-	server.meta.ResourceManager.AssignNode("rg12", 1014)
+	server.meta.ResourceManager.AddResourceGroup(ctx, "rg11")
+	server.meta.ResourceManager.AssignNode(ctx, "rg11", 1011)
+	server.meta.ResourceManager.AssignNode(ctx, "rg11", 1012)
+	server.meta.ResourceManager.AddResourceGroup(ctx, "rg12")
+	server.meta.ResourceManager.AssignNode(ctx, "rg12", 1013)
+	server.meta.ResourceManager.AssignNode(ctx, "rg12", 1014)
 	server.meta.CollectionManager.PutCollection(utils.CreateTestCollection(1, 1))
 	server.meta.CollectionManager.PutCollection(utils.CreateTestCollection(2, 1))
 	server.meta.ReplicaManager.Put(meta.NewReplica(&querypb.Replica{
@@ -465,9 +466,9 @@ func (suite *ServiceSuite) TestTransferNode() {
 	ctx := context.Background()
 	server := suite.server
 
-	err := server.meta.ResourceManager.AddResourceGroup("rg1")
+	err := server.meta.ResourceManager.AddResourceGroup(ctx, "rg1")
 	suite.NoError(err)
-	err = server.meta.ResourceManager.AddResourceGroup("rg2")
+	err = server.meta.ResourceManager.AddResourceGroup(ctx, "rg2")
 	suite.NoError(err)
 	// test transfer node
 	resp, err := server.TransferNode(ctx, &milvuspb.TransferNodeRequest{
@@ -512,11 +513,11 @@ func (suite *ServiceSuite) TestTransferReplica() {
 	ctx := context.Background()
 	server := suite.server
 
-	err := server.meta.ResourceManager.AddResourceGroup("rg1")
+	err := server.meta.ResourceManager.AddResourceGroup(ctx, "rg1")
 	suite.NoError(err)
-	err = server.meta.ResourceManager.AddResourceGroup("rg2")
+	err = server.meta.ResourceManager.AddResourceGroup(ctx, "rg2")
 	suite.NoError(err)
-	err = server.meta.ResourceManager.AddResourceGroup("rg3")
+	err = server.meta.ResourceManager.AddResourceGroup(ctx, "rg3")
 	suite.NoError(err)
 
 	resp, err := suite.server.TransferReplica(ctx, &querypb.TransferReplicaRequest{
@@ -561,10 +562,10 @@ func (suite *ServiceSuite) TestTransferReplica() {
 	suite.server.nodeMgr.Add(session.NewNodeInfo(1002, "localhost"))
 	suite.server.nodeMgr.Add(session.NewNodeInfo(1003, "localhost"))
 	suite.server.nodeMgr.Add(session.NewNodeInfo(1004, "localhost"))
-	suite.server.meta.AssignNode("rg1", 1001)
-	suite.server.meta.AssignNode("rg2", 1002)
-	suite.server.meta.AssignNode("rg3", 1003)
-	suite.server.meta.AssignNode("rg3", 1004)
+	suite.server.meta.AssignNode(ctx, "rg1", 1001)
+	suite.server.meta.AssignNode(ctx, "rg2", 1002)
+	suite.server.meta.AssignNode(ctx, "rg3", 1003)
+	suite.server.meta.AssignNode(ctx, "rg3", 1004)
 
 	resp, err = suite.server.TransferReplica(ctx, &querypb.TransferReplicaRequest{
 		SourceResourceGroup: meta.DefaultResourceGroupName,
@@ -106,6 +106,7 @@ type NodeInfo struct {
 	id            int64
 	addr          string
 	state         State
+	weight        float64
 	lastHeartbeat *atomic.Int64
 }
 
@@ -149,6 +150,19 @@ func (n *NodeInfo) SetState(s State) {
 	n.state = s
 }
 
+// Weight returns the relative capacity of the node, 1.0 for a standard node
+func (n *NodeInfo) Weight() float64 {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.weight
+}
+
+func (n *NodeInfo) SetWeight(weight float64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.weight = weight
+}
+
 func (n *NodeInfo) UpdateStats(opts ...StatsOption) {
 	n.mu.Lock()
 	for _, opt := range opts {
@@ -162,6 +176,7 @@ func NewNodeInfo(id int64, addr string) *NodeInfo {
 		stats:         newStats(),
 		id:            id,
 		addr:          addr,
+		weight:        1.0,
 		lastHeartbeat: atomic.NewInt64(0),
 	}
 }
@@ -183,7 +183,7 @@ func SpawnReplicasWithRG(m *meta.Meta, collection int64, resourceGroups []string
 	replicaSet := make([]*meta.Replica, 0)
 	for _, rgName := range resourceGroups {
 		if !m.ResourceManager.ContainResourceGroup(rgName) {
-			return nil, meta.ErrRGNotExist
+			return nil, &meta.ResourceGroupNotFoundError{Name: rgName}
 		}
 
 		replicas, err := m.ReplicaManager.Spawn(collection, 1, rgName)
@@ -17,6 +17,7 @@
 package utils
 
 import (
+	"context"
 	"testing"
 
 	etcdKV "github.com/milvus-io/milvus/internal/kv/etcd"
@@ -27,6 +28,7 @@ import (
 )
 
 func TestSpawnReplicasWithRG(t *testing.T) {
+	ctx := context.Background()
 	Params.Init()
 	config := GenerateEtcdConfig()
 	cli, _ := etcd.GetEtcdClient(
@@ -42,21 +44,21 @@ func TestSpawnReplicasWithRG(t *testing.T) {
 	store := meta.NewMetaStore(kv)
 	nodeMgr := session.NewNodeManager()
 	m := meta.NewMeta(RandomIncrementIDAllocator(), store, nodeMgr)
-	m.ResourceManager.AddResourceGroup("rg1")
-	m.ResourceManager.AddResourceGroup("rg2")
-	m.ResourceManager.AddResourceGroup("rg3")
+	m.ResourceManager.AddResourceGroup(ctx, "rg1")
+	m.ResourceManager.AddResourceGroup(ctx, "rg2")
+	m.ResourceManager.AddResourceGroup(ctx, "rg3")
 
 	for i := 1; i < 10; i++ {
 		nodeMgr.Add(session.NewNodeInfo(int64(i), "localhost"))
 
 		if i%3 == 0 {
-			m.ResourceManager.AssignNode("rg1", int64(i))
+			m.ResourceManager.AssignNode(ctx, "rg1", int64(i))
 		}
 		if i%3 == 1 {
-			m.ResourceManager.AssignNode("rg2", int64(i))
+			m.ResourceManager.AssignNode(ctx, "rg2", int64(i))
 		}
 		if i%3 == 2 {
-			m.ResourceManager.AssignNode("rg3", int64(i))
+			m.ResourceManager.AssignNode(ctx, "rg3", int64(i))
 		}
 	}
 
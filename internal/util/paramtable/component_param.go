@@ -893,6 +893,17 @@ type queryCoordConfig struct {
 	CheckNodeInReplicaInterval ParamItem `refreshable:"false"`
 	CheckResourceGroupInterval ParamItem `refreshable:"false"`
 	EnableRGAutoRecover        ParamItem `refreshable:"true"`
+	MaxResourceGroupNum        ParamItem `refreshable:"false"`
+	DefaultRGReservedNodeNum   ParamItem `refreshable:"true"`
+	RGStoreRetryNum            ParamItem `refreshable:"true"`
+	RGStoreRetryInterval       ParamItem `refreshable:"true"`
+	RGNodeCountHistorySize     ParamItem `refreshable:"false"`
+	RGNodeDownGracePeriod      ParamItem `refreshable:"true"`
+	RGAuditLogEnabled          ParamItem `refreshable:"true"`
+	RGChurnRateWindow          ParamItem `refreshable:"true"`
+	RGRecoverRefuseOverLimit   ParamItem `refreshable:"true"`
+	RGNodeUpAutoPlacement      ParamItem `refreshable:"true"`
+	RGMaxReplicaNum            ParamItem `refreshable:"true"`
 }
 
 func (p *queryCoordConfig) init(base *BaseTable) {
@@ -1067,6 +1078,96 @@ func (p *queryCoordConfig) init(base *BaseTable) {
 		PanicIfEmpty: true,
 	}
 	p.EnableRGAutoRecover.Init(base.mgr)
+
+	p.MaxResourceGroupNum = ParamItem{
+		Key:          "queryCoord.maxResourceGroupNum",
+		Version:      "2.3.0",
+		DefaultValue: "1024",
+		PanicIfEmpty: true,
+	}
+	p.MaxResourceGroupNum.Init(base.mgr)
+
+	p.DefaultRGReservedNodeNum = ParamItem{
+		Key:          "queryCoord.defaultRGReservedNodeNum",
+		Version:      "2.3.0",
+		DefaultValue: "0",
+		PanicIfEmpty: true,
+	}
+	p.DefaultRGReservedNodeNum.Init(base.mgr)
+
+	p.RGStoreRetryNum = ParamItem{
+		Key:          "queryCoord.rgStoreRetryNum",
+		Version:      "2.3.0",
+		DefaultValue: "3",
+		PanicIfEmpty: true,
+	}
+	p.RGStoreRetryNum.Init(base.mgr)
+
+	// in milliseconds, doubled after each failure
+	p.RGStoreRetryInterval = ParamItem{
+		Key:          "queryCoord.rgStoreRetryInterval",
+		Version:      "2.3.0",
+		DefaultValue: "100",
+		PanicIfEmpty: true,
+	}
+	p.RGStoreRetryInterval.Init(base.mgr)
+
+	p.RGNodeCountHistorySize = ParamItem{
+		Key:          "queryCoord.rgNodeCountHistorySize",
+		Version:      "2.3.0",
+		DefaultValue: "16",
+		PanicIfEmpty: true,
+	}
+	p.RGNodeCountHistorySize.Init(base.mgr)
+
+	p.RGNodeDownGracePeriod = ParamItem{
+		Key:          "queryCoord.rgNodeDownGracePeriod",
+		Version:      "2.3.0",
+		DefaultValue: "0",
+		PanicIfEmpty: true,
+	}
+	p.RGNodeDownGracePeriod.Init(base.mgr)
+
+	p.RGAuditLogEnabled = ParamItem{
+		Key:          "queryCoord.rgAuditLogEnabled",
+		Version:      "2.3.0",
+		DefaultValue: "false",
+		PanicIfEmpty: true,
+	}
+	p.RGAuditLogEnabled.Init(base.mgr)
+
+	p.RGChurnRateWindow = ParamItem{
+		Key:          "queryCoord.rgChurnRateWindow",
+		Version:      "2.3.0",
+		DefaultValue: "300",
+		PanicIfEmpty: true,
+	}
+	p.RGChurnRateWindow.Init(base.mgr)
+
+	p.RGRecoverRefuseOverLimit = ParamItem{
+		Key:          "queryCoord.rgRecoverRefuseOverLimit",
+		Version:      "2.3.0",
+		DefaultValue: "false",
+		PanicIfEmpty: true,
+	}
+	p.RGRecoverRefuseOverLimit.Init(base.mgr)
+
+	p.RGNodeUpAutoPlacement = ParamItem{
+		Key:          "queryCoord.rgNodeUpAutoPlacement",
+		Version:      "2.3.0",
+		DefaultValue: "false",
+		PanicIfEmpty: true,
+	}
+	p.RGNodeUpAutoPlacement.Init(base.mgr)
+
+	// 0 means unlimited
+	p.RGMaxReplicaNum = ParamItem{
+		Key:          "queryCoord.rgMaxReplicaNum",
+		Version:      "2.3.0",
+		DefaultValue: "0",
+		PanicIfEmpty: true,
+	}
+	p.RGMaxReplicaNum.Init(base.mgr)
 }
 
 // /////////////////////////////////////////////////////////////////////////////
@@ -265,6 +265,28 @@ func TestComponentParam(t *testing.T) {
 		params.Save("queryCoord.enableRGAutoRecover", "false")
 		enableResourceGroupAutoRecover = Params.EnableRGAutoRecover
 		assert.Equal(t, false, enableResourceGroupAutoRecover.GetAsBool())
+
+		maxResourceGroupNum := Params.MaxResourceGroupNum
+		assert.Equal(t, 1024, maxResourceGroupNum.GetAsInt())
+		params.Save("queryCoord.maxResourceGroupNum", "16")
+		maxResourceGroupNum = Params.MaxResourceGroupNum
+		assert.Equal(t, 16, maxResourceGroupNum.GetAsInt())
+
+		defaultRGReservedNodeNum := Params.DefaultRGReservedNodeNum
+		assert.Equal(t, 0, defaultRGReservedNodeNum.GetAsInt())
+		params.Save("queryCoord.defaultRGReservedNodeNum", "2")
+		defaultRGReservedNodeNum = Params.DefaultRGReservedNodeNum
+		assert.Equal(t, 2, defaultRGReservedNodeNum.GetAsInt())
+
+		assert.Equal(t, 3, Params.RGStoreRetryNum.GetAsInt())
+		assert.Equal(t, 100*time.Millisecond, Params.RGStoreRetryInterval.GetAsDuration(time.Millisecond))
+		assert.Equal(t, 16, Params.RGNodeCountHistorySize.GetAsInt())
+		assert.Equal(t, time.Duration(0), Params.RGNodeDownGracePeriod.GetAsDuration(time.Millisecond))
+		assert.False(t, Params.RGAuditLogEnabled.GetAsBool())
+		assert.Equal(t, 300*time.Second, Params.RGChurnRateWindow.GetAsDuration(time.Second))
+		assert.False(t, Params.RGRecoverRefuseOverLimit.GetAsBool())
+		assert.False(t, Params.RGNodeUpAutoPlacement.GetAsBool())
+		assert.Equal(t, 0, Params.RGMaxReplicaNum.GetAsInt())
 	})
 
 	t.Run("test queryNodeConfig", func(t *testing.T) {